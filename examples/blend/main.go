@@ -65,6 +65,10 @@ func main() {
 		"screen":     fxblend.FXBlendScreen,
 		"overlay":    fxblend.FXBlendOverlay,
 		"difference": fxblend.FXBlendDifference,
+		"hue":        fxblend.FXBlendHue,
+		"saturation": fxblend.FXBlendSaturation,
+		"color":      fxblend.FXBlendColor,
+		"luminosity": fxblend.FXBlendLuminosity,
 	}
 
 	outputNode := fximage.NewFXImageOutput()
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"time"
+
+	"kdfx/pkg/fxanim"
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fximage"
+	"kdfx/pkg/fxlib/fxblur"
+	"kdfx/pkg/fxlib/fxcolor"
+	"kdfx/pkg/fxvideo"
+)
+
+func main() {
+	width, height := 512, 512
+	ctx, err := fxcontext.NewFXOffscreenContext(width, height)
+	if err != nil {
+		panic(err)
+	}
+	defer ctx.Destroy()
+
+	// 1. Create a test image (Checkered pattern)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (x/32+y/32)%2 == 0 {
+				img.Set(x, y, color.RGBA{255, 255, 255, 255})
+			} else {
+				img.Set(x, y, color.RGBA{0, 0, 0, 255})
+			}
+		}
+	}
+	saveImage("input.png", img)
+
+	inputNode, err := fximage.NewFXImageInputFromFile("input.png")
+	if err != nil {
+		panic(err)
+	}
+
+	// 2. Build Graph
+	bcNode, err := fxcolor.NewFXColorAdjustmentNode(ctx, width, height)
+	if err != nil {
+		panic(err)
+	}
+	bcNode.SetInput("u_texture", inputNode)
+
+	mbNode, err := fxblur.NewFXMotionBlurNode(ctx, width, height)
+	if err != nil {
+		panic(err)
+	}
+	mbNode.SetInput("u_texture", bcNode)
+
+	// 3. Keyframe Tracks
+	duration := 8 * time.Second
+	anim := fxvideo.NewFXAnimation(duration, 30, nil)
+
+	// Brightness pulses up and back down every two seconds.
+	brightness, err := fxanim.NewFXParamTrack(bcNode, "brightness")
+	if err != nil {
+		panic(err)
+	}
+	must(brightness.AddKeyframe(0, fxanim.FXEaseInOutSine, 0.0))
+	must(brightness.AddKeyframe(time.Second, nil, 0.4))
+	brightness.SetLoopMode(fxanim.FXLoopPingPong)
+	anim.AddTrack(brightness)
+
+	// The image slides in with an overshoot, then snaps through two positions.
+	position := fxanim.NewFXPositionTrack(bcNode)
+	must(position.AddKeyframe(0, fxanim.FXEaseOutBack, -1.0, 0.0))
+	must(position.AddKeyframe(2*time.Second, fxanim.FXEaseStep, 0.0, 0.0))
+	must(position.AddKeyframe(4*time.Second, fxanim.FXEaseStep, 0.2, 0.2))
+	must(position.AddKeyframe(6*time.Second, fxanim.FXCubicBezier(0.25, 0.1, 0.25, 1.0), -0.2, -0.2))
+	must(position.AddKeyframe(8*time.Second, nil, 0.0, 0.0))
+	anim.AddTrack(position)
+
+	// One full turn, easing in and out.
+	rotation := fxanim.NewFXRotationTrack(bcNode)
+	must(rotation.AddKeyframe(0, fxanim.FXEaseInOutCubic, 0.0))
+	must(rotation.AddKeyframe(duration, nil, 2*math.Pi))
+	anim.AddTrack(rotation)
+
+	// The motion blur follows the rotation and fades out.
+	angle, err := fxanim.NewFXParamTrack(mbNode, "angle")
+	if err != nil {
+		panic(err)
+	}
+	must(angle.AddKeyframe(0, nil, 0.0))
+	must(angle.AddKeyframe(duration, nil, 360.0))
+	anim.AddTrack(angle)
+
+	strength, err := fxanim.NewFXParamTrack(mbNode, "strength")
+	if err != nil {
+		panic(err)
+	}
+	must(strength.AddKeyframe(0, fxanim.FXEaseOutExpo, 0.08))
+	must(strength.AddKeyframe(duration, nil, 0.0))
+	anim.AddTrack(strength)
+
+	// 4. Render to MP4
+	outFile, err := os.Create("output_keyframes.mp4")
+	if err != nil {
+		panic(err)
+	}
+	defer outFile.Close()
+
+	fmt.Println("Rendering animation to output_keyframes.mp4...")
+	startTime := time.Now()
+
+	if err := anim.Render(ctx, mbNode, outFile); err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("Done! Rendered in %v\n", time.Since(startTime))
+}
+
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func saveImage(filename string, img image.Image) {
+	f, err := os.Create(filename)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	png.Encode(f, img)
+}
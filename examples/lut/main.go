@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxcore"
+	"kdfx/pkg/fximage"
+	"kdfx/pkg/fxlib/fxcolor"
+	"kdfx/pkg/fxnode"
+)
+
+func main() {
+	width, height := 512, 512
+	ctx, err := fxcontext.NewFXOffscreenContext(width, height)
+	if err != nil {
+		panic(err)
+	}
+	defer ctx.Destroy()
+
+	// 1. Create Test Image (Hue and Brightness Gradient)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r := uint8(x * 255 / (width - 1))
+			g := uint8(y * 255 / (height - 1))
+			b := uint8(255 - int(r)/2 - int(g)/2)
+			img.Set(x, y, color.RGBA{r, g, b, 255})
+		}
+	}
+	saveImage("input.png", img)
+	inputNode, err := fximage.NewFXImageInputFromFile("input.png")
+	if err != nil {
+		panic(err)
+	}
+
+	// 2. Bake a Look into a .cube File
+	// The look is built at the size of the table, from nodes that treat each pixel on its own.
+	fmt.Println("Baking look.cube...")
+	var look []fxnode.FXNode
+	lut, err := fxcolor.FXBakeLUT(ctx, 33, func(input fxnode.FXInput, w, h int) (fxnode.FXNode, error) {
+		balance, err := fxcolor.NewFXColorBalanceNode(ctx, w, h)
+		if err != nil {
+			return nil, err
+		}
+		look = append(look, balance)
+		balance.SetShadows(0.0, 0.05, 0.15)
+		balance.SetHighlights(0.15, 0.05, -0.1)
+		balance.SetInput("u_texture", input)
+
+		levels, err := fxcolor.NewFXLevelsNode(ctx, w, h)
+		if err != nil {
+			return nil, err
+		}
+		look = append(look, levels)
+		levels.SetInputLevels(0.05, 0.95)
+		levels.SetInput("u_texture", balance)
+
+		// Float outputs keep full precision between the nodes.
+		for _, node := range look {
+			if err := node.SetFormat(fxcore.FXTextureRGBA16F); err != nil {
+				fmt.Println("Float textures not supported, baking with 8 bits:", err)
+				break
+			}
+		}
+		return levels, nil
+	})
+	for _, node := range look {
+		node.Release()
+	}
+	if err != nil {
+		panic(err)
+	}
+	lut.Title = "Teal and Orange"
+	if err := fxcolor.FXSaveCubeLUT("look.cube", lut); err != nil {
+		panic(err)
+	}
+
+	// 3. Apply the .cube File
+	fmt.Println("Applying look.cube...")
+	lutNode, err := fxcolor.NewFXLUTNode(ctx, width, height)
+	if err != nil {
+		panic(err)
+	}
+	defer lutNode.Release()
+	if err := lutNode.LoadLUT("look.cube"); err != nil {
+		panic(err)
+	}
+	lutNode.SetInput("u_texture", inputNode)
+
+	outputNode := fximage.NewFXImageOutput()
+	outputNode.SetInput(lutNode)
+	if err := outputNode.Process(ctx); err != nil {
+		panic(err)
+	}
+	if err := outputNode.Save("output_lut.png"); err != nil {
+		panic(err)
+	}
+
+	// 4. Apply Half of the Look
+	lutNode.SetMix(0.5)
+	if err := outputNode.Process(ctx); err != nil {
+		panic(err)
+	}
+	if err := outputNode.Save("output_lut_half.png"); err != nil {
+		panic(err)
+	}
+
+	fmt.Println("Done! Check look.cube and output_lut*.png files.")
+}
+
+func saveImage(filename string, img image.Image) {
+	f, err := os.Create(filename)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	png.Encode(f, img)
+}
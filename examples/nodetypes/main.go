@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"kdfx/pkg/fxnode"
+
+	// Register the built-in node types.
+	_ "kdfx/pkg/fxlib/fxartistic"
+	_ "kdfx/pkg/fxlib/fxblend"
+	_ "kdfx/pkg/fxlib/fxblur"
+	_ "kdfx/pkg/fxlib/fxcolor"
+	_ "kdfx/pkg/fxlib/fxdistortion"
+	_ "kdfx/pkg/fxlib/fxtransition"
+)
+
+// Lists the registered node types and their parameters.
+// Pass a type name to show only that type.
+func main() {
+	types := fxnode.FXNodeTypes()
+	if len(os.Args) > 1 {
+		nodeType, ok := fxnode.FXLookupNodeType(os.Args[1])
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown node type %q\n", os.Args[1])
+			os.Exit(1)
+		}
+		types = []fxnode.FXNodeType{nodeType}
+	}
+
+	for _, nodeType := range types {
+		fmt.Printf("%s (%s): %s\n", nodeType.Name, nodeType.Category, nodeType.Description)
+		fmt.Printf("  inputs: %s\n", strings.Join(nodeType.Inputs, ", "))
+		for _, param := range nodeType.Params {
+			line := fmt.Sprintf("  %-20s %-5s default %v", param.Name, param.Kind, param.Default)
+			if len(param.Options) > 0 {
+				line += fmt.Sprintf(", one of %s", strings.Join(param.Options, "|"))
+			} else if param.Min != param.Max {
+				line += fmt.Sprintf(", range %v to %v", param.Min, param.Max)
+			}
+			fmt.Printf("%s\n      %s\n", line, param.Description)
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fximage"
+	"kdfx/pkg/fxnode"
+	"kdfx/pkg/fxpreset"
+)
+
+func main() {
+	width, height := 512, 512
+	ctx, err := fxcontext.NewFXOffscreenContext(width, height)
+	if err != nil {
+		panic(err)
+	}
+	defer ctx.Destroy()
+
+	// 1. Create Test Image (Gradient with Circle) next to the preset
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			dx, dy := float64(x-width/2), float64(y-height/2)
+			if dx*dx+dy*dy < 100*100 {
+				img.Set(x, y, color.RGBA{255, 220, 120, 255})
+			} else {
+				img.Set(x, y, color.RGBA{uint8(x / 4), 0, uint8(y / 4), 255})
+			}
+		}
+	}
+	saveImage("input.png", img)
+
+	// 2. Load the preset
+	// Input paths are relative to the preset file, so run this from the example directory.
+	fmt.Println("Loading glow.yaml...")
+	graph, def, err := fxpreset.FXLoadGraph(ctx, "glow.yaml", nil)
+	if err != nil {
+		panic(err)
+	}
+	defer graph.Release()
+
+	// 3. Execute and save the result
+	pipeline := fxnode.NewFXPipeline(ctx, graph)
+	if err := pipeline.Execute(def.Output); err != nil {
+		panic(err)
+	}
+	outputNode := fximage.NewFXImageOutput()
+	outputNode.SetInput(graph.GetNode(def.Output))
+	if err := outputNode.Save("output_preset.png"); err != nil {
+		panic(err)
+	}
+
+	// 4. Tweak a parameter and write the graph back out as JSON
+	if blur := graph.GetNode("blur"); blur != nil {
+		nodeType, _ := fxnode.FXNodeTypeOf(blur)
+		if err := nodeType.SetParam(blur, "radius", 16); err != nil {
+			panic(err)
+		}
+	}
+	if err := fxpreset.FXSaveGraph("glow_strong.json", graph, def.Output); err != nil {
+		panic(err)
+	}
+
+	fmt.Println("Done! Check output_preset.png and glow_strong.json.")
+}
+
+func saveImage(filename string, img image.Image) {
+	f, err := os.Create(filename)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	png.Encode(f, img)
+}
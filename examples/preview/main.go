@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"time"
+
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fximage"
+	"kdfx/pkg/fxlib/fxcolor"
+	"kdfx/pkg/fxlib/fxdistortion"
+	"kdfx/pkg/fxpreview"
+)
+
+func main() {
+	width, height := 512, 512
+	ctx, err := fxcontext.NewFXWindowContext(width, height, "kdfx preview")
+	if err != nil {
+		panic(err)
+	}
+	defer ctx.Destroy()
+
+	// 1. Create a test image (Checkered pattern)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (x/32+y/32)%2 == 0 {
+				img.Set(x, y, color.RGBA{255, 200, 0, 255})
+			} else {
+				img.Set(x, y, color.RGBA{0, 64, 128, 255})
+			}
+		}
+	}
+	saveImage("input.png", img)
+
+	inputNode, err := fximage.NewFXImageInputFromFile("input.png")
+	if err != nil {
+		panic(err)
+	}
+
+	// 2. Build Graph
+	adjustNode, err := fxcolor.NewFXColorAdjustmentNode(ctx, width, height)
+	if err != nil {
+		panic(err)
+	}
+	adjustNode.SetInput("u_texture", inputNode)
+
+	twirlNode, err := fxdistortion.NewFXTwirlNode(ctx, width, height)
+	if err != nil {
+		panic(err)
+	}
+	twirlNode.SetInput("u_texture", adjustNode)
+
+	// 3. Setup Preview
+	preview, err := fxpreview.NewFXPreview(ctx, 30)
+	if err != nil {
+		panic(err)
+	}
+	defer preview.Release()
+
+	preview.AddNode("twirl", twirlNode)
+	preview.AddNode("adjust", adjustNode)
+
+	// Animate the twirl angle over time.
+	preview.SetUpdate(func(t time.Duration) {
+		twirlNode.SetAngle(float32(3.0 * math.Sin(t.Seconds())))
+	})
+
+	// Up/Down tweak saturation live.
+	saturation := float32(1.0)
+	preview.SetKeyHandler(func(key fxcontext.FXKey) {
+		switch key {
+		case fxcontext.FXKeyUp:
+			saturation += 0.1
+		case fxcontext.FXKeyDown:
+			saturation = float32(math.Max(0, float64(saturation-0.1)))
+		default:
+			return
+		}
+		adjustNode.SetSaturation(saturation)
+		fmt.Printf("Saturation: %.1f\n", saturation)
+	})
+
+	fmt.Println("Space: pause, Right: step, Tab: switch node, Up/Down: saturation, Esc: quit")
+	if err := preview.Run(); err != nil {
+		panic(err)
+	}
+}
+
+func saveImage(filename string, img image.Image) {
+	f, err := os.Create(filename)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	png.Encode(f, img)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxvideo"
+)
+
+func main() {
+	// Use the output from animation example as input
+	inputPath := "output.mp4"
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		fmt.Println("Error: output.mp4 not found. Please run examples/animation/main.go first.")
+		return
+	}
+
+	width, height := 512, 512
+	ctx, err := fxcontext.NewFXOffscreenContext(width, height)
+	if err != nil {
+		panic(err)
+	}
+	defer ctx.Destroy()
+
+	// 1. Create Timeline
+	timeline, err := fxvideo.NewFXTimeline(ctx, width, height)
+	if err != nil {
+		panic(err)
+	}
+	defer timeline.Release()
+
+	track, err := timeline.AddTrack()
+	if err != nil {
+		panic(err)
+	}
+
+	// 2. Add Clips
+	// The first clip plays 5 seconds from the start of the video.
+	first, err := fxvideo.NewFXVideoInputNode(ctx, inputPath)
+	if err != nil {
+		panic(err)
+	}
+	if err := track.AddClip(fxvideo.FXClip{Node: first, Out: 5 * time.Second}); err != nil {
+		panic(err)
+	}
+
+	// The second clip plays 10 seconds from the middle of the video at double speed.
+	// It starts one second before the first clip ends, to crossfade between them.
+	second, err := fxvideo.NewFXVideoInputNode(ctx, inputPath)
+	if err != nil {
+		panic(err)
+	}
+	crossfade, err := fxvideo.NewFXCrossfadeTransition(ctx, width, height)
+	if err != nil {
+		panic(err)
+	}
+	if err := track.AddClip(fxvideo.FXClip{
+		Node:       second,
+		Start:      4 * time.Second,
+		In:         30 * time.Second,
+		Out:        40 * time.Second,
+		Speed:      2,
+		Transition: crossfade,
+	}); err != nil {
+		panic(err)
+	}
+
+	// 3. Render Output
+	outFile, err := os.Create("timeline_out.mp4")
+	if err != nil {
+		panic(err)
+	}
+	defer outFile.Close()
+
+	anim := fxvideo.NewFXTimelineAnimation(timeline, 30, nil)
+
+	fmt.Printf("Rendering timeline_out.mp4 (%v)...\n", timeline.Duration())
+	startTime := time.Now()
+
+	if err := anim.Render(ctx, timeline, outFile); err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("Done! Rendered in %v\n", time.Since(startTime))
+}
@@ -23,9 +23,10 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
-	fmt.Printf("Input Video: %dx%d @ %d fps, Duration: %v\n", info.Width, info.Height, info.FPS, info.Duration)
+	fmt.Printf("Input Video: %dx%d @ %.3f fps (%d frames), Duration: %v\n", info.Width, info.Height, info.FrameRate.Float64(), info.FrameCount, info.Duration)
 
-	width, height := info.Width, info.Height
+	// The video node outputs frames rotated and stretched as a player shows them.
+	width, height := info.DisplaySize()
 	ctx, err := fxcontext.NewFXOffscreenContext(width, height)
 	if err != nil {
 		panic(err)
@@ -63,6 +64,10 @@ func main() {
 		videoNode.SetTime(t)
 	})
 
+	// Keep the audio of the input, if any. It plays once and is padded with silence
+	// while the video loops.
+	anim.SetAudio(&fxvideo.FXAudioSource{Path: inputPath})
+
 	fmt.Println("Rendering video_out.mp4...")
 	startTime := time.Now()
 
@@ -5,4 +5,5 @@ go 1.25.0
 require (
 	github.com/go-gl/gl v0.0.0-20231021071112-07e5d0ea2e71
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20250301202403-da16c1255728
+	gopkg.in/yaml.v3 v3.0.1
 )
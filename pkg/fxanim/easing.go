@@ -0,0 +1,165 @@
+// Package fxanim provides keyframe animation of node parameters with easing curves.
+package fxanim
+
+import (
+	"math"
+)
+
+// FXEasing maps the progress between two keyframes (0 to 1) to an interpolation factor.
+// The factor is usually in 0 to 1 as well, but curves such as FXEaseOutBack overshoot.
+type FXEasing func(t float64) float64
+
+// FXEaseLinear interpolates at constant speed.
+func FXEaseLinear(t float64) float64 { return t }
+
+// FXEaseStep holds the value of the keyframe until the next keyframe is reached.
+func FXEaseStep(t float64) float64 {
+	if t >= 1 {
+		return 1
+	}
+	return 0
+}
+
+// FXEaseInQuad starts slowly and accelerates.
+func FXEaseInQuad(t float64) float64 { return t * t }
+
+// FXEaseOutQuad starts quickly and decelerates.
+func FXEaseOutQuad(t float64) float64 { return t * (2 - t) }
+
+// FXEaseInOutQuad accelerates until halfway, then decelerates.
+func FXEaseInOutQuad(t float64) float64 {
+	if t < 0.5 {
+		return 2 * t * t
+	}
+	return -1 + (4-2*t)*t
+}
+
+// FXEaseInCubic starts slowly and accelerates, more strongly than FXEaseInQuad.
+func FXEaseInCubic(t float64) float64 { return t * t * t }
+
+// FXEaseOutCubic starts quickly and decelerates, more strongly than FXEaseOutQuad.
+func FXEaseOutCubic(t float64) float64 {
+	u := t - 1
+	return u*u*u + 1
+}
+
+// FXEaseInOutCubic accelerates until halfway, then decelerates.
+func FXEaseInOutCubic(t float64) float64 {
+	if t < 0.5 {
+		return 4 * t * t * t
+	}
+	u := 2*t - 2
+	return 0.5*u*u*u + 1
+}
+
+// FXEaseInSine starts slowly following a sine curve.
+func FXEaseInSine(t float64) float64 { return 1 - math.Cos(t*math.Pi/2) }
+
+// FXEaseOutSine decelerates following a sine curve.
+func FXEaseOutSine(t float64) float64 { return math.Sin(t * math.Pi / 2) }
+
+// FXEaseInOutSine accelerates and decelerates following a sine curve.
+func FXEaseInOutSine(t float64) float64 { return 0.5 * (1 - math.Cos(t*math.Pi)) }
+
+// FXEaseInExpo starts very slowly and accelerates exponentially.
+func FXEaseInExpo(t float64) float64 {
+	if t <= 0 {
+		return 0
+	}
+	return math.Pow(2, 10*(t-1))
+}
+
+// FXEaseOutExpo decelerates exponentially.
+func FXEaseOutExpo(t float64) float64 {
+	if t >= 1 {
+		return 1
+	}
+	return 1 - math.Pow(2, -10*t)
+}
+
+// FXEaseOutBack overshoots the target slightly before settling.
+func FXEaseOutBack(t float64) float64 {
+	const c1 = 1.70158
+	const c3 = c1 + 1
+	u := t - 1
+	return 1 + c3*u*u*u + c1*u*u
+}
+
+// FXEaseOutBounce bounces against the target like a dropped ball.
+func FXEaseOutBounce(t float64) float64 {
+	const n1 = 7.5625
+	const d1 = 2.75
+	switch {
+	case t < 1/d1:
+		return n1 * t * t
+	case t < 2/d1:
+		t -= 1.5 / d1
+		return n1*t*t + 0.75
+	case t < 2.5/d1:
+		t -= 2.25 / d1
+		return n1*t*t + 0.9375
+	default:
+		t -= 2.625 / d1
+		return n1*t*t + 0.984375
+	}
+}
+
+// FXCubicBezier returns an easing defined by a cubic Bezier curve from (0, 0) to (1, 1)
+// with control points (x1, y1) and (x2, y2), like CSS cubic-bezier().
+// x1 and x2 are clamped to 0 to 1 so the curve is a function of time.
+func FXCubicBezier(x1, y1, x2, y2 float64) FXEasing {
+	x1 = math.Max(0, math.Min(1, x1))
+	x2 = math.Max(0, math.Min(1, x2))
+
+	// Polynomial coefficients of the curve: b(s) = ((a*s + b)*s + c)*s.
+	cx := 3 * x1
+	bx := 3*(x2-x1) - cx
+	ax := 1 - cx - bx
+	cy := 3 * y1
+	by := 3*(y2-y1) - cy
+	ay := 1 - cy - by
+
+	sampleX := func(s float64) float64 { return ((ax*s+bx)*s + cx) * s }
+	sampleY := func(s float64) float64 { return ((ay*s+by)*s + cy) * s }
+	slopeX := func(s float64) float64 { return (3*ax*s+2*bx)*s + cx }
+
+	return func(t float64) float64 {
+		if t <= 0 {
+			return 0
+		}
+		if t >= 1 {
+			return 1
+		}
+
+		// Solve x(s) = t with Newton's method, falling back to bisection
+		// where the slope is too flat to converge.
+		s := t
+		for i := 0; i < 8; i++ {
+			dx := sampleX(s) - t
+			if math.Abs(dx) < 1e-7 {
+				return sampleY(s)
+			}
+			d := slopeX(s)
+			if math.Abs(d) < 1e-6 {
+				break
+			}
+			s -= dx / d
+		}
+
+		lo, hi := 0.0, 1.0
+		s = t
+		for i := 0; i < 32; i++ {
+			x := sampleX(s)
+			if math.Abs(x-t) < 1e-7 {
+				break
+			}
+			if x < t {
+				lo = s
+			} else {
+				hi = s
+			}
+			s = (lo + hi) / 2
+		}
+		return sampleY(s)
+	}
+}
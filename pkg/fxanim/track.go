@@ -0,0 +1,257 @@
+package fxanim
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"kdfx/pkg/fxnode"
+)
+
+// FXLoopMode defines how a track behaves after its last keyframe.
+type FXLoopMode int
+
+const (
+	// FXLoopNone holds the first value before the first keyframe and the last value after the last one.
+	FXLoopNone FXLoopMode = iota
+	// FXLoopRepeat restarts from the first keyframe after reaching the last one.
+	FXLoopRepeat
+	// FXLoopPingPong plays the keyframes forwards and backwards alternately.
+	FXLoopPingPong
+)
+
+// FXKeyframe is a value of a track at a point in time.
+type FXKeyframe struct {
+	// Time is the time of the keyframe.
+	Time time.Duration
+	// Value holds one component per track dimension.
+	Value []float32
+	// Easing shapes the interpolation from this keyframe to the next one.
+	// A nil easing is linear.
+	Easing FXEasing
+}
+
+// FXTrack animates a value with one or more components using keyframes.
+type FXTrack interface {
+	// AddKeyframe adds a keyframe, replacing any keyframe at the same time.
+	// The easing applies to the segment starting at this keyframe.
+	// It returns an error if the number of values does not match the track size.
+	AddKeyframe(t time.Duration, easing FXEasing, value ...float32) error
+	// GetKeyframes returns the keyframes ordered by time.
+	GetKeyframes() []FXKeyframe
+	// SetLoopMode sets how the track behaves outside its keyframes.
+	SetLoopMode(mode FXLoopMode)
+	// Duration returns the time of the last keyframe.
+	Duration() time.Duration
+	// Evaluate returns the value of the track at time t, or nil if it has no keyframes.
+	Evaluate(t time.Duration) []float32
+	// Apply evaluates the track at time t and applies the value to its target.
+	// The target is only updated when the value changes, so static segments do not dirty nodes.
+	Apply(t time.Duration)
+}
+
+// fxTrack implements FXTrack.
+type fxTrack struct {
+	// size is the number of components of the animated value.
+	size int
+	// keyframes are the keyframes ordered by time.
+	keyframes []FXKeyframe
+	// loop is the loop mode.
+	loop FXLoopMode
+	// apply sets the value on the target.
+	apply func(value []float32)
+	// last is the last applied value, used to skip redundant updates.
+	last []float32
+}
+
+// NewFXTrack creates a track with the given number of components that passes its value to apply.
+func NewFXTrack(size int, apply func(value []float32)) FXTrack {
+	return &fxTrack{
+		size:  size,
+		apply: apply,
+	}
+}
+
+// NewFXUniformTrack creates a track that sets a uniform of the node.
+// A size of 1 sets a float32 uniform; sizes 2 and 3 set vec2 and vec3 uniforms.
+func NewFXUniformTrack(node fxnode.FXNode, uniform string, size int) FXTrack {
+	return NewFXTrack(size, func(value []float32) {
+		if size == 1 {
+			node.SetUniform(uniform, value[0])
+			return
+		}
+		node.SetUniform(uniform, append([]float32(nil), value...))
+	})
+}
+
+// NewFXParamTrack creates a track that sets a parameter of a registered node type, such as
+// "radius" of a gaussianBlur node. This goes through the node's setter, so it also works for
+// parameters that are not plain uniforms. Int parameters are rounded and bool parameters are
+// true from 0.5 upwards.
+func NewFXParamTrack(node fxnode.FXNode, name string) (FXTrack, error) {
+	nodeType, ok := fxnode.FXNodeTypeOf(node)
+	if !ok {
+		return nil, fmt.Errorf("node type %T is not registered", node)
+	}
+	param, ok := nodeType.GetParam(name)
+	if !ok {
+		return nil, fmt.Errorf("node type %s has no parameter %s", nodeType.Name, name)
+	}
+
+	size := 1
+	switch param.Kind {
+	case fxnode.FXParamVec2:
+		size = 2
+	case fxnode.FXParamVec3:
+		size = 3
+	}
+
+	return NewFXTrack(size, func(value []float32) {
+		var v interface{}
+		switch param.Kind {
+		case fxnode.FXParamFloat:
+			v = value[0]
+		case fxnode.FXParamInt:
+			v = int(math.Round(float64(value[0])))
+		case fxnode.FXParamBool:
+			v = value[0] >= 0.5
+		default:
+			v = append([]float32(nil), value...)
+		}
+		// Out of range enumerated values are ignored.
+		if converted, err := param.Convert(v); err == nil {
+			param.Set(node, converted)
+		}
+	}), nil
+}
+
+// NewFXPositionTrack creates a two-component track that sets the position of the node.
+func NewFXPositionTrack(node fxnode.FXNode) FXTrack {
+	return NewFXTrack(2, func(value []float32) { node.SetPosition(value[0], value[1]) })
+}
+
+// NewFXSizeTrack creates a two-component track that sets the size of the node.
+func NewFXSizeTrack(node fxnode.FXNode) FXTrack {
+	return NewFXTrack(2, func(value []float32) { node.SetSize(value[0], value[1]) })
+}
+
+// NewFXRotationTrack creates a track that sets the rotation of the node in radians.
+func NewFXRotationTrack(node fxnode.FXNode) FXTrack {
+	return NewFXTrack(1, func(value []float32) { node.SetRotation(value[0]) })
+}
+
+func (tr *fxTrack) AddKeyframe(t time.Duration, easing FXEasing, value ...float32) error {
+	if len(value) != tr.size {
+		return fmt.Errorf("keyframe at %v has %d values, track expects %d", t, len(value), tr.size)
+	}
+	kf := FXKeyframe{Time: t, Value: append([]float32(nil), value...), Easing: easing}
+
+	// Keep the keyframes sorted by time.
+	i := sort.Search(len(tr.keyframes), func(i int) bool { return tr.keyframes[i].Time >= t })
+	if i < len(tr.keyframes) && tr.keyframes[i].Time == t {
+		tr.keyframes[i] = kf
+		return nil
+	}
+	tr.keyframes = append(tr.keyframes, FXKeyframe{})
+	copy(tr.keyframes[i+1:], tr.keyframes[i:])
+	tr.keyframes[i] = kf
+	return nil
+}
+
+func (tr *fxTrack) GetKeyframes() []FXKeyframe {
+	return append([]FXKeyframe(nil), tr.keyframes...)
+}
+
+func (tr *fxTrack) SetLoopMode(mode FXLoopMode) {
+	tr.loop = mode
+}
+
+func (tr *fxTrack) Duration() time.Duration {
+	if len(tr.keyframes) == 0 {
+		return 0
+	}
+	return tr.keyframes[len(tr.keyframes)-1].Time
+}
+
+// localTime maps t into the keyframe range according to the loop mode.
+func (tr *fxTrack) localTime(t time.Duration) time.Duration {
+	start := tr.keyframes[0].Time
+	span := tr.keyframes[len(tr.keyframes)-1].Time - start
+	if span <= 0 || t <= start || tr.loop == FXLoopNone {
+		return t
+	}
+
+	elapsed := t - start
+	switch tr.loop {
+	case FXLoopRepeat:
+		// Land on the last keyframe at the end of each cycle rather than jumping back to the first.
+		if elapsed%span == 0 {
+			return start + span
+		}
+		return start + elapsed%span
+	case FXLoopPingPong:
+		cycle := elapsed % (2 * span)
+		if cycle > span {
+			cycle = 2*span - cycle
+		}
+		return start + cycle
+	}
+	return t
+}
+
+func (tr *fxTrack) Evaluate(t time.Duration) []float32 {
+	n := len(tr.keyframes)
+	if n == 0 {
+		return nil
+	}
+	t = tr.localTime(t)
+
+	// Hold the end values outside the keyframe range.
+	if t <= tr.keyframes[0].Time {
+		return append([]float32(nil), tr.keyframes[0].Value...)
+	}
+	if t >= tr.keyframes[n-1].Time {
+		return append([]float32(nil), tr.keyframes[n-1].Value...)
+	}
+
+	// Find the segment [a, b] containing t.
+	i := sort.Search(n, func(i int) bool { return tr.keyframes[i].Time > t })
+	a, b := tr.keyframes[i-1], tr.keyframes[i]
+	progress := float64(t-a.Time) / float64(b.Time-a.Time)
+	f := progress
+	if a.Easing != nil {
+		f = a.Easing(progress)
+	}
+
+	value := make([]float32, tr.size)
+	for c := range value {
+		value[c] = a.Value[c] + (b.Value[c]-a.Value[c])*float32(f)
+	}
+	return value
+}
+
+func (tr *fxTrack) Apply(t time.Duration) {
+	value := tr.Evaluate(t)
+	if value == nil || tr.apply == nil {
+		return
+	}
+	if tr.last != nil && equalValues(tr.last, value) {
+		return
+	}
+	tr.apply(value)
+	tr.last = value
+}
+
+// equalValues reports whether two values have identical components.
+func equalValues(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
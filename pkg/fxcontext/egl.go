@@ -0,0 +1,225 @@
+//go:build linux
+
+package fxcontext
+
+/*
+#cgo LDFLAGS: -lEGL
+#include <stdlib.h>
+#include <EGL/egl.h>
+#include <EGL/eglext.h>
+
+#ifndef EGL_PLATFORM_SURFACELESS_MESA
+#define EGL_PLATFORM_SURFACELESS_MESA 0x31DD
+#endif
+
+// kdfxGetSurfacelessDisplay returns a display on the Mesa surfaceless platform,
+// or EGL_NO_DISPLAY if the platform extension is not available.
+static EGLDisplay kdfxGetSurfacelessDisplay() {
+	const char* exts = eglQueryString(EGL_NO_DISPLAY, EGL_EXTENSIONS);
+	if (exts == NULL) {
+		return EGL_NO_DISPLAY;
+	}
+	PFNEGLGETPLATFORMDISPLAYEXTPROC getPlatformDisplay =
+		(PFNEGLGETPLATFORMDISPLAYEXTPROC) eglGetProcAddress("eglGetPlatformDisplayEXT");
+	if (getPlatformDisplay == NULL) {
+		return EGL_NO_DISPLAY;
+	}
+	return getPlatformDisplay(EGL_PLATFORM_SURFACELESS_MESA, EGL_DEFAULT_DISPLAY, NULL);
+}
+
+static EGLDisplay kdfxGetDefaultDisplay() {
+	return eglGetDisplay(EGL_DEFAULT_DISPLAY);
+}
+
+static void* kdfxGetProcAddress(const char* name) {
+	return (void*) eglGetProcAddress(name);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.1/gles2"
+)
+
+// fxEGLContext implements FXContext using EGL without any window system.
+type fxEGLContext struct {
+	// display is the EGL display connection.
+	display C.EGLDisplay
+	// surface is the pbuffer surface, or EGL_NO_SURFACE for surfaceless contexts.
+	surface C.EGLSurface
+	// context is the EGL rendering context.
+	context C.EGLContext
+	// width is the width of the context.
+	width int
+	// height is the height of the context.
+	height int
+}
+
+// NewFXEGLContext creates a new headless context with the specified dimensions.
+// It prefers the Mesa surfaceless platform (EGL_MESA_platform_surfaceless) and falls back
+// to the default EGL display, so it needs neither an X server nor a GLFW window.
+// A pbuffer surface is used when the config supports it, otherwise the context is made
+// current without a surface (EGL_KHR_surfaceless_context). All rendering targets FBOs either way.
+func NewFXEGLContext(width, height int) (FXContext, error) {
+	// Prefer the surfaceless platform, which works on bare servers with Mesa llvmpipe.
+	display := C.kdfxGetSurfacelessDisplay()
+	if display == 0 {
+		display = C.kdfxGetDefaultDisplay()
+	}
+	if display == 0 {
+		return nil, fmt.Errorf("failed to get egl display")
+	}
+
+	var major, minor C.EGLint
+	if C.eglInitialize(display, &major, &minor) == C.EGL_FALSE {
+		return nil, fmt.Errorf("failed to initialize egl: error %x", int(C.eglGetError()))
+	}
+
+	// Bind the OpenGL ES API, matching the GLES 2.0 context created by the GLFW implementation.
+	if C.eglBindAPI(C.EGL_OPENGL_ES_API) == C.EGL_FALSE {
+		C.eglTerminate(display)
+		return nil, fmt.Errorf("failed to bind gles api: error %x", int(C.eglGetError()))
+	}
+
+	// Try a pbuffer-capable config first, then any GLES2 config for a surfaceless context.
+	config, pbuffer, err := chooseEGLConfig(display)
+	if err != nil {
+		C.eglTerminate(display)
+		return nil, err
+	}
+
+	surface := C.EGLSurface(nil)
+	if pbuffer {
+		surfaceAttribs := []C.EGLint{
+			C.EGL_WIDTH, C.EGLint(width),
+			C.EGL_HEIGHT, C.EGLint(height),
+			C.EGL_NONE,
+		}
+		surface = C.eglCreatePbufferSurface(display, config, &surfaceAttribs[0])
+		if surface == nil {
+			C.eglTerminate(display)
+			return nil, fmt.Errorf("failed to create pbuffer surface: error %x", int(C.eglGetError()))
+		}
+	} else if !hasEGLExtension(display, "EGL_KHR_surfaceless_context") {
+		C.eglTerminate(display)
+		return nil, fmt.Errorf("egl display supports neither pbuffers nor surfaceless contexts")
+	}
+
+	contextAttribs := []C.EGLint{
+		C.EGL_CONTEXT_CLIENT_VERSION, 2,
+		C.EGL_NONE,
+	}
+	context := C.eglCreateContext(display, config, nil, &contextAttribs[0])
+	if context == nil {
+		if surface != nil {
+			C.eglDestroySurface(display, surface)
+		}
+		C.eglTerminate(display)
+		return nil, fmt.Errorf("failed to create egl context: error %x", int(C.eglGetError()))
+	}
+
+	c := &fxEGLContext{
+		display: display,
+		surface: surface,
+		context: context,
+		width:   width,
+		height:  height,
+	}
+
+	// Make the context current immediately so we can initialize GLES.
+	if C.eglMakeCurrent(display, surface, surface, context) == C.EGL_FALSE {
+		err := fmt.Errorf("failed to make egl context current: error %x", int(C.eglGetError()))
+		c.Destroy()
+		return nil, err
+	}
+
+	// Initialize GLES bindings through EGL so we don't depend on GLX being usable.
+	if err := gles2.InitWithProcAddrFunc(eglGetProcAddress); err != nil {
+		c.Destroy()
+		return nil, fmt.Errorf("failed to initialize gles2: %v", err)
+	}
+
+	return c, nil
+}
+
+// chooseEGLConfig picks an RGBA8 GLES2 config, reporting whether it supports pbuffers.
+func chooseEGLConfig(display C.EGLDisplay) (C.EGLConfig, bool, error) {
+	for _, pbuffer := range []bool{true, false} {
+		attribs := []C.EGLint{
+			C.EGL_RED_SIZE, 8,
+			C.EGL_GREEN_SIZE, 8,
+			C.EGL_BLUE_SIZE, 8,
+			C.EGL_ALPHA_SIZE, 8,
+			C.EGL_RENDERABLE_TYPE, C.EGL_OPENGL_ES2_BIT,
+		}
+		if pbuffer {
+			attribs = append(attribs, C.EGL_SURFACE_TYPE, C.EGL_PBUFFER_BIT)
+		} else {
+			attribs = append(attribs, C.EGL_SURFACE_TYPE, 0)
+		}
+		attribs = append(attribs, C.EGL_NONE)
+
+		var config C.EGLConfig
+		var numConfigs C.EGLint
+		if C.eglChooseConfig(display, &attribs[0], &config, 1, &numConfigs) == C.EGL_TRUE && numConfigs > 0 {
+			return config, pbuffer, nil
+		}
+	}
+	return 0, false, fmt.Errorf("no suitable egl config found")
+}
+
+// hasEGLExtension reports whether the display advertises the named extension.
+func hasEGLExtension(display C.EGLDisplay, name string) bool {
+	exts := C.eglQueryString(display, C.EGL_EXTENSIONS)
+	if exts == nil {
+		return false
+	}
+	for _, ext := range strings.Fields(C.GoString(exts)) {
+		if ext == name {
+			return true
+		}
+	}
+	return false
+}
+
+// eglGetProcAddress resolves GLES entry points through EGL.
+func eglGetProcAddress(name string) unsafe.Pointer {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	return C.kdfxGetProcAddress(cname)
+}
+
+func (c *fxEGLContext) MakeCurrent() {
+	C.eglMakeCurrent(c.display, c.surface, c.surface, c.context)
+}
+
+func (c *fxEGLContext) SwapBuffers() {
+	// Pbuffers have no back buffer to present, and surfaceless contexts have no surface at all.
+	// Flushing keeps the semantics of "frame finished" consistent with the GLFW implementation.
+	gles2.Flush()
+}
+
+func (c *fxEGLContext) Destroy() {
+	// Release the context before destroying it, then tear down the display.
+	C.eglMakeCurrent(c.display, nil, nil, nil)
+	if c.context != nil {
+		C.eglDestroyContext(c.display, c.context)
+	}
+	if c.surface != nil {
+		C.eglDestroySurface(c.display, c.surface)
+	}
+	C.eglTerminate(c.display)
+}
+
+func (c *fxEGLContext) GetSize() (int, int) {
+	return c.width, c.height
+}
+
+func (c *fxEGLContext) Viewport(x, y, width, height int) {
+	// Set the OpenGL viewport.
+	gles2.Viewport(int32(x), int32(y), int32(width), int32(height))
+}
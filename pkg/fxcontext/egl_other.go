@@ -0,0 +1,11 @@
+//go:build !linux
+
+package fxcontext
+
+import "fmt"
+
+// NewFXEGLContext creates a new headless context with the specified dimensions.
+// Headless EGL contexts are only supported on Linux; other platforms return an error.
+func NewFXEGLContext(width, height int) (FXContext, error) {
+	return nil, fmt.Errorf("egl headless context is not supported on this platform")
+}
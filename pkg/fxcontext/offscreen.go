@@ -26,7 +26,23 @@ type fxOffscreenContext struct {
 // NewFXOffscreenContext creates a new offscreen context with the specified dimensions.
 // It initializes GLFW and creates a hidden window to provide an OpenGL ES 2.0 context.
 // This is suitable for headless rendering or background processing where no visible window is required.
+// If GLFW cannot provide a context (e.g. there is no display server), it falls back to NewFXEGLContext.
 func NewFXOffscreenContext(width, height int) (FXContext, error) {
+	ctx, err := newFXGLFWOffscreenContext(width, height)
+	if err == nil {
+		return ctx, nil
+	}
+
+	// Fall back to a surfaceless EGL context, which works without an X server.
+	eglCtx, eglErr := NewFXEGLContext(width, height)
+	if eglErr != nil {
+		return nil, fmt.Errorf("%v; egl fallback failed: %v", err, eglErr)
+	}
+	return eglCtx, nil
+}
+
+// newFXGLFWOffscreenContext creates the hidden GLFW window backed context.
+func newFXGLFWOffscreenContext(width, height int) (FXContext, error) {
 	// Initialize GLFW. This is required before creating any window.
 	if err := glfw.Init(); err != nil {
 		return nil, fmt.Errorf("failed to initialize glfw: %v", err)
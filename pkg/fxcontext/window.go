@@ -0,0 +1,170 @@
+package fxcontext
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.1/gles2"
+	"github.com/go-gl/glfw/v3.3/glfw"
+)
+
+// FXKey identifies a keyboard key. Values match GLFW key codes.
+type FXKey int
+
+const (
+	FXKeySpace  FXKey = FXKey(glfw.KeySpace)  // Space bar.
+	FXKeyEscape FXKey = FXKey(glfw.KeyEscape) // Escape key.
+	FXKeyEnter  FXKey = FXKey(glfw.KeyEnter)  // Enter/Return key.
+	FXKeyTab    FXKey = FXKey(glfw.KeyTab)    // Tab key.
+	FXKeyRight  FXKey = FXKey(glfw.KeyRight)  // Right arrow key.
+	FXKeyLeft   FXKey = FXKey(glfw.KeyLeft)   // Left arrow key.
+	FXKeyUp     FXKey = FXKey(glfw.KeyUp)     // Up arrow key.
+	FXKeyDown   FXKey = FXKey(glfw.KeyDown)   // Down arrow key.
+)
+
+// FXKeyCallback is called when a key is pressed or repeated.
+type FXKeyCallback func(key FXKey)
+
+// FXResizeCallback is called when the window framebuffer is resized.
+type FXResizeCallback func(width, height int)
+
+// FXWindowContext is an FXContext backed by a visible window.
+// It adds the event handling needed to drive an interactive render loop.
+type FXWindowContext interface {
+	FXContext
+	// ShouldClose returns true once the user has requested the window to close.
+	ShouldClose() bool
+	// SetShouldClose sets or clears the close flag of the window.
+	SetShouldClose(value bool)
+	// PollEvents processes pending window events and invokes the registered callbacks.
+	PollEvents()
+	// SetTitle sets the window title.
+	SetTitle(title string)
+	// SetKeyCallback registers a callback for key presses.
+	SetKeyCallback(cb FXKeyCallback)
+	// SetResizeCallback registers a callback for framebuffer resizes.
+	SetResizeCallback(cb FXResizeCallback)
+}
+
+// fxWindowContext implements FXWindowContext using a visible GLFW window.
+type fxWindowContext struct {
+	// window is the visible GLFW window used for the context.
+	window *glfw.Window
+	// width is the current framebuffer width of the window.
+	width int
+	// height is the current framebuffer height of the window.
+	height int
+	// onKey is the user callback for key presses.
+	onKey FXKeyCallback
+	// onResize is the user callback for framebuffer resizes.
+	onResize FXResizeCallback
+}
+
+// NewFXWindowContext creates a new visible, resizable window with the specified dimensions.
+// It provides an OpenGL ES 2.0 context like NewFXOffscreenContext, but the default framebuffer
+// is presented on screen by SwapBuffers. This is intended for interactive previews.
+func NewFXWindowContext(width, height int, title string) (FXWindowContext, error) {
+	// Initialize GLFW. This is required before creating any window.
+	if err := glfw.Init(); err != nil {
+		return nil, fmt.Errorf("failed to initialize glfw: %v", err)
+	}
+
+	// Set window hints for a visible, resizable window and OpenGL ES 2.0 context.
+	glfw.WindowHint(glfw.Visible, glfw.True)
+	glfw.WindowHint(glfw.Resizable, glfw.True)
+	glfw.WindowHint(glfw.ContextVersionMajor, 2)
+	glfw.WindowHint(glfw.ContextVersionMinor, 0)
+	glfw.WindowHint(glfw.ClientAPI, glfw.OpenGLESAPI)
+
+	// Create the window.
+	window, err := glfw.CreateWindow(width, height, title, nil, nil)
+	if err != nil {
+		glfw.Terminate()
+		return nil, fmt.Errorf("failed to create glfw window: %v", err)
+	}
+
+	// Make the context current immediately so we can initialize GLES.
+	window.MakeContextCurrent()
+	// Synchronize buffer swaps with the display refresh.
+	glfw.SwapInterval(1)
+
+	// Initialize GLES bindings.
+	if err := gles2.Init(); err != nil {
+		window.Destroy()
+		glfw.Terminate()
+		return nil, fmt.Errorf("failed to initialize gles2: %v", err)
+	}
+
+	// The framebuffer size can differ from the window size on HiDPI displays.
+	fbWidth, fbHeight := window.GetFramebufferSize()
+	c := &fxWindowContext{
+		window: window,
+		width:  fbWidth,
+		height: fbHeight,
+	}
+
+	window.SetFramebufferSizeCallback(func(w *glfw.Window, width, height int) {
+		c.width = width
+		c.height = height
+		if c.onResize != nil {
+			c.onResize(width, height)
+		}
+	})
+	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+		if action == glfw.Release {
+			return
+		}
+		if c.onKey != nil {
+			c.onKey(FXKey(key))
+		}
+	})
+
+	return c, nil
+}
+
+func (c *fxWindowContext) MakeCurrent() {
+	c.window.MakeContextCurrent()
+}
+
+func (c *fxWindowContext) SwapBuffers() {
+	// Present the default framebuffer on screen.
+	c.window.SwapBuffers()
+}
+
+func (c *fxWindowContext) Destroy() {
+	// Clean up the window and terminate GLFW.
+	c.window.Destroy()
+	glfw.Terminate()
+}
+
+func (c *fxWindowContext) GetSize() (int, int) {
+	return c.width, c.height
+}
+
+func (c *fxWindowContext) Viewport(x, y, width, height int) {
+	// Set the OpenGL viewport.
+	gles2.Viewport(int32(x), int32(y), int32(width), int32(height))
+}
+
+func (c *fxWindowContext) ShouldClose() bool {
+	return c.window.ShouldClose()
+}
+
+func (c *fxWindowContext) SetShouldClose(value bool) {
+	c.window.SetShouldClose(value)
+}
+
+func (c *fxWindowContext) PollEvents() {
+	glfw.PollEvents()
+}
+
+func (c *fxWindowContext) SetTitle(title string) {
+	c.window.SetTitle(title)
+}
+
+func (c *fxWindowContext) SetKeyCallback(cb FXKeyCallback) {
+	c.onKey = cb
+}
+
+func (c *fxWindowContext) SetResizeCallback(cb FXResizeCallback) {
+	c.onResize = cb
+}
@@ -0,0 +1,244 @@
+package fxcore
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/go-gl/gl/v3.1/gles2"
+)
+
+// FXTextureFormat is the storage format of a texture's pixels.
+type FXTextureFormat int
+
+const (
+	// FXTextureRGBA8 stores 8 bits per channel, normalized to 0 to 1. It is always supported.
+	FXTextureRGBA8 FXTextureFormat = iota
+	// FXTextureRGBA16F stores a 16-bit float per channel. Values are not clamped,
+	// which avoids banding and clipping in chains of effects.
+	FXTextureRGBA16F
+	// FXTextureRGBA32F stores a 32-bit float per channel.
+	FXTextureRGBA32F
+)
+
+// String returns the name of the format, as accepted by FXParseTextureFormat.
+func (f FXTextureFormat) String() string {
+	switch f {
+	case FXTextureRGBA8:
+		return "rgba8"
+	case FXTextureRGBA16F:
+		return "rgba16f"
+	case FXTextureRGBA32F:
+		return "rgba32f"
+	}
+	return fmt.Sprintf("FXTextureFormat(%d)", int(f))
+}
+
+// IsFloat returns true for the floating-point formats.
+func (f FXTextureFormat) IsFloat() bool {
+	return f == FXTextureRGBA16F || f == FXTextureRGBA32F
+}
+
+// FXParseTextureFormat parses a format name such as "rgba16f". Names are case-insensitive.
+func FXParseTextureFormat(name string) (FXTextureFormat, error) {
+	for _, f := range []FXTextureFormat{FXTextureRGBA8, FXTextureRGBA16F, FXTextureRGBA32F} {
+		if strings.EqualFold(name, f.String()) {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown texture format %q", name)
+}
+
+// fxHalfFloatOES is GL_HALF_FLOAT_OES from OES_texture_half_float.
+// It differs from the GLES 3 GL_HALF_FLOAT value.
+const fxHalfFloatOES = 0x8D61
+
+// fxGLCaps describes the float texture capabilities of the current context.
+type fxGLCaps struct {
+	// es3 is true for OpenGL ES 3.0 and later contexts.
+	es3 bool
+	// extensions is the set of supported extensions.
+	extensions map[string]bool
+	// supported caches the result of FXTextureFormatSupported.
+	supported map[FXTextureFormat]bool
+}
+
+// fxCaps caches the capabilities of the current context.
+var fxCaps *fxGLCaps
+
+// glCaps queries the capabilities of the current context once and caches them.
+func glCaps() *fxGLCaps {
+	if fxCaps != nil {
+		return fxCaps
+	}
+	caps := &fxGLCaps{
+		extensions: make(map[string]bool),
+		supported:  make(map[FXTextureFormat]bool),
+	}
+
+	// The version string has the form "OpenGL ES <major>.<minor> <vendor info>".
+	var major, minor int
+	version := gles2.GoStr(gles2.GetString(gles2.VERSION))
+	if _, err := fmt.Sscanf(strings.TrimPrefix(version, "OpenGL ES "), "%d.%d", &major, &minor); err == nil {
+		caps.es3 = major >= 3
+	}
+
+	if exts := gles2.GetString(gles2.EXTENSIONS); exts != nil {
+		for _, ext := range strings.Fields(gles2.GoStr(exts)) {
+			caps.extensions[ext] = true
+		}
+	}
+
+	fxCaps = caps
+	return caps
+}
+
+// glFormat returns the internal format, pixel format and type used to allocate the format.
+// GLES 3 uses sized internal formats; GLES 2 uses the OES float texture extensions.
+func (c *fxGLCaps) glFormat(format FXTextureFormat) (internalFormat int32, pixelFormat, pixelType uint32) {
+	switch format {
+	case FXTextureRGBA16F:
+		if c.es3 {
+			return gles2.RGBA16F, gles2.RGBA, gles2.HALF_FLOAT
+		}
+		return gles2.RGBA, gles2.RGBA, fxHalfFloatOES
+	case FXTextureRGBA32F:
+		if c.es3 {
+			return gles2.RGBA32F, gles2.RGBA, gles2.FLOAT
+		}
+		return gles2.RGBA, gles2.RGBA, gles2.FLOAT
+	}
+	return gles2.RGBA, gles2.RGBA, gles2.UNSIGNED_BYTE
+}
+
+// linearFilter reports whether the format can be sampled with linear filtering.
+func (c *fxGLCaps) linearFilter(format FXTextureFormat) bool {
+	switch format {
+	case FXTextureRGBA16F:
+		return c.es3 || c.extensions["GL_OES_texture_half_float_linear"]
+	case FXTextureRGBA32F:
+		return c.extensions["GL_OES_texture_float_linear"]
+	}
+	return true
+}
+
+// FXTextureFormatSupported reports whether textures of the format can be created and rendered to
+// in the current context. Float formats need GLES 3 or the OES float texture extensions for sampling,
+// and a color-buffer extension (EXT_color_buffer_half_float or EXT_color_buffer_float) for rendering.
+// Support is probed once by creating a small framebuffer, and the result is cached.
+func FXTextureFormatSupported(format FXTextureFormat) bool {
+	if format == FXTextureRGBA8 {
+		return true
+	}
+	caps := glCaps()
+	if supported, ok := caps.supported[format]; ok {
+		return supported
+	}
+
+	supported := false
+	switch format {
+	case FXTextureRGBA16F:
+		supported = (caps.es3 || caps.extensions["GL_OES_texture_half_float"]) &&
+			(caps.extensions["GL_EXT_color_buffer_half_float"] || caps.extensions["GL_EXT_color_buffer_float"])
+	case FXTextureRGBA32F:
+		supported = (caps.es3 || caps.extensions["GL_OES_texture_float"]) &&
+			caps.extensions["GL_EXT_color_buffer_float"]
+	}
+	if supported {
+		// Drivers may advertise the extensions but still reject the attachment, so probe it.
+		supported = probeRenderable(caps, format)
+	}
+
+	caps.supported[format] = supported
+	return supported
+}
+
+// probeRenderable creates a 1x1 texture of the format and checks that it is framebuffer complete.
+func probeRenderable(caps *fxGLCaps, format FXTextureFormat) bool {
+	// Clear stale errors so the allocation can be checked.
+	for gles2.GetError() != gles2.NO_ERROR {
+	}
+
+	var tex, fbo uint32
+	gles2.GenTextures(1, &tex)
+	gles2.BindTexture(gles2.TEXTURE_2D, tex)
+	internalFormat, pixelFormat, pixelType := caps.glFormat(format)
+	gles2.TexImage2D(gles2.TEXTURE_2D, 0, internalFormat, 1, 1, 0, pixelFormat, pixelType, nil)
+	ok := gles2.GetError() == gles2.NO_ERROR
+	gles2.BindTexture(gles2.TEXTURE_2D, 0)
+
+	if ok {
+		gles2.GenFramebuffers(1, &fbo)
+		gles2.BindFramebuffer(gles2.FRAMEBUFFER, fbo)
+		gles2.FramebufferTexture2D(gles2.FRAMEBUFFER, gles2.COLOR_ATTACHMENT0, gles2.TEXTURE_2D, tex, 0)
+		ok = gles2.CheckFramebufferStatus(gles2.FRAMEBUFFER) == gles2.FRAMEBUFFER_COMPLETE
+		gles2.BindFramebuffer(gles2.FRAMEBUFFER, 0)
+		gles2.DeleteFramebuffers(1, &fbo)
+	}
+
+	gles2.DeleteTextures(1, &tex)
+	return ok
+}
+
+// floatToHalf converts a float32 to IEEE 754 half precision bits, rounding to nearest.
+// Values too large for half precision become infinity.
+func floatToHalf(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int((bits>>23)&0xff) - 127 + 15
+	mant := bits & 0x7fffff
+
+	switch {
+	case (bits>>23)&0xff == 0xff:
+		// Infinity or NaN.
+		if mant != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	case exp >= 0x1f:
+		// Overflow.
+		return sign | 0x7c00
+	case exp <= 0:
+		// Subnormal or zero.
+		if exp < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint32(14 - exp)
+		half := uint16(mant >> shift)
+		if (mant>>(shift-1))&1 != 0 {
+			half++
+		}
+		return sign | half
+	}
+
+	half := sign | uint16(exp)<<10 | uint16(mant>>13)
+	if mant&0x1000 != 0 {
+		// Round up; a carry into the exponent is still correct.
+		half++
+	}
+	return half
+}
+
+// halfToFloat converts IEEE 754 half precision bits to a float32.
+func halfToFloat(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+
+	switch {
+	case exp == 0:
+		if mant == 0 {
+			return math.Float32frombits(sign)
+		}
+		// Subnormal: value is mant * 2^-24.
+		f := float32(mant) / (1 << 24)
+		if sign != 0 {
+			return -f
+		}
+		return f
+	case exp == 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	}
+	return math.Float32frombits(sign | (exp+127-15)<<23 | mant<<13)
+}
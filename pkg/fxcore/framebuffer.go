@@ -33,13 +33,22 @@ type fxFramebuffer struct {
 // NewFXFramebuffer creates a new fxFramebuffer with a fxTexture attachment of the specified size.
 // NewFXFramebuffer creates a new fxFramebuffer with a fxTexture attachment of the specified size.
 func NewFXFramebuffer(width, height int) (FXFramebuffer, error) {
+	return NewFXFramebufferWithFormat(width, height, FXTextureRGBA8)
+}
+
+// NewFXFramebufferWithFormat creates a new fxFramebuffer with a fxTexture attachment of the specified
+// size and storage format. Float formats keep values outside 0 to 1 between passes.
+func NewFXFramebufferWithFormat(width, height int, format FXTextureFormat) (FXFramebuffer, error) {
+	// Create a texture to attach to the FBO. This will store the rendered output.
+	tex, err := NewFXTextureWithFormat(width, height, format)
+	if err != nil {
+		return nil, err
+	}
+
 	var id uint32
 	// Generate a new Framebuffer Object (FBO) ID.
 	gles2.GenFramebuffers(1, &id)
 
-	// Create a texture to attach to the FBO. This will store the rendered output.
-	tex := NewFXTexture(width, height)
-
 	// Bind the FBO to configure it.
 	gles2.BindFramebuffer(gles2.FRAMEBUFFER, id)
 	// Attach the texture to the color attachment point 0.
@@ -0,0 +1,241 @@
+package fxcore
+
+import (
+	"fmt"
+	"image"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.1/gles2"
+)
+
+// FXReadback reads textures back to CPU memory asynchronously using a ring of buffers.
+//
+// Start queues the readback of a texture and returns without waiting for the GPU. Finish
+// completes the oldest queued readback. Keeping up to depth readbacks in flight lets the GPU
+// render the next frames while earlier ones are copied and encoded:
+//
+//	rb.Start(tex)          // frame 0
+//	rb.Start(tex)          // frame 1, rendered while frame 0 is copied
+//	rb.Finish(img)         // frame 0
+//
+// On OpenGL ES 3 the copies go through pixel buffer objects. Elsewhere the pixels are read
+// synchronously in Start, but into reusable buffers, so no memory is allocated per frame.
+type FXReadback interface {
+	// Start queues the readback of a texture of the readback size.
+	// It returns an error if depth readbacks are already pending; call Finish first.
+	Start(tex FXTexture) error
+	// Finish waits for the oldest pending readback and copies it into dst, which must have the
+	// readback size. Rows are ordered from the top, as with FXTexture.Download.
+	// Float textures are clamped to 0 to 1 and quantized to 8 bits.
+	Finish(dst *image.RGBA) error
+	// Pending returns the number of readbacks started but not finished.
+	Pending() int
+	// Depth returns the maximum number of pending readbacks.
+	Depth() int
+	// GetSize returns the width and height of the textures read back.
+	GetSize() (int, int)
+	// Release frees the buffers. Pending readbacks are discarded.
+	Release()
+}
+
+// fxReadbackSlot is one buffer of the readback ring.
+type fxReadbackSlot struct {
+	// pbo is the pixel buffer object receiving the pixels, or 0 without PBO support.
+	pbo uint32
+	// pboSize is the allocated size of the pixel buffer object in bytes.
+	pboSize int
+	// float is true if the pixel buffer object holds RGBA floats rather than bytes.
+	float bool
+	// pixels receives the pixels without PBO support, bottom row first.
+	pixels []uint8
+	// image holds a texture downloaded synchronously because it could not be read directly.
+	image *image.RGBA
+}
+
+// fxReadback implements FXReadback.
+type fxReadback struct {
+	// width is the width of the textures read back.
+	width int
+	// height is the height of the textures read back.
+	height int
+	// fbo is the framebuffer that textures are attached to for reading.
+	fbo uint32
+	// usePBO is true if pixel buffer objects are used.
+	usePBO bool
+	// slots is the ring of buffers.
+	slots []fxReadbackSlot
+	// next is the index of the slot used by the next Start.
+	next int
+	// pending is the number of readbacks started but not finished.
+	pending int
+}
+
+// NewFXReadback creates a readback ring for textures of the given size.
+// A depth of 2 gives double buffering and 3 triple buffering; values below 1 are treated as 1.
+func NewFXReadback(width, height, depth int) FXReadback {
+	if depth < 1 {
+		depth = 1
+	}
+	rb := &fxReadback{
+		width:  width,
+		height: height,
+		usePBO: glCaps().es3,
+		slots:  make([]fxReadbackSlot, depth),
+	}
+	gles2.GenFramebuffers(1, &rb.fbo)
+	return rb
+}
+
+func (rb *fxReadback) Pending() int {
+	return rb.pending
+}
+
+func (rb *fxReadback) Depth() int {
+	return len(rb.slots)
+}
+
+func (rb *fxReadback) GetSize() (int, int) {
+	return rb.width, rb.height
+}
+
+func (rb *fxReadback) Start(tex FXTexture) error {
+	if rb.pending == len(rb.slots) {
+		return fmt.Errorf("all %d readback buffers are pending", len(rb.slots))
+	}
+	if w, h := tex.GetSize(); w != rb.width || h != rb.height {
+		return fmt.Errorf("texture size mismatch: expected %dx%d, got %dx%d", rb.width, rb.height, w, h)
+	}
+	slot := &rb.slots[rb.next]
+
+	// 1. Float textures without a float read path
+	// Half float color buffers may only be readable as half floats; Download handles that case.
+	format := tex.GetFormat()
+	if format.IsFloat() && !(rb.usePBO && glCaps().extensions["GL_EXT_color_buffer_float"]) {
+		img, err := tex.Download()
+		if err != nil {
+			return err
+		}
+		slot.image = img
+		rb.advance()
+		return nil
+	}
+
+	// 2. Attach the texture
+	// The framebuffer object is reused across frames instead of being created for each read.
+	gles2.BindFramebuffer(gles2.FRAMEBUFFER, rb.fbo)
+	defer gles2.BindFramebuffer(gles2.FRAMEBUFFER, 0)
+	gles2.FramebufferTexture2D(gles2.FRAMEBUFFER, gles2.COLOR_ATTACHMENT0, gles2.TEXTURE_2D, tex.GetID(), 0)
+	if status := gles2.CheckFramebufferStatus(gles2.FRAMEBUFFER); status != gles2.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("fxFramebuffer incomplete: status %x", status)
+	}
+
+	// 3. Read pixels
+	w, h := int32(rb.width), int32(rb.height)
+	if !rb.usePBO {
+		if len(slot.pixels) != rb.width*rb.height*4 {
+			slot.pixels = make([]uint8, rb.width*rb.height*4)
+		}
+		gles2.ReadPixels(0, 0, w, h, gles2.RGBA, gles2.UNSIGNED_BYTE, gles2.Ptr(slot.pixels))
+		rb.advance()
+		return nil
+	}
+
+	// With a pixel pack buffer bound, ReadPixels only queues the copy and returns immediately.
+	slot.float = format.IsFloat()
+	size := rb.width * rb.height * 4
+	pixelType := uint32(gles2.UNSIGNED_BYTE)
+	if slot.float {
+		size *= 4
+		pixelType = gles2.FLOAT
+	}
+	if slot.pbo == 0 {
+		gles2.GenBuffers(1, &slot.pbo)
+	}
+	gles2.BindBuffer(gles2.PIXEL_PACK_BUFFER, slot.pbo)
+	if slot.pboSize != size {
+		gles2.BufferData(gles2.PIXEL_PACK_BUFFER, size, nil, gles2.STREAM_READ)
+		slot.pboSize = size
+	}
+	gles2.ReadPixels(0, 0, w, h, gles2.RGBA, pixelType, gles2.PtrOffset(0))
+	gles2.BindBuffer(gles2.PIXEL_PACK_BUFFER, 0)
+	rb.advance()
+	return nil
+}
+
+// advance moves to the next slot after starting a readback.
+func (rb *fxReadback) advance() {
+	rb.next = (rb.next + 1) % len(rb.slots)
+	rb.pending++
+}
+
+func (rb *fxReadback) Finish(dst *image.RGBA) error {
+	if rb.pending == 0 {
+		return fmt.Errorf("no pending readback")
+	}
+	if dst.Rect.Dx() != rb.width || dst.Rect.Dy() != rb.height {
+		return fmt.Errorf("image size mismatch: expected %dx%d, got %dx%d", rb.width, rb.height, dst.Rect.Dx(), dst.Rect.Dy())
+	}
+	slot := &rb.slots[(rb.next-rb.pending+len(rb.slots))%len(rb.slots)]
+	rb.pending--
+
+	// Images downloaded synchronously are already top-down.
+	if slot.image != nil {
+		copyRows(dst, slot.image.Pix, slot.image.Stride, false)
+		slot.image = nil
+		return nil
+	}
+	if slot.pbo == 0 {
+		copyRows(dst, slot.pixels, rb.width*4, true)
+		return nil
+	}
+
+	// Mapping the buffer waits for the copy queued in Start to complete.
+	gles2.BindBuffer(gles2.PIXEL_PACK_BUFFER, slot.pbo)
+	defer gles2.BindBuffer(gles2.PIXEL_PACK_BUFFER, 0)
+	ptr := gles2.MapBufferRange(gles2.PIXEL_PACK_BUFFER, 0, slot.pboSize, gles2.MAP_READ_BIT)
+	if ptr == nil {
+		return fmt.Errorf("failed to map pixel buffer: error %x", gles2.GetError())
+	}
+	if slot.float {
+		values := unsafe.Slice((*float32)(ptr), rb.width*rb.height*4)
+		stride := rb.width * 4
+		for y := 0; y < rb.height; y++ {
+			src := values[y*stride : (y+1)*stride]
+			dstRow := dst.Pix[(rb.height-1-y)*dst.Stride:]
+			for i, v := range src {
+				dstRow[i] = uint8(clamp01(v)*255 + 0.5)
+			}
+		}
+	} else {
+		copyRows(dst, unsafe.Slice((*uint8)(ptr), slot.pboSize), rb.width*4, true)
+	}
+	gles2.UnmapBuffer(gles2.PIXEL_PACK_BUFFER)
+	return nil
+}
+
+// copyRows copies RGBA rows into dst, flipping them vertically if flip is true.
+func copyRows(dst *image.RGBA, src []uint8, srcStride int, flip bool) {
+	height := dst.Rect.Dy()
+	rowSize := dst.Rect.Dx() * 4
+	for y := 0; y < height; y++ {
+		dy := y
+		if flip {
+			dy = height - 1 - y
+		}
+		copy(dst.Pix[dy*dst.Stride:dy*dst.Stride+rowSize], src[y*srcStride:y*srcStride+rowSize])
+	}
+}
+
+func (rb *fxReadback) Release() {
+	for i := range rb.slots {
+		if rb.slots[i].pbo != 0 {
+			gles2.DeleteBuffers(1, &rb.slots[i].pbo)
+		}
+	}
+	rb.slots = nil
+	rb.pending = 0
+	if rb.fbo != 0 {
+		gles2.DeleteFramebuffers(1, &rb.fbo)
+		rb.fbo = 0
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"image/draw"
 	_ "image/jpeg"
 	_ "image/png"
+	"math"
 	"os"
 
 	"github.com/go-gl/gl/v3.1/gles2"
@@ -22,14 +23,25 @@ type FXTexture interface {
 	// Release frees the OpenGL resources associated with the fxTexture.
 	Release()
 	// Download reads the fxTexture data back to an image.RGBA.
+	// Float textures are clamped to 0 to 1 and quantized to 8 bits.
 	Download() (*image.RGBA, error)
+	// DownloadRGBA64 reads the fxTexture data back to an image.RGBA64.
+	// Float textures are clamped to 0 to 1 and quantized to 16 bits.
+	DownloadRGBA64() (*image.RGBA64, error)
+	// DownloadFloat reads the fxTexture data back as RGBA floats, row by row from the top.
+	// Float textures are returned unclamped; RGBA8 textures are normalized to 0 to 1.
+	DownloadFloat() ([]float32, error)
 	// GetID returns the OpenGL fxTexture ID.
 	GetID() uint32
 	// GetSize returns the width and height of the fxTexture.
-	// GetSize returns the width and height of the fxTexture.
 	GetSize() (int, int)
+	// GetFormat returns the storage format of the fxTexture.
+	GetFormat() FXTextureFormat
 	// Upload updates the fxTexture content from an image.RGBA.
 	Upload(img *image.RGBA)
+	// UploadFloat updates the fxTexture content from RGBA floats, in the same row order as Upload.
+	// The slice must hold width*height*4 values.
+	UploadFloat(pixels []float32) error
 }
 
 // fxTexture implements FXTexture.
@@ -40,28 +52,52 @@ type fxTexture struct {
 	width int
 	// height is the height of the texture.
 	height int
+	// format is the storage format of the texture.
+	format FXTextureFormat
 }
 
 // NewFXTexture creates a new empty fxTexture.
 func NewFXTexture(width, height int) FXTexture {
+	return newFXTexture(width, height, FXTextureRGBA8)
+}
+
+// NewFXTextureWithFormat creates a new empty fxTexture with the specified storage format.
+// It returns an error if the format is not supported by the current context.
+func NewFXTextureWithFormat(width, height int, format FXTextureFormat) (FXTexture, error) {
+	if !FXTextureFormatSupported(format) {
+		return nil, fmt.Errorf("texture format %s is not supported by this context", format)
+	}
+	return newFXTexture(width, height, format), nil
+}
+
+// newFXTexture creates a new empty fxTexture with a format known to be supported.
+func newFXTexture(width, height int, format FXTextureFormat) FXTexture {
 	var id uint32
 	// Generate a new texture ID.
 	gles2.GenTextures(1, &id)
-	t := &fxTexture{id: id, width: width, height: height}
+	t := &fxTexture{id: id, width: width, height: height, format: format}
 	// Bind the texture to configure it.
 	t.Bind()
 
 	// Set default parameters
-	// Linear filtering for minification and magnification.
-	gles2.TexParameteri(gles2.TEXTURE_2D, gles2.TEXTURE_MIN_FILTER, gles2.LINEAR)
-	gles2.TexParameteri(gles2.TEXTURE_2D, gles2.TEXTURE_MAG_FILTER, gles2.LINEAR)
+	// Linear filtering for minification and magnification, unless the format cannot be filtered.
+	filter := int32(gles2.LINEAR)
+	if format != FXTextureRGBA8 && !glCaps().linearFilter(format) {
+		filter = gles2.NEAREST
+	}
+	gles2.TexParameteri(gles2.TEXTURE_2D, gles2.TEXTURE_MIN_FILTER, filter)
+	gles2.TexParameteri(gles2.TEXTURE_2D, gles2.TEXTURE_MAG_FILTER, filter)
 	// Clamp to edge to avoid artifacts at the borders.
 	gles2.TexParameteri(gles2.TEXTURE_2D, gles2.TEXTURE_WRAP_S, gles2.CLAMP_TO_EDGE)
 	gles2.TexParameteri(gles2.TEXTURE_2D, gles2.TEXTURE_WRAP_T, gles2.CLAMP_TO_EDGE)
 
 	// Allocate storage (empty)
 	// Initialize the texture with null data, allocating memory on the GPU.
-	gles2.TexImage2D(gles2.TEXTURE_2D, 0, gles2.RGBA, int32(width), int32(height), 0, gles2.RGBA, gles2.UNSIGNED_BYTE, nil)
+	internalFormat, pixelFormat, pixelType := int32(gles2.RGBA), uint32(gles2.RGBA), uint32(gles2.UNSIGNED_BYTE)
+	if format != FXTextureRGBA8 {
+		internalFormat, pixelFormat, pixelType = glCaps().glFormat(format)
+	}
+	gles2.TexImage2D(gles2.TEXTURE_2D, 0, internalFormat, int32(width), int32(height), 0, pixelFormat, pixelType, nil)
 
 	// Unbind the texture.
 	t.Unbind()
@@ -69,6 +105,30 @@ func NewFXTexture(width, height int) FXTexture {
 	return t
 }
 
+// fxMaxTextureUnits caches the GL_MAX_TEXTURE_IMAGE_UNITS limit.
+var fxMaxTextureUnits int32
+
+// FXMaxTextureUnits returns the number of texture units available to fragment shaders.
+// The value is queried from the current context once and cached.
+func FXMaxTextureUnits() int {
+	if fxMaxTextureUnits == 0 {
+		gles2.GetIntegerv(gles2.MAX_TEXTURE_IMAGE_UNITS, &fxMaxTextureUnits)
+	}
+	return int(fxMaxTextureUnits)
+}
+
+// fxMaxTextureSize caches the GL_MAX_TEXTURE_SIZE limit.
+var fxMaxTextureSize int32
+
+// FXMaxTextureSize returns the largest width or height of a texture.
+// The value is queried from the current context once and cached.
+func FXMaxTextureSize() int {
+	if fxMaxTextureSize == 0 {
+		gles2.GetIntegerv(gles2.MAX_TEXTURE_SIZE, &fxMaxTextureSize)
+	}
+	return int(fxMaxTextureSize)
+}
+
 // FXLoadTextureFromFile loads a fxTexture from an image file.
 func FXLoadTextureFromFile(path string) (FXTexture, error) {
 	// Open the file.
@@ -126,30 +186,57 @@ func (t *fxTexture) GetSize() (int, int) {
 	return t.width, t.height
 }
 
-// Download reads the fxTexture data back to an image.RGBA.
-func (t *fxTexture) Download() (*image.RGBA, error) {
+func (t *fxTexture) GetFormat() FXTextureFormat {
+	return t.format
+}
+
+// readPixels attaches the fxTexture to a temporary FBO and calls read while it is bound.
+func (t *fxTexture) readPixels(read func() error) error {
 	// Create a temporary FBO to read from
 	// We can't read directly from a texture, so we attach it to an FBO.
 	var fbo uint32
 	gles2.GenFramebuffers(1, &fbo)
 	gles2.BindFramebuffer(gles2.FRAMEBUFFER, fbo)
 	gles2.FramebufferTexture2D(gles2.FRAMEBUFFER, gles2.COLOR_ATTACHMENT0, gles2.TEXTURE_2D, t.id, 0)
+	defer func() {
+		// Unbind the FBO.
+		gles2.BindFramebuffer(gles2.FRAMEBUFFER, 0)
+		gles2.DeleteFramebuffers(1, &fbo)
+	}()
 
 	// Check if the FBO is complete.
 	status := gles2.CheckFramebufferStatus(gles2.FRAMEBUFFER)
 	if status != gles2.FRAMEBUFFER_COMPLETE {
-		gles2.BindFramebuffer(gles2.FRAMEBUFFER, 0)
-		gles2.DeleteFramebuffers(1, &fbo)
-		return nil, fmt.Errorf("fxFramebuffer incomplete: status %x", status)
+		return fmt.Errorf("fxFramebuffer incomplete: status %x", status)
 	}
 
 	// Read pixels from the FBO.
-	pixels := make([]uint8, t.width*t.height*4)
-	gles2.ReadPixels(0, 0, int32(t.width), int32(t.height), gles2.RGBA, gles2.UNSIGNED_BYTE, gles2.Ptr(pixels))
+	return read()
+}
 
-	// Unbind the FBO.
-	gles2.BindFramebuffer(gles2.FRAMEBUFFER, 0)
-	gles2.DeleteFramebuffers(1, &fbo)
+// Download reads the fxTexture data back to an image.RGBA.
+func (t *fxTexture) Download() (*image.RGBA, error) {
+	if t.format.IsFloat() {
+		// Float color buffers cannot be read as bytes, so read floats and quantize.
+		values, err := t.DownloadFloat()
+		if err != nil {
+			return nil, err
+		}
+		img := image.NewRGBA(image.Rect(0, 0, t.width, t.height))
+		for i, v := range values {
+			img.Pix[i] = uint8(clamp01(v)*255 + 0.5)
+		}
+		return img, nil
+	}
+
+	pixels := make([]uint8, t.width*t.height*4)
+	err := t.readPixels(func() error {
+		gles2.ReadPixels(0, 0, int32(t.width), int32(t.height), gles2.RGBA, gles2.UNSIGNED_BYTE, gles2.Ptr(pixels))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	// Create an image from the pixels.
 	rect := image.Rect(0, 0, t.width, t.height)
@@ -167,7 +254,107 @@ func (t *fxTexture) Download() (*image.RGBA, error) {
 	return img, nil
 }
 
+// DownloadRGBA64 reads the fxTexture data back to an image.RGBA64.
+func (t *fxTexture) DownloadRGBA64() (*image.RGBA64, error) {
+	img := image.NewRGBA64(image.Rect(0, 0, t.width, t.height))
+	if !t.format.IsFloat() {
+		// Expand 8-bit values to 16 bits (0xff becomes 0xffff).
+		rgba, err := t.Download()
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range rgba.Pix {
+			img.Pix[2*i] = v
+			img.Pix[2*i+1] = v
+		}
+		return img, nil
+	}
+
+	values, err := t.DownloadFloat()
+	if err != nil {
+		return nil, err
+	}
+	// image.RGBA64 stores big-endian 16-bit values.
+	for i, v := range values {
+		c := uint16(clamp01(v)*65535 + 0.5)
+		img.Pix[2*i] = uint8(c >> 8)
+		img.Pix[2*i+1] = uint8(c)
+	}
+	return img, nil
+}
+
+// DownloadFloat reads the fxTexture data back as RGBA floats, row by row from the top.
+func (t *fxTexture) DownloadFloat() ([]float32, error) {
+	count := t.width * t.height * 4
+	values := make([]float32, count)
+
+	if !t.format.IsFloat() {
+		rgba, err := t.Download()
+		if err != nil {
+			return nil, err
+		}
+		for i, v := range rgba.Pix {
+			values[i] = float32(v) / 255
+		}
+		return values, nil
+	}
+
+	// RGBA/FLOAT is always readable from float color buffers (EXT_color_buffer_float).
+	// With only EXT_color_buffer_half_float, the implementation may require half floats instead.
+	err := t.readPixels(func() error {
+		var readFormat, readType int32
+		gles2.GetIntegerv(gles2.IMPLEMENTATION_COLOR_READ_FORMAT, &readFormat)
+		gles2.GetIntegerv(gles2.IMPLEMENTATION_COLOR_READ_TYPE, &readType)
+		if readFormat == gles2.RGBA && (readType == gles2.HALF_FLOAT || readType == fxHalfFloatOES) {
+			halves := make([]uint16, count)
+			gles2.ReadPixels(0, 0, int32(t.width), int32(t.height), gles2.RGBA, uint32(readType), gles2.Ptr(halves))
+			for i, h := range halves {
+				values[i] = halfToFloat(h)
+			}
+		} else {
+			gles2.ReadPixels(0, 0, int32(t.width), int32(t.height), gles2.RGBA, gles2.FLOAT, gles2.Ptr(values))
+		}
+		if glErr := gles2.GetError(); glErr != gles2.NO_ERROR {
+			return fmt.Errorf("failed to read %s pixels: error %x", t.format, glErr)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Flip Y, as in Download.
+	stride := t.width * 4
+	row := make([]float32, stride)
+	for y := 0; y < t.height/2; y++ {
+		top := values[y*stride : (y+1)*stride]
+		bottom := values[(t.height-1-y)*stride : (t.height-y)*stride]
+		copy(row, top)
+		copy(top, bottom)
+		copy(bottom, row)
+	}
+	return values, nil
+}
+
+// clamp01 clamps a value to 0 to 1. NaN becomes 0.
+func clamp01(v float32) float32 {
+	if !(v > 0) {
+		return 0
+	}
+	return float32(math.Min(float64(v), 1))
+}
+
 func (t *fxTexture) Upload(img *image.RGBA) {
+	if t.format.IsFloat() {
+		// Float textures only accept float data.
+		values := make([]float32, len(img.Pix))
+		for i, v := range img.Pix {
+			values[i] = float32(v) / 255
+		}
+		t.UploadFloat(values)
+		return
+	}
+
 	// Bind the texture to upload new data.
 	t.Bind()
 	// Upload new data to the existing texture storage.
@@ -175,3 +362,31 @@ func (t *fxTexture) Upload(img *image.RGBA) {
 	// Unbind the texture.
 	t.Unbind()
 }
+
+func (t *fxTexture) UploadFloat(pixels []float32) error {
+	if len(pixels) != t.width*t.height*4 {
+		return fmt.Errorf("expected %d values, got %d", t.width*t.height*4, len(pixels))
+	}
+
+	t.Bind()
+	defer t.Unbind()
+	switch t.format {
+	case FXTextureRGBA8:
+		bytes := make([]uint8, len(pixels))
+		for i, v := range pixels {
+			bytes[i] = uint8(clamp01(v)*255 + 0.5)
+		}
+		gles2.TexSubImage2D(gles2.TEXTURE_2D, 0, 0, 0, int32(t.width), int32(t.height), gles2.RGBA, gles2.UNSIGNED_BYTE, gles2.Ptr(bytes))
+	case FXTextureRGBA16F:
+		// Convert to half floats, which both GLES 2 and GLES 3 accept for half float textures.
+		halves := make([]uint16, len(pixels))
+		for i, v := range pixels {
+			halves[i] = floatToHalf(v)
+		}
+		_, pixelFormat, pixelType := glCaps().glFormat(t.format)
+		gles2.TexSubImage2D(gles2.TEXTURE_2D, 0, 0, 0, int32(t.width), int32(t.height), pixelFormat, pixelType, gles2.Ptr(halves))
+	case FXTextureRGBA32F:
+		gles2.TexSubImage2D(gles2.TEXTURE_2D, 0, 0, 0, int32(t.width), int32(t.height), gles2.RGBA, gles2.FLOAT, gles2.Ptr(pixels))
+	}
+	return nil
+}
@@ -7,6 +7,8 @@ import (
 // FXImageInput is a simple node that provides a texture from an image or existing texture.
 type FXImageInput struct {
 	Texture fxcore.FXTexture
+	// Path is the file the texture was loaded from, or empty if it was provided directly.
+	Path string
 }
 
 // NewFXImageInput creates a new FXImageInput node.
@@ -20,7 +22,7 @@ func NewFXImageInputFromFile(path string) (*FXImageInput, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &FXImageInput{Texture: tex}, nil
+	return &FXImageInput{Texture: tex, Path: path}, nil
 }
 
 func (n *FXImageInput) GetTexture() fxcore.FXTexture { return n.Texture }
@@ -2,6 +2,7 @@ package fximage
 
 import (
 	"fmt"
+	"image"
 	"image/png"
 	"os"
 
@@ -39,13 +40,20 @@ func (n *FXImageOutput) IsDirty() bool {
 }
 
 // Save saves the current texture to a PNG file.
+// Float textures are saved as 16-bit PNGs to keep their extra precision.
 func (n *FXImageOutput) Save(filename string) error {
 	tex := n.GetTexture()
 	if tex == nil {
 		return fmt.Errorf("no input texture to save")
 	}
 
-	img, err := tex.Download()
+	var img image.Image
+	var err error
+	if tex.GetFormat().IsFloat() {
+		img, err = tex.DownloadRGBA64()
+	} else {
+		img, err = tex.Download()
+	}
 	if err != nil {
 		return err
 	}
@@ -68,8 +76,5 @@ func (n *FXImageOutput) Process(ctx fxcontext.FXContext) error {
 	// But usually the pipeline handles processing.
 	// For now, let's assume the user drives processing on the node they want to render.
 	// If FXImageOutput is used as a sink, maybe it should trigger processing of its input?
-	if node, ok := n.Input.(fxnode.FXNode); ok {
-		return node.Process(ctx)
-	}
-	return nil
+	return fxnode.FXProcessInput(ctx, n.Input)
 }
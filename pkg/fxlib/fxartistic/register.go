@@ -0,0 +1,166 @@
+package fxartistic
+
+import (
+	"reflect"
+
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxnode"
+)
+
+// init registers the artistic nodes so they can be created by type name.
+func init() {
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "bloom",
+		Category:    "artistic",
+		Description: "Makes bright areas glow.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxBloomNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXBloomNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				Name:        "threshold",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Brightness above which pixels glow.",
+				Default:     float32(0.7),
+				Min:         0,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_threshold"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXBloomNode).SetThreshold(v.(float32)) },
+			},
+			{
+				Name:        "intensity",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Strength of the glow.",
+				Default:     float32(1),
+				Min:         0,
+				Max:         2,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_intensity"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXBloomNode).SetIntensity(v.(float32)) },
+			},
+			{
+				Name:        "blurSize",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Size of the glow blur.",
+				Default:     float32(2),
+				Min:         0,
+				Max:         10,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_blurSize"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXBloomNode).SetBlurSize(v.(float32)) },
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "edgeDetection",
+		Category:    "artistic",
+		Description: "Highlights edges using a Sobel filter.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxEdgeDetectionNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXEdgeDetectionNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				Name:        "threshold",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Minimum edge strength that is kept.",
+				Default:     float32(0),
+				Min:         0,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_threshold"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXEdgeDetectionNode).SetThreshold(v.(float32)) },
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "oilPaint",
+		Category:    "artistic",
+		Description: "Simulates an oil painting with a Kuwahara filter.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxOilPaintNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXOilPaintNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				Name:        "radius",
+				Kind:        fxnode.FXParamInt,
+				Description: "Radius of the brush in pixels.",
+				Default:     4,
+				Min:         1,
+				Max:         10,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamInt, "u_radius"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXOilPaintNode).SetRadius(v.(int)) },
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "pixelize",
+		Category:    "artistic",
+		Description: "Renders the image as large blocks of color.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxPixelizeNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXPixelizeNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				Name:        "pixelSize",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Size of the blocks in pixels.",
+				Default:     float32(10),
+				Min:         1,
+				Max:         100,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_pixelSize"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXPixelizeNode).SetPixelSize(v.(float32)) },
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "vignette",
+		Category:    "artistic",
+		Description: "Darkens the corners of the image.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxVignetteNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXVignetteNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				Name:        "radius",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Radius of the unaffected center.",
+				Default:     float32(0.75),
+				Min:         0,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_radius"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXVignetteNode).SetRadius(v.(float32)) },
+			},
+			{
+				Name:        "softness",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Softness of the vignette edge.",
+				Default:     float32(0.45),
+				Min:         0,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_softness"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXVignetteNode).SetSoftness(v.(float32)) },
+			},
+			{
+				Name:        "opacity",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Opacity of the vignette.",
+				Default:     float32(0.5),
+				Min:         0,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_opacity"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXVignetteNode).SetOpacity(v.(float32)) },
+			},
+		},
+	})
+}
@@ -1,4 +1,5 @@
-// Package fxblend provides blending modes for combining textures.
+// Package fxblend provides blending modes and Porter-Duff operators for combining textures,
+// following the W3C Compositing and Blending specification.
 package fxblend
 
 import (
@@ -37,6 +38,31 @@ const (
 	FXBlendDifference
 	// FXBlendExclusion produces an effect similar to Difference but lower contrast.
 	FXBlendExclusion
+	// FXBlendHue uses the hue of the blend color with the saturation and luminosity of the base color.
+	FXBlendHue
+	// FXBlendSaturation uses the saturation of the blend color with the hue and luminosity of the base color.
+	FXBlendSaturation
+	// FXBlendColor uses the hue and saturation of the blend color with the luminosity of the base color.
+	FXBlendColor
+	// FXBlendLuminosity uses the luminosity of the blend color with the hue and saturation of the base color.
+	FXBlendLuminosity
+)
+
+// FXCompositeOperator represents the Porter-Duff operator that combines the blended texture
+// with the base texture by their alpha.
+type FXCompositeOperator int
+
+const (
+	// FXCompositeOver shows the blend texture over the base texture.
+	FXCompositeOver FXCompositeOperator = iota
+	// FXCompositeIn shows the blend texture only where the base texture is, and nothing else.
+	FXCompositeIn
+	// FXCompositeOut shows the blend texture only where the base texture is not, and nothing else.
+	FXCompositeOut
+	// FXCompositeAtop shows the blend texture over the base texture, only where the base texture is.
+	FXCompositeAtop
+	// FXCompositeXor shows each texture only where the other is not.
+	FXCompositeXor
 )
 
 // FXBlendFS is the fragment fxShader for blending.
@@ -47,6 +73,8 @@ uniform sampler2D u_texture1; // Base
 uniform sampler2D u_texture2; // Blend
 uniform float u_factor;       // Opacity
 uniform int u_mode;
+uniform int u_operator;
+uniform int u_premultiplied;
 
 float blendAdd(float base, float blend) {
 	return min(base + blend, 1.0);
@@ -96,9 +124,48 @@ float blendExclusion(float base, float blend) {
 	return base + blend - 2.0 * base * blend;
 }
 
+// Helpers for the non-separable modes, as defined by the W3C spec.
+float lum(vec3 c) {
+	return dot(c, vec3(0.3, 0.59, 0.11));
+}
+
+vec3 clipColor(vec3 c) {
+	float l = lum(c);
+	float n = min(c.r, min(c.g, c.b));
+	float x = max(c.r, max(c.g, c.b));
+	if (n < 0.0) {
+		c = l + (c - l) * l / (l - n);
+	}
+	if (x > 1.0) {
+		c = l + (c - l) * (1.0 - l) / (x - l);
+	}
+	return c;
+}
+
+vec3 setLum(vec3 c, float l) {
+	return clipColor(c + (l - lum(c)));
+}
+
+float sat(vec3 c) {
+	return max(c.r, max(c.g, c.b)) - min(c.r, min(c.g, c.b));
+}
+
+vec3 setSat(vec3 c, float s) {
+	// Stretch the channels so the largest is s and the smallest 0, keeping their order.
+	float n = min(c.r, min(c.g, c.b));
+	float range = sat(c);
+	return range > 0.0 ? (c - n) * s / range : vec3(0.0);
+}
+
 void main() {
 	vec4 c1 = texture2D(u_texture1, v_texCoord);
 	vec4 c2 = texture2D(u_texture2, v_texCoord);
+
+	// Blend modes work on colors that are not premultiplied.
+	if (u_premultiplied == 1) {
+		c1.rgb = c1.a > 0.0 ? c1.rgb / c1.a : vec3(0.0);
+		c2.rgb = c2.a > 0.0 ? c2.rgb / c2.a : vec3(0.0);
+	}
 	
 	vec3 base = c1.rgb;
 	vec3 blend = c2.rgb;
@@ -128,25 +195,66 @@ void main() {
 		result = vec3(blendDifference(base.r, blend.r), blendDifference(base.g, blend.g), blendDifference(base.b, blend.b));
 	} else if (u_mode == 12) { // Exclusion
 		result = vec3(blendExclusion(base.r, blend.r), blendExclusion(base.g, blend.g), blendExclusion(base.b, blend.b));
+	} else if (u_mode == 13) { // Hue
+		result = setLum(setSat(blend, sat(base)), lum(base));
+	} else if (u_mode == 14) { // Saturation
+		result = setLum(setSat(base, sat(blend)), lum(base));
+	} else if (u_mode == 15) { // Color
+		result = setLum(blend, lum(base));
+	} else if (u_mode == 16) { // Luminosity
+		result = setLum(base, lum(blend));
 	} else { // Normal (0)
 		result = blend;
 	}
 
-	// Apply opacity (factor)
-	// Interpolate between base color and blended result
-	gl_FragColor = vec4(mix(base, result, u_factor), c1.a);
+	// The blend color only applies where there is a base: Cs' = (1 - ab) * Cs + ab * B(Cb, Cs)
+	float alphaB = c1.a;
+	float alphaS = c2.a * u_factor; // Apply opacity (factor)
+	vec3 source = mix(blend, result, alphaB);
+
+	// Porter-Duff: co = cs * Fa + cb * Fb with premultiplied colors, and the same for alpha
+	float fa = 1.0;
+	float fb = 1.0 - alphaS;
+	if (u_operator == 1) { // In
+		fa = alphaB;
+		fb = 0.0;
+	} else if (u_operator == 2) { // Out
+		fa = 1.0 - alphaB;
+		fb = 0.0;
+	} else if (u_operator == 3) { // Atop
+		fa = alphaB;
+	} else if (u_operator == 4) { // Xor
+		fa = 1.0 - alphaB;
+	}
+	vec3 co = source * alphaS * fa + base * alphaB * fb;
+	float ao = alphaS * fa + alphaB * fb;
+
+	if (u_premultiplied == 1) {
+		gl_FragColor = vec4(co, ao);
+	} else {
+		gl_FragColor = vec4(ao > 0.0 ? co / ao : vec3(0.0), ao);
+	}
 }
 `
 
 // FXBlendNode blends two input textures.
+// The blend mode mixes the colors where both textures are, and the composite operator then
+// combines the result with the base texture by their alpha, as in the W3C spec.
 type FXBlendNode interface {
 	fxnode.FXNode
 	// SetFactor sets the opacity of the blend (0.0 to 1.0).
 	// 0.0 means fully base color, 1.0 means fully blended result.
+	// It scales the alpha of the blend texture.
 	SetFactor(f float32)
 	// SetMode sets the blending mode.
 	// See FXBlendMode constants for available modes.
 	SetMode(mode FXBlendMode)
+	// SetOperator sets the Porter-Duff operator. The default is FXCompositeOver.
+	// See FXCompositeOperator constants for available operators.
+	SetOperator(op FXCompositeOperator)
+	// SetPremultiplied sets whether the inputs have premultiplied alpha, and the output as well.
+	// By default colors are not premultiplied, like the textures loaded from images.
+	SetPremultiplied(premultiplied bool)
 	// SetInput1 sets the base texture input.
 	// This is the background image.
 	SetInput1(input fxnode.FXInput)
@@ -186,6 +294,9 @@ func NewFXBlendNode(ctx fxcontext.FXContext, width, height int) (FXBlendNode, er
 	n.SetFactor(1.0)
 	// Set default blend mode to Normal.
 	n.SetMode(FXBlendNormal)
+	// Set default operator to Over, with straight alpha.
+	n.SetOperator(FXCompositeOver)
+	n.SetPremultiplied(false)
 
 	return n, nil
 }
@@ -200,6 +311,19 @@ func (n *fxBlendNode) SetMode(mode FXBlendMode) {
 	n.SetUniform("u_mode", int(mode))
 }
 
+func (n *fxBlendNode) SetOperator(op FXCompositeOperator) {
+	// Set the composite operator uniform.
+	n.SetUniform("u_operator", int(op))
+}
+
+func (n *fxBlendNode) SetPremultiplied(premultiplied bool) {
+	val := 0
+	if premultiplied {
+		val = 1
+	}
+	n.SetUniform("u_premultiplied", val)
+}
+
 func (n *fxBlendNode) SetInput1(input fxnode.FXInput) {
 	// Set the base texture input.
 	n.SetInput("u_texture1", input)
@@ -0,0 +1,70 @@
+package fxblend
+
+import (
+	"reflect"
+
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxnode"
+)
+
+// init registers the blend node so it can be created by type name.
+func init() {
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "blend",
+		Category:    "blend",
+		Description: "Blends two textures using a blend mode and composites them by alpha.",
+		Inputs:      []string{"u_texture1", "u_texture2"},
+		GoType:      reflect.TypeOf(&fxBlendNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXBlendNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				Name:        "factor",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Opacity of the blended result.",
+				Default:     float32(1),
+				Min:         0,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_factor"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXBlendNode).SetFactor(v.(float32)) },
+			},
+			{
+				Name:        "mode",
+				Kind:        fxnode.FXParamInt,
+				Description: "Blend mode.",
+				Default:     0,
+				Options:     fxBlendModeNames,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamInt, "u_mode"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXBlendNode).SetMode(FXBlendMode(v.(int))) },
+			},
+			{
+				Name:        "operator",
+				Kind:        fxnode.FXParamInt,
+				Description: "Porter-Duff operator combining the result with the base by alpha.",
+				Default:     0,
+				Options:     fxCompositeOperatorNames,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamInt, "u_operator"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXBlendNode).SetOperator(FXCompositeOperator(v.(int))) },
+			},
+			{
+				Name:        "premultiplied",
+				Kind:        fxnode.FXParamBool,
+				Description: "Inputs and output have premultiplied alpha.",
+				Default:     false,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamBool, "u_premultiplied"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXBlendNode).SetPremultiplied(v.(bool)) },
+			},
+		},
+	})
+}
+
+// fxBlendModeNames names the blend modes in FXBlendMode order.
+var fxBlendModeNames = []string{
+	"normal", "add", "multiply", "screen", "overlay", "darken", "lighten",
+	"colorDodge", "colorBurn", "hardLight", "softLight", "difference", "exclusion",
+	"hue", "saturation", "color", "luminosity",
+}
+
+// fxCompositeOperatorNames names the composite operators in FXCompositeOperator order.
+var fxCompositeOperatorNames = []string{"over", "in", "out", "atop", "xor"}
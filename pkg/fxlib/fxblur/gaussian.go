@@ -97,6 +97,25 @@ func (n *fxGaussianBlurNode) SetRadius(r float32) {
 	n.radius = r
 }
 
+// SetFormat overrides the default to also reallocate the intermediate framebuffer,
+// so the first pass keeps the same precision as the output.
+func (n *fxGaussianBlurNode) SetFormat(format fxcore.FXTextureFormat) error {
+	if err := n.FXNode.SetFormat(format); err != nil {
+		return err
+	}
+	if n.tempFB.GetTexture().GetFormat() == format {
+		return nil
+	}
+	w, h := n.tempFB.GetTexture().GetSize()
+	tempFB, err := fxcore.NewFXFramebufferWithFormat(w, h, format)
+	if err != nil {
+		return err
+	}
+	n.tempFB.Release()
+	n.tempFB = tempFB
+	return nil
+}
+
 // Process overrides the default process to implement two-pass blur
 func (n *fxGaussianBlurNode) Process(ctx fxcontext.FXContext) error {
 	if !n.IsDirty() {
@@ -110,11 +129,9 @@ func (n *fxGaussianBlurNode) Process(ctx fxcontext.FXContext) error {
 	}
 
 	// 2. Process Input if it's a Node
-	if inputNode, ok := input.(fxnode.FXNode); ok {
-		if inputNode.IsDirty() {
-			if err := inputNode.Process(ctx); err != nil {
-				return err
-			}
+	if inputNode, ok := input.(fxnode.FXNode); ok && inputNode.IsDirty() {
+		if err := fxnode.FXProcessInput(ctx, inputNode); err != nil {
+			return err
 		}
 	}
 	inputTex := input.GetTexture()
@@ -0,0 +1,151 @@
+package fxblur
+
+import (
+	"reflect"
+
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxnode"
+)
+
+// init registers the blur nodes so they can be created by type name.
+func init() {
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "boxBlur",
+		Category:    "blur",
+		Description: "Averages a 5x5 neighbourhood of pixels.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxBoxBlurNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXBoxBlurNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				Name:        "radius",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Spacing of the samples in pixels.",
+				Default:     float32(0),
+				Min:         0,
+				Max:         10,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_radius"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXBoxBlurNode).SetRadius(v.(float32)) },
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "gaussianBlur",
+		Category:    "blur",
+		Description: "Two-pass separable Gaussian blur.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxGaussianBlurNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXGaussianBlurNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				// The radius is passed to the shader per pass, so read it from the node.
+				Name:        "radius",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Blur radius (sigma) in pixels.",
+				Default:     float32(0),
+				Min:         0,
+				Max:         20,
+				Get:         func(node fxnode.FXNode) interface{} { return node.(*fxGaussianBlurNode).radius },
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXGaussianBlurNode).SetRadius(v.(float32)) },
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "motionBlur",
+		Category:    "blur",
+		Description: "Blurs along a direction to simulate motion.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxMotionBlurNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXMotionBlurNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				// Angle and strength are combined into u_velocity, so read them from the node.
+				Name:        "angle",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Direction of the blur in degrees.",
+				Default:     float32(0),
+				Min:         0,
+				Max:         360,
+				Get:         func(node fxnode.FXNode) interface{} { return node.(*fxMotionBlurNode).angle },
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXMotionBlurNode).SetAngle(v.(float32)) },
+			},
+			{
+				Name:        "strength",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Length of the blur trail in normalized coordinates.",
+				Default:     float32(0.01),
+				Min:         0,
+				Max:         1,
+				Get:         func(node fxnode.FXNode) interface{} { return node.(*fxMotionBlurNode).strength },
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXMotionBlurNode).SetStrength(v.(float32)) },
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "radialBlur",
+		Category:    "blur",
+		Description: "Blurs towards a center point (zoom blur).",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxRadialBlurNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXRadialBlurNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				Name:        "center",
+				Kind:        fxnode.FXParamVec2,
+				Description: "Center of the blur in normalized coordinates.",
+				Default:     []float32{0.5, 0.5},
+				Min:         0,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamVec2, "u_center"),
+				Set: func(node fxnode.FXNode, v interface{}) {
+					c := v.([]float32)
+					node.(FXRadialBlurNode).SetCenter(c[0], c[1])
+				},
+			},
+			{
+				Name:        "strength",
+				Kind:        fxnode.FXParamFloat,
+				Description: "How far pixels are pulled towards the center.",
+				Default:     float32(0.1),
+				Min:         0,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_strength"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXRadialBlurNode).SetStrength(v.(float32)) },
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "sharpen",
+		Category:    "blur",
+		Description: "Sharpens the image with a Laplacian kernel.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxSharpenNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXSharpenNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				Name:        "amount",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Sharpening amount.",
+				Default:     float32(0.5),
+				Min:         0,
+				Max:         2,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_amount"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXSharpenNode).SetAmount(v.(float32)) },
+			},
+		},
+	})
+}
@@ -0,0 +1,346 @@
+package fxcolor
+
+import (
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxcore"
+	"kdfx/pkg/fxnode"
+)
+
+// FXChromaKeySpace represents the color space in which colors are compared with the key color.
+type FXChromaKeySpace int
+
+const (
+	// FXChromaKeyYCbCr compares the chroma (Cb, Cr) of the colors and ignores their brightness,
+	// so shadows and uneven lighting on the screen key evenly.
+	FXChromaKeyYCbCr FXChromaKeySpace = iota
+	// FXChromaKeyHSV compares the hue and saturation of the colors.
+	FXChromaKeyHSV
+)
+
+// FXMaxMatteRadius is the largest choke or feather radius in pixels.
+const FXMaxMatteRadius = 32
+
+// FXChromaKeyFS is the fragment shader that pulls the matte and suppresses the spill.
+// The output holds the foreground color with the matte in alpha, not premultiplied.
+const FXChromaKeyFS = `
+#ifdef GL_FRAGMENT_PRECISION_HIGH
+precision highp float;
+#else
+precision mediump float;
+#endif
+varying vec2 v_texCoord;
+uniform sampler2D u_texture;
+
+uniform vec3 u_keyColor;
+uniform int u_space;
+uniform float u_tolerance;
+uniform float u_softness;
+uniform float u_spill;
+
+// BT.601 conversions, with Cb and Cr from -0.5 to 0.5.
+vec3 rgb2ycc(vec3 c) {
+	float y = dot(c, vec3(0.299, 0.587, 0.114));
+	return vec3(y, (c.b - y) * 0.564, (c.r - y) * 0.713);
+}
+
+vec3 ycc2rgb(vec3 c) {
+	float r = c.x + 1.403 * c.z;
+	float b = c.x + 1.773 * c.y;
+	return vec3(r, (c.x - 0.299 * r - 0.114 * b) / 0.587, b);
+}
+
+// hueSaturation returns the HSV hue in turns and saturation of a color.
+vec2 hueSaturation(vec3 c) {
+	float cMax = max(c.r, max(c.g, c.b));
+	float d = cMax - min(c.r, min(c.g, c.b));
+	if (d <= 0.0 || cMax <= 0.0) {
+		return vec2(0.0);
+	}
+	float h;
+	if (cMax == c.r) {
+		h = mod((c.g - c.b) / d, 6.0);
+	} else if (cMax == c.g) {
+		h = (c.b - c.r) / d + 2.0;
+	} else {
+		h = (c.r - c.g) / d + 4.0;
+	}
+	return vec2(h / 6.0, clamp(d / cMax, 0.0, 1.0));
+}
+
+void main() {
+	vec4 color = texture2D(u_texture, v_texCoord);
+	vec3 ycc = rgb2ycc(color.rgb);
+	vec3 key = rgb2ycc(u_keyColor);
+
+	// 1. Distance from the key color, from 0.0 to about 1.0
+	float d;
+	if (u_space == 1) { // HSV
+		vec2 a = hueSaturation(color.rgb);
+		vec2 b = hueSaturation(u_keyColor);
+		float dh = abs(a.x - b.x);
+		// Dull colors have no reliable hue, so the saturation difference counts as well.
+		d = max(min(dh, 1.0 - dh) * 2.0, abs(a.y - b.y));
+	} else { // YCbCr
+		d = distance(ycc.yz, key.yz);
+	}
+	float matte = smoothstep(u_tolerance, u_tolerance + max(u_softness, 0.0001), d);
+
+	// 2. Spill: remove the part of the chroma that points towards the key color, keeping the luma.
+	float keyChroma = length(key.yz);
+	if (keyChroma > 0.0) {
+		vec2 dir = key.yz / keyChroma;
+		ycc.yz -= dir * max(dot(ycc.yz, dir), 0.0) * u_spill;
+	}
+
+	gl_FragColor = vec4(ycc2rgb(ycc), color.a * matte);
+}
+`
+
+// FXMatteFS is the fragment shader for the passes that choke and feather the matte in alpha.
+// Each pass works along u_step, so a horizontal and a vertical pass cover a square (choke) or
+// a Gaussian (feather). The color is passed through.
+const FXMatteFS = `
+precision mediump float;
+varying vec2 v_texCoord;
+uniform sampler2D u_texture;
+
+uniform vec2 u_step;
+uniform float u_radius;
+uniform int u_operation;
+uniform int u_matteOnly;
+
+const int MAX_RADIUS = 32;
+
+void main() {
+	vec4 color = texture2D(u_texture, v_texCoord);
+	float a = color.a;
+	float total = 1.0;
+	float sigma = max(u_radius, 1.0) / 2.0;
+	for (int i = 1; i <= MAX_RADIUS; i++) {
+		float d = float(i);
+		if (d > u_radius) {
+			break;
+		}
+		float a1 = texture2D(u_texture, v_texCoord + u_step * d).a;
+		float a2 = texture2D(u_texture, v_texCoord - u_step * d).a;
+		if (u_operation == 0) { // Erode
+			a = min(a, min(a1, a2));
+		} else if (u_operation == 1) { // Dilate
+			a = max(a, max(a1, a2));
+		} else { // Blur
+			float w = exp(-d * d / (2.0 * sigma * sigma));
+			a += (a1 + a2) * w;
+			total += 2.0 * w;
+		}
+	}
+	a /= total;
+
+	if (u_matteOnly == 1) {
+		gl_FragColor = vec4(vec3(a), 1.0);
+	} else {
+		gl_FragColor = vec4(color.rgb, a);
+	}
+}
+`
+
+// FXChromaKeyNode removes a key color, such as a green screen, and puts the matte in alpha.
+// The output is not premultiplied, so it can be composited with FXBlendNode.
+type FXChromaKeyNode interface {
+	fxnode.FXNode
+	// SetKeyColor sets the color of the screen (0.0 to 1.0 per channel).
+	SetKeyColor(r, g, b float32)
+	// SetColorSpace sets the color space in which colors are compared with the key color.
+	// See FXChromaKeySpace constants for available spaces.
+	SetColorSpace(space FXChromaKeySpace)
+	// SetTolerance sets the distance from the key color within which colors are fully removed (0.0 to 1.0).
+	SetTolerance(tolerance float32)
+	// SetSoftness sets the distance beyond the tolerance over which colors fade in (0.0 to 1.0).
+	SetSoftness(softness float32)
+	// SetChoke shrinks the matte by a number of pixels, or grows it if negative,
+	// up to FXMaxMatteRadius.
+	SetChoke(pixels float32)
+	// SetFeather blurs the edge of the matte over a number of pixels, up to FXMaxMatteRadius.
+	SetFeather(pixels float32)
+	// SetSpillSuppression sets how much of the key color reflected on the foreground is removed (0.0 to 1.0).
+	SetSpillSuppression(amount float32)
+	// SetMatteOnly outputs the matte as an opaque grayscale image instead of the foreground,
+	// to check the key.
+	SetMatteOnly(matteOnly bool)
+}
+
+// fxChromaKeyNode implements FXChromaKeyNode.
+// The key pass feeds the horizontal and vertical choke passes, then the horizontal feather pass,
+// and the node itself is the vertical feather pass. Passes with a zero radius are skipped.
+type fxChromaKeyNode struct {
+	fxnode.FXNode
+	// key pulls the matte and suppresses the spill.
+	key fxnode.FXNode
+	// chokeH chokes the matte horizontally.
+	chokeH fxnode.FXNode
+	// chokeV chokes the matte vertically.
+	chokeV fxnode.FXNode
+	// featherH feathers the matte horizontally.
+	featherH fxnode.FXNode
+	// choke is the choke radius in pixels; negative values grow the matte.
+	choke float32
+	// feather is the feather radius in pixels.
+	feather float32
+}
+
+// NewFXChromaKeyNode creates a new chroma key fxnode. It keys a green screen by default.
+func NewFXChromaKeyNode(ctx fxcontext.FXContext, width, height int) (FXChromaKeyNode, error) {
+	n := &fxChromaKeyNode{}
+
+	// 1. Create the passes
+	var err error
+	if n.key, err = newFXMattePass(ctx, width, height, FXChromaKeyFS); err != nil {
+		return nil, err
+	}
+	horizontal := []float32{1 / float32(width), 0}
+	vertical := []float32{0, 1 / float32(height)}
+	passes := []struct {
+		node *fxnode.FXNode
+		step []float32
+	}{
+		{&n.chokeH, horizontal},
+		{&n.chokeV, vertical},
+		{&n.featherH, horizontal},
+		{&n.FXNode, vertical},
+	}
+	for _, pass := range passes {
+		if *pass.node, err = newFXMattePass(ctx, width, height, FXMatteFS); err != nil {
+			n.Release()
+			return nil, err
+		}
+		(*pass.node).SetUniform("u_step", pass.step)
+		(*pass.node).SetUniform("u_matteOnly", 0)
+	}
+	n.featherH.SetUniform("u_operation", 2)
+	n.FXNode.SetUniform("u_operation", 2)
+
+	// 2. Set defaults
+	n.SetKeyColor(0, 1, 0)
+	n.SetColorSpace(FXChromaKeyYCbCr)
+	n.SetTolerance(0.2)
+	n.SetSoftness(0.1)
+	n.SetSpillSuppression(1.0)
+	n.SetMatteOnly(false)
+	n.SetChoke(0)
+	n.SetFeather(0)
+
+	return n, nil
+}
+
+// newFXMattePass creates a node that renders one pass of the key.
+func newFXMattePass(ctx fxcontext.FXContext, width, height int, fs string) (fxnode.FXNode, error) {
+	base, err := fxnode.NewFXBaseNode(ctx, width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := fxcore.NewFXShaderProgram(fxcore.FXSimpleVS, fs)
+	if err != nil {
+		base.Release()
+		return nil, err
+	}
+
+	base.SetShaderProgram(program)
+	return base, nil
+}
+
+// connect chains the passes, leaving out the ones with a zero radius.
+func (n *fxChromaKeyNode) connect() {
+	var matte fxnode.FXInput = n.key
+	if n.choke != 0 {
+		n.chokeH.SetInput("u_texture", matte)
+		n.chokeV.SetInput("u_texture", n.chokeH)
+		matte = n.chokeV
+	}
+	if n.feather != 0 {
+		n.featherH.SetInput("u_texture", matte)
+		matte = n.featherH
+	}
+	n.FXNode.SetInput("u_texture", matte)
+}
+
+// SetInput connects the source to the key pass.
+func (n *fxChromaKeyNode) SetInput(name string, input fxnode.FXInput) {
+	n.key.SetInput(name, input)
+}
+
+// GetInput returns the source connected to the key pass.
+func (n *fxChromaKeyNode) GetInput(name string) fxnode.FXInput {
+	return n.key.GetInput(name)
+}
+
+func (n *fxChromaKeyNode) SetKeyColor(r, g, b float32) {
+	n.key.SetUniform("u_keyColor", []float32{r, g, b})
+}
+
+func (n *fxChromaKeyNode) SetColorSpace(space FXChromaKeySpace) {
+	n.key.SetUniform("u_space", int(space))
+}
+
+func (n *fxChromaKeyNode) SetTolerance(tolerance float32) {
+	n.key.SetUniform("u_tolerance", tolerance)
+}
+
+func (n *fxChromaKeyNode) SetSoftness(softness float32) {
+	n.key.SetUniform("u_softness", softness)
+}
+
+func (n *fxChromaKeyNode) SetChoke(pixels float32) {
+	n.choke = max(min(pixels, FXMaxMatteRadius), -FXMaxMatteRadius)
+	operation := 0 // Erode
+	if n.choke < 0 {
+		operation = 1 // Dilate
+	}
+	for _, pass := range []fxnode.FXNode{n.chokeH, n.chokeV} {
+		pass.SetUniform("u_operation", operation)
+		pass.SetUniform("u_radius", max(n.choke, -n.choke))
+	}
+	n.connect()
+}
+
+func (n *fxChromaKeyNode) SetFeather(pixels float32) {
+	n.feather = max(min(pixels, FXMaxMatteRadius), 0)
+	n.featherH.SetUniform("u_radius", n.feather)
+	n.FXNode.SetUniform("u_radius", n.feather)
+	n.connect()
+}
+
+func (n *fxChromaKeyNode) SetSpillSuppression(amount float32) {
+	n.key.SetUniform("u_spill", amount)
+}
+
+func (n *fxChromaKeyNode) SetMatteOnly(matteOnly bool) {
+	val := 0
+	if matteOnly {
+		val = 1
+	}
+	n.FXNode.SetUniform("u_matteOnly", val)
+}
+
+// MarkDirty marks the key pass, so every pass renders again on the next Process.
+// A pipeline calls it when the source has changed, since the source's own flag is cleared by then.
+func (n *fxChromaKeyNode) MarkDirty() {
+	n.key.MarkDirty()
+}
+
+// SetFormat sets the format of every pass, so the matte keeps the precision of the output.
+func (n *fxChromaKeyNode) SetFormat(format fxcore.FXTextureFormat) error {
+	for _, pass := range []fxnode.FXNode{n.key, n.chokeH, n.chokeV, n.featherH, n.FXNode} {
+		if err := pass.SetFormat(format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *fxChromaKeyNode) Release() {
+	for _, pass := range []fxnode.FXNode{n.key, n.chokeH, n.chokeV, n.featherH, n.FXNode} {
+		if pass != nil {
+			pass.Release()
+		}
+	}
+}
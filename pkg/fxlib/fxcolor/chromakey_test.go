@@ -0,0 +1,84 @@
+package fxcolor
+
+import (
+	"runtime"
+	"testing"
+
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxcore"
+	"kdfx/pkg/fxnode"
+)
+
+// solidFS fills the output with u_color.
+const solidFS = `
+precision mediump float;
+varying vec2 v_texCoord;
+uniform vec3 u_color;
+
+void main() {
+	gl_FragColor = vec4(u_color, 1.0);
+}
+`
+
+func TestChromaKeyRekeysChangingSourceInPipeline(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	ctx, err := fxcontext.NewFXEGLContext(4, 4)
+	if err != nil {
+		t.Skipf("no headless GL context: %v", err)
+	}
+	defer ctx.Destroy()
+
+	// 1. A solid green source keyed by a chroma key
+	source, err := fxnode.NewFXBaseNode(ctx, 4, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	program, err := fxcore.NewFXShaderProgram(fxcore.FXSimpleVS, solidFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source.SetShaderProgram(program)
+	source.SetUniform("u_color", []float32{0, 1, 0})
+
+	key, err := NewFXChromaKeyNode(ctx, 4, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	graph := fxnode.NewFXGraph()
+	graph.AddNode("source", source)
+	graph.AddNode("key", key)
+	if err := graph.Connect("source", "key", "u_texture"); err != nil {
+		t.Fatal(err)
+	}
+	pipeline := fxnode.NewFXPipeline(ctx, graph)
+	defer pipeline.Release()
+
+	alpha := func() uint8 {
+		t.Helper()
+		img, err := key.GetTexture().Download()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return img.RGBAAt(1, 1).A
+	}
+
+	// 2. Green is keyed out
+	if err := pipeline.Execute("key"); err != nil {
+		t.Fatal(err)
+	}
+	if a := alpha(); a != 0 {
+		t.Fatalf("green source: alpha = %d, want 0", a)
+	}
+
+	// 3. Once the source turns red, the key must render again and keep it
+	source.SetUniform("u_color", []float32{1, 0, 0})
+	if err := pipeline.Execute("key"); err != nil {
+		t.Fatal(err)
+	}
+	if a := alpha(); a != 255 {
+		t.Fatalf("red source: alpha = %d, want 255", a)
+	}
+}
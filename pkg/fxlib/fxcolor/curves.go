@@ -0,0 +1,374 @@
+package fxcolor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxcore"
+	"kdfx/pkg/fximage"
+	"kdfx/pkg/fxnode"
+)
+
+// FXCurveChannel selects one of the curves of a curves node.
+type FXCurveChannel int
+
+const (
+	// FXCurveMaster maps the red, green and blue channels alike, after their own curves.
+	FXCurveMaster FXCurveChannel = iota
+	// FXCurveRed maps the red channel.
+	FXCurveRed
+	// FXCurveGreen maps the green channel.
+	FXCurveGreen
+	// FXCurveBlue maps the blue channel.
+	FXCurveBlue
+	// FXCurveHueLuminance scales the brightness of colors by their hue.
+	FXCurveHueLuminance
+	// FXCurveHueSaturation scales the saturation of colors by their hue.
+	FXCurveHueSaturation
+)
+
+// fxCurveChannels is the number of curves of a curves node.
+const fxCurveChannels = int(FXCurveHueSaturation) + 1
+
+// fxCurveSamples is the number of samples of each curve in the lookup texture.
+const fxCurveSamples = 1024
+
+// FXCurvePoint is a control point of a curve.
+// For the tone curves X is the input and Y the output, both from 0.0 to 1.0.
+// For the hue curves X is the hue in turns (0.0 is red) and Y a gain where 0.5 leaves
+// colors unchanged, 0.0 removes the luminance or saturation and 1.0 doubles it.
+type FXCurvePoint struct {
+	X, Y float32
+}
+
+// FXCurvesFS is the fragment shader for curves.
+// The curves are sampled into a texture of 2 rows: the red, green, blue and master curves in
+// the first, and the hue-vs-luminance and hue-vs-saturation curves in the second.
+// Samples are read at texel centers and interpolated in the shader, so float textures
+// work without linear filtering.
+const FXCurvesFS = `
+#ifdef GL_FRAGMENT_PRECISION_HIGH
+precision highp float;
+#else
+precision mediump float;
+#endif
+varying vec2 v_texCoord;
+uniform sampler2D u_texture;
+uniform sampler2D u_curves;
+uniform int u_hueCurves;
+
+const float SAMPLES = 1024.0;
+
+// tone returns the channel curves in rgb and the master curve in a, at input x.
+vec4 tone(float x) {
+	float p = clamp(x, 0.0, 1.0) * (SAMPLES - 1.0);
+	float i = min(floor(p), SAMPLES - 2.0);
+	vec4 a = texture2D(u_curves, vec2((i + 0.5) / SAMPLES, 0.25));
+	vec4 b = texture2D(u_curves, vec2((i + 1.5) / SAMPLES, 0.25));
+	return mix(a, b, p - i);
+}
+
+// hueGain returns the luminance and saturation gains at a hue in turns. The curves wrap around.
+vec2 hueGain(float hue) {
+	float p = hue * SAMPLES;
+	float i = floor(p);
+	vec2 a = texture2D(u_curves, vec2((mod(i, SAMPLES) + 0.5) / SAMPLES, 0.75)).rg;
+	vec2 b = texture2D(u_curves, vec2((mod(i + 1.0, SAMPLES) + 0.5) / SAMPLES, 0.75)).rg;
+	return mix(a, b, p - i) * 2.0;
+}
+
+// hueSaturation returns the HSV hue in turns and saturation of a color.
+vec2 hueSaturation(vec3 c) {
+	float cMax = max(c.r, max(c.g, c.b));
+	float d = cMax - min(c.r, min(c.g, c.b));
+	if (d <= 0.0 || cMax <= 0.0) {
+		return vec2(0.0);
+	}
+	float h;
+	if (cMax == c.r) {
+		h = mod((c.g - c.b) / d, 6.0);
+	} else if (cMax == c.g) {
+		h = (c.b - c.r) / d + 2.0;
+	} else {
+		h = (c.r - c.g) / d + 4.0;
+	}
+	return vec2(h / 6.0, clamp(d / cMax, 0.0, 1.0));
+}
+
+void main() {
+	vec4 color = texture2D(u_texture, v_texCoord);
+
+	// 1. Channel curves, then the master curve
+	vec3 rgb = vec3(tone(color.r).r, tone(color.g).g, tone(color.b).b);
+	rgb = vec3(tone(rgb.r).a, tone(rgb.g).a, tone(rgb.b).a);
+
+	// 2. Hue curves
+	if (u_hueCurves == 1) {
+		vec2 hs = hueSaturation(rgb);
+		// Grays have no hue, so the gains fade out with the saturation.
+		vec2 gain = mix(vec2(1.0), hueGain(hs.x), hs.y);
+		float luma = dot(rgb, vec3(0.299, 0.587, 0.114));
+		rgb = (luma + (rgb - luma) * gain.y) * gain.x;
+	}
+
+	gl_FragColor = vec4(rgb, color.a);
+}
+`
+
+// FXCurvesNode remaps colors with curves through control points.
+// Each curve is a monotone cubic spline: it passes through every point and does not overshoot
+// between them. Outside the first and last points the tone curves stay flat, and the hue
+// curves wrap around from 1.0 to 0.0. Inputs outside 0.0 to 1.0 are clipped.
+type FXCurvesNode interface {
+	fxnode.FXNode
+	// SetCurve sets the control points of a curve, in any order. If two points have the same X,
+	// the last one is used. The X of hue curve points wraps around into 0.0 to 1.0, so a point
+	// at 1.0 is the same as a point at 0.0. No points resets the curve: tone curves become the
+	// identity and hue curves a flat line at 0.5. Points with a NaN or infinite coordinate
+	// are rejected and leave the curve unchanged.
+	// See FXCurveChannel constants for available curves.
+	SetCurve(channel FXCurveChannel, points []FXCurvePoint)
+	// GetCurve returns the control points of a curve, sorted by X.
+	GetCurve(channel FXCurveChannel) []FXCurvePoint
+	// ResetCurves resets all the curves.
+	ResetCurves()
+}
+
+// fxCurvesNode implements FXCurvesNode.
+type fxCurvesNode struct {
+	fxnode.FXNode
+	// curves holds the control points of each curve, indexed by FXCurveChannel.
+	curves [fxCurveChannels][]FXCurvePoint
+	// texture holds the sampled curves.
+	texture fxcore.FXTexture
+}
+
+// NewFXCurvesNode creates a new curves fxnode. All curves start reset.
+func NewFXCurvesNode(ctx fxcontext.FXContext, width, height int) (FXCurvesNode, error) {
+	base, err := fxnode.NewFXBaseNode(ctx, width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := fxcore.NewFXShaderProgram(fxcore.FXSimpleVS, FXCurvesFS)
+	if err != nil {
+		base.Release()
+		return nil, err
+	}
+
+	base.SetShaderProgram(program)
+
+	texture, err := fxcore.NewFXTextureWithFormat(fxCurveSamples, 2, lutTextureFormat())
+	if err != nil {
+		base.Release()
+		return nil, fmt.Errorf("failed to create curve texture: %w", err)
+	}
+
+	n := &fxCurvesNode{
+		FXNode:  base,
+		texture: texture,
+	}
+	n.SetInput("u_curves", fximage.NewFXImageInput(texture))
+
+	// Set defaults
+	n.ResetCurves()
+
+	return n, nil
+}
+
+func (n *fxCurvesNode) SetCurve(channel FXCurveChannel, points []FXCurvePoint) {
+	if channel < 0 || int(channel) >= fxCurveChannels {
+		return
+	}
+	for _, p := range points {
+		if !isFinite(p.X) || !isFinite(p.Y) {
+			return
+		}
+	}
+	if channel == FXCurveHueLuminance || channel == FXCurveHueSaturation {
+		points = wrapCurvePoints(points)
+	}
+	n.curves[channel] = sortCurvePoints(points)
+	n.updateCurves()
+}
+
+func (n *fxCurvesNode) GetCurve(channel FXCurveChannel) []FXCurvePoint {
+	if channel < 0 || int(channel) >= fxCurveChannels {
+		return nil
+	}
+	return append([]FXCurvePoint(nil), n.curves[channel]...)
+}
+
+func (n *fxCurvesNode) ResetCurves() {
+	for i := range n.curves {
+		n.curves[i] = nil
+	}
+	n.updateCurves()
+}
+
+// updateCurves samples every curve into the texture.
+func (n *fxCurvesNode) updateCurves() {
+	// 1. Build the splines
+	var splines [fxCurveChannels]*fxSpline
+	for i, points := range n.curves {
+		channel := FXCurveChannel(i)
+		hue := channel == FXCurveHueLuminance || channel == FXCurveHueSaturation
+		switch {
+		case len(points) > 0:
+			splines[i] = newFXSpline(points, hue)
+		case hue:
+			splines[i] = newFXSpline([]FXCurvePoint{{0, 0.5}}, true)
+		default:
+			splines[i] = newFXSpline([]FXCurvePoint{{0, 0}, {1, 1}}, false)
+		}
+	}
+
+	// 2. Sample them, the tone curves from 0 to 1 and the hue curves around the circle
+	pixels := make([]float32, fxCurveSamples*2*4)
+	hueRow := pixels[fxCurveSamples*4:]
+	for i := 0; i < fxCurveSamples; i++ {
+		x := float64(i) / (fxCurveSamples - 1)
+		pixels[i*4] = splines[FXCurveRed].eval(x)
+		pixels[i*4+1] = splines[FXCurveGreen].eval(x)
+		pixels[i*4+2] = splines[FXCurveBlue].eval(x)
+		pixels[i*4+3] = splines[FXCurveMaster].eval(x)
+
+		hue := float64(i) / fxCurveSamples
+		hueRow[i*4] = splines[FXCurveHueLuminance].eval(hue)
+		hueRow[i*4+1] = splines[FXCurveHueSaturation].eval(hue)
+		hueRow[i*4+3] = 1
+	}
+	n.texture.UploadFloat(pixels)
+
+	// 3. Skip the hue conversion while the hue curves are flat
+	hueCurves := 0
+	if len(n.curves[FXCurveHueLuminance]) > 0 || len(n.curves[FXCurveHueSaturation]) > 0 {
+		hueCurves = 1
+	}
+	n.SetUniform("u_hueCurves", hueCurves)
+	n.MarkDirty()
+}
+
+func (n *fxCurvesNode) Release() {
+	n.texture.Release()
+	n.FXNode.Release()
+}
+
+// sortCurvePoints returns the points sorted by X, keeping the last of points with the same X.
+func sortCurvePoints(points []FXCurvePoint) []FXCurvePoint {
+	sorted := append([]FXCurvePoint(nil), points...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].X < sorted[j].X })
+	unique := sorted[:0]
+	for _, p := range sorted {
+		if len(unique) > 0 && unique[len(unique)-1].X == p.X {
+			unique[len(unique)-1] = p
+			continue
+		}
+		unique = append(unique, p)
+	}
+	return unique
+}
+
+// wrapCurvePoints returns the points with X wrapped around into [0, 1).
+func wrapCurvePoints(points []FXCurvePoint) []FXCurvePoint {
+	wrapped := make([]FXCurvePoint, len(points))
+	for i, p := range points {
+		x := p.X - float32(math.Floor(float64(p.X)))
+		// Tiny negative X rounds up to 1.0 in float32.
+		if x >= 1 {
+			x = 0
+		}
+		wrapped[i] = FXCurvePoint{X: x, Y: p.Y}
+	}
+	return wrapped
+}
+
+// isFinite reports whether v is neither NaN nor infinite.
+func isFinite(v float32) bool {
+	f := float64(v)
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// fxSpline is a monotone cubic spline (Fritsch-Carlson): a cubic Hermite spline whose tangents
+// are limited so that it never overshoots between points.
+type fxSpline struct {
+	// x and y are the coordinates of the points, sorted by x.
+	x, y []float64
+	// m are the tangents at the points.
+	m []float64
+}
+
+// newFXSpline creates a spline through points sorted by X with no duplicates.
+// Periodic splines repeat every 1.0 and need every X in [0, 1).
+func newFXSpline(points []FXCurvePoint, periodic bool) *fxSpline {
+	s := &fxSpline{}
+	if periodic {
+		// Copies of the points one period before and after make the curve wrap smoothly.
+		for _, offset := range []float64{-1, 0, 1} {
+			for _, p := range points {
+				s.x = append(s.x, float64(p.X)+offset)
+				s.y = append(s.y, float64(p.Y))
+			}
+		}
+	} else {
+		for _, p := range points {
+			s.x = append(s.x, float64(p.X))
+			s.y = append(s.y, float64(p.Y))
+		}
+	}
+
+	count := len(s.x)
+	s.m = make([]float64, count)
+	if count < 2 {
+		return s
+	}
+
+	// 1. Slopes of the segments
+	slopes := make([]float64, count-1)
+	for i := range slopes {
+		slopes[i] = (s.y[i+1] - s.y[i]) / (s.x[i+1] - s.x[i])
+	}
+
+	// 2. Tangents: the mean of the neighboring slopes, or flat at a peak or valley
+	s.m[0], s.m[count-1] = slopes[0], slopes[count-2]
+	for i := 1; i < count-1; i++ {
+		if slopes[i-1]*slopes[i] > 0 {
+			s.m[i] = (slopes[i-1] + slopes[i]) / 2
+		}
+	}
+
+	// 3. Limit the tangents of each segment so it stays monotone
+	for i, slope := range slopes {
+		if slope == 0 {
+			s.m[i], s.m[i+1] = 0, 0
+			continue
+		}
+		a, b := s.m[i]/slope, s.m[i+1]/slope
+		if r := a*a + b*b; r > 9 {
+			t := 3 / math.Sqrt(r)
+			s.m[i], s.m[i+1] = t*a*slope, t*b*slope
+		}
+	}
+	return s
+}
+
+// eval returns the value of the spline at x. It is flat outside the points.
+func (s *fxSpline) eval(x float64) float32 {
+	count := len(s.x)
+	if x <= s.x[0] {
+		return float32(s.y[0])
+	}
+	if x >= s.x[count-1] {
+		return float32(s.y[count-1])
+	}
+
+	// Segment i goes from point i to point i+1.
+	i := sort.SearchFloat64s(s.x, x) - 1
+	h := s.x[i+1] - s.x[i]
+	t := (x - s.x[i]) / h
+	t2, t3 := t*t, t*t*t
+	y := (2*t3-3*t2+1)*s.y[i] + (t3-2*t2+t)*h*s.m[i] + (-2*t3+3*t2)*s.y[i+1] + (t3-t2)*h*s.m[i+1]
+	return float32(y)
+}
@@ -0,0 +1,393 @@
+package fxcolor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FXMaxLUTSize is the largest number of entries per axis accepted for 3D tables.
+const FXMaxLUTSize = 256
+
+// FXMaxLUT1DSize is the largest number of entries accepted for 1D tables.
+const FXMaxLUT1DSize = 65536
+
+// FXLUT is a color lookup table, as loaded from a .cube or .3dl file.
+type FXLUT struct {
+	// Title is the title of the table, if the file has one.
+	Title string
+	// Dimensions is 1 for a curve applied to each channel, or 3 for a cube.
+	Dimensions int
+	// Size is the number of entries along each axis.
+	Size int
+	// DomainMin is the input value mapped to the first entry, per channel.
+	DomainMin [3]float32
+	// DomainMax is the input value mapped to the last entry, per channel.
+	DomainMax [3]float32
+	// Data holds the output RGB triples. 1D tables have Size entries. 3D tables have Size^3
+	// entries with red changing fastest, then green, then blue, as in .cube files:
+	// entry (r, g, b) starts at 3*(r + g*Size + b*Size*Size).
+	Data []float32
+}
+
+// NewFXIdentityLUT creates a table that leaves colors unchanged.
+func NewFXIdentityLUT(dimensions, size int) *FXLUT {
+	lut := &FXLUT{
+		Dimensions: dimensions,
+		Size:       size,
+		DomainMax:  [3]float32{1, 1, 1},
+	}
+	step := 1 / float32(size-1)
+	if dimensions == 1 {
+		lut.Data = make([]float32, 0, size*3)
+		for i := 0; i < size; i++ {
+			v := float32(i) * step
+			lut.Data = append(lut.Data, v, v, v)
+		}
+		return lut
+	}
+	lut.Data = make([]float32, 0, size*size*size*3)
+	for b := 0; b < size; b++ {
+		for g := 0; g < size; g++ {
+			for r := 0; r < size; r++ {
+				lut.Data = append(lut.Data, float32(r)*step, float32(g)*step, float32(b)*step)
+			}
+		}
+	}
+	return lut
+}
+
+// entries returns the number of RGB triples the table should hold.
+func (l *FXLUT) entries() int {
+	if l.Dimensions == 1 {
+		return l.Size
+	}
+	return l.Size * l.Size * l.Size
+}
+
+// validate checks that the table is consistent.
+func (l *FXLUT) validate() error {
+	switch l.Dimensions {
+	case 1:
+		if l.Size < 2 || l.Size > FXMaxLUT1DSize {
+			return fmt.Errorf("1D table size %d is out of range 2 to %d", l.Size, FXMaxLUT1DSize)
+		}
+	case 3:
+		if l.Size < 2 || l.Size > FXMaxLUTSize {
+			return fmt.Errorf("3D table size %d is out of range 2 to %d", l.Size, FXMaxLUTSize)
+		}
+	default:
+		return fmt.Errorf("table has %d dimensions, expected 1 or 3", l.Dimensions)
+	}
+	if len(l.Data) != l.entries()*3 {
+		return fmt.Errorf("table has %d values, expected %d", len(l.Data), l.entries()*3)
+	}
+	for i, v := range l.Data {
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return fmt.Errorf("entry %d is not a finite number", i/3)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if !(l.DomainMax[i] > l.DomainMin[i]) {
+			return fmt.Errorf("domain maximum %g is not above minimum %g", l.DomainMax[i], l.DomainMin[i])
+		}
+	}
+	return nil
+}
+
+// FXLoadLUT loads a table from a .cube or .3dl file, chosen by the file extension.
+func FXLoadLUT(path string) (*FXLUT, error) {
+	var read func(io.Reader) (*FXLUT, error)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".cube":
+		read = FXReadCubeLUT
+	case ".3dl":
+		read = FXRead3DLLUT
+	default:
+		return nil, fmt.Errorf("unknown LUT file extension %q", filepath.Ext(path))
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	lut, err := read(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return lut, nil
+}
+
+// FXReadCubeLUT reads a table in the Adobe .cube format, with either LUT_1D_SIZE or LUT_3D_SIZE.
+// DOMAIN_MIN and DOMAIN_MAX are supported, as well as the LUT_1D_INPUT_RANGE and
+// LUT_3D_INPUT_RANGE keywords written by some applications. Unknown keywords are ignored.
+func FXReadCubeLUT(r io.Reader) (*FXLUT, error) {
+	lut := &FXLUT{DomainMax: [3]float32{1, 1, 1}}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+
+		// Data lines start with a number; everything else is a keyword.
+		if !isLUTNumber(fields[0]) {
+			if err := parseCubeKeyword(lut, fields, text); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			continue
+		}
+
+		if lut.Dimensions == 0 {
+			return nil, fmt.Errorf("line %d: data before LUT_1D_SIZE or LUT_3D_SIZE", line)
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected 3 values, got %d", line, len(fields))
+		}
+		if len(lut.Data) == lut.entries()*3 {
+			return nil, fmt.Errorf("line %d: more than %d entries", line, lut.entries())
+		}
+		for _, field := range fields {
+			v, err := strconv.ParseFloat(field, 32)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid value %q", line, field)
+			}
+			lut.Data = append(lut.Data, float32(v))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if lut.Dimensions == 0 {
+		return nil, fmt.Errorf("missing LUT_1D_SIZE or LUT_3D_SIZE")
+	}
+	if err := lut.validate(); err != nil {
+		return nil, err
+	}
+	return lut, nil
+}
+
+// parseCubeKeyword applies a .cube keyword line to the table.
+func parseCubeKeyword(lut *FXLUT, fields []string, text string) error {
+	switch fields[0] {
+	case "TITLE":
+		lut.Title = strings.Trim(strings.TrimSpace(strings.TrimPrefix(text, "TITLE")), `"`)
+	case "LUT_1D_SIZE", "LUT_3D_SIZE":
+		if lut.Dimensions != 0 {
+			return fmt.Errorf("more than one table size")
+		}
+		if len(fields) != 2 {
+			return fmt.Errorf("%s needs 1 value", fields[0])
+		}
+		size, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return fmt.Errorf("invalid size %q", fields[1])
+		}
+		lut.Dimensions, lut.Size = 3, size
+		limit := FXMaxLUTSize
+		if fields[0] == "LUT_1D_SIZE" {
+			lut.Dimensions, limit = 1, FXMaxLUT1DSize
+		}
+		// Check the size before allocating for it.
+		if size < 2 || size > limit {
+			return fmt.Errorf("%s %d is out of range 2 to %d", fields[0], size, limit)
+		}
+		lut.Data = make([]float32, 0, lut.entries()*3)
+	case "DOMAIN_MIN", "DOMAIN_MAX":
+		values, err := parseLUTValues(fields[1:], 3)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fields[0], err)
+		}
+		if fields[0] == "DOMAIN_MIN" {
+			copy(lut.DomainMin[:], values)
+		} else {
+			copy(lut.DomainMax[:], values)
+		}
+	case "LUT_1D_INPUT_RANGE", "LUT_3D_INPUT_RANGE":
+		values, err := parseLUTValues(fields[1:], 2)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fields[0], err)
+		}
+		lut.DomainMin = [3]float32{values[0], values[0], values[0]}
+		lut.DomainMax = [3]float32{values[1], values[1], values[1]}
+	}
+	return nil
+}
+
+// FXRead3DLLUT reads a table in the Autodesk .3dl format. The first line of numbers lists the
+// input values of the mesh, which must be evenly spaced from 0; its length is the size of the
+// cube. The entries follow as integer triples with blue changing fastest. Their bit depth is
+// taken from a "Mesh <input bits> <output bits>" line if present, and otherwise from the
+// largest value, as 10, 12, 14 or 16 bits.
+func FXRead3DLLUT(r io.Reader) (*FXLUT, error) {
+	var mesh []float32
+	var values []float32
+	outputBits := 0
+	maxValue := float32(0)
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+
+		// Keyword lines such as "3DMESH" or "gamma 1.0" are ignored, except the bit depths.
+		if !isLUTNumber(fields[0]) {
+			if strings.EqualFold(fields[0], "Mesh") && len(fields) == 3 {
+				bits, err := strconv.Atoi(fields[2])
+				if err != nil || bits < 1 || bits > 32 {
+					return nil, fmt.Errorf("line %d: invalid output bit depth %q", line, fields[2])
+				}
+				outputBits = bits
+			}
+			continue
+		}
+
+		if mesh == nil {
+			m, err := parseLUTValues(fields, len(fields))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if len(m) < 2 || len(m) > FXMaxLUTSize {
+				return nil, fmt.Errorf("line %d: mesh size %d is out of range 2 to %d", line, len(m), FXMaxLUTSize)
+			}
+			mesh = m
+			values = make([]float32, 0, len(mesh)*len(mesh)*len(mesh)*3)
+			continue
+		}
+
+		v, err := parseLUTValues(fields, 3)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(values) == cap(values) {
+			return nil, fmt.Errorf("line %d: more than %d entries", line, cap(values)/3)
+		}
+		for _, c := range v {
+			maxValue = max(maxValue, c)
+		}
+		values = append(values, v...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if mesh == nil {
+		return nil, fmt.Errorf("missing mesh line")
+	}
+	if len(values) != cap(values) {
+		return nil, fmt.Errorf("table has %d entries, expected %d", len(values)/3, cap(values)/3)
+	}
+
+	// Scale the integers to 0 to 1.
+	if outputBits == 0 {
+		outputBits = 16
+		for _, bits := range []int{10, 12, 14} {
+			if maxValue <= float32(int(1)<<bits-1) {
+				outputBits = bits
+				break
+			}
+		}
+	}
+	scale := 1 / float32(math.Pow(2, float64(outputBits))-1)
+
+	// Reorder from blue fastest to red fastest.
+	size := len(mesh)
+	lut := &FXLUT{
+		Dimensions: 3,
+		Size:       size,
+		DomainMax:  [3]float32{1, 1, 1},
+		Data:       make([]float32, len(values)),
+	}
+	for i := 0; i < size*size*size; i++ {
+		r, g, b := i/(size*size), i/size%size, i%size
+		dst := 3 * (r + g*size + b*size*size)
+		for c := 0; c < 3; c++ {
+			lut.Data[dst+c] = values[i*3+c] * scale
+		}
+	}
+	return lut, nil
+}
+
+// isLUTNumber returns true if the field is a number rather than a keyword such as "3DMESH".
+func isLUTNumber(field string) bool {
+	c := field[0]
+	if !(c >= '0' && c <= '9' || c == '-' || c == '+' || c == '.') {
+		return false
+	}
+	_, err := strconv.ParseFloat(field, 32)
+	return err == nil
+}
+
+// parseLUTValues parses exactly count numbers.
+func parseLUTValues(fields []string, count int) ([]float32, error) {
+	if len(fields) != count {
+		return nil, fmt.Errorf("expected %d values, got %d", count, len(fields))
+	}
+	values := make([]float32, count)
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(field, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q", field)
+		}
+		values[i] = float32(v)
+	}
+	return values, nil
+}
+
+// WriteCube writes the table in the Adobe .cube format.
+func (l *FXLUT) WriteCube(w io.Writer) error {
+	if err := l.validate(); err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(w)
+	if l.Title != "" {
+		fmt.Fprintf(out, "TITLE \"%s\"\n", strings.ReplaceAll(l.Title, `"`, "'"))
+	}
+	if l.Dimensions == 1 {
+		fmt.Fprintf(out, "LUT_1D_SIZE %d\n", l.Size)
+	} else {
+		fmt.Fprintf(out, "LUT_3D_SIZE %d\n", l.Size)
+	}
+	if l.DomainMin != [3]float32{0, 0, 0} || l.DomainMax != [3]float32{1, 1, 1} {
+		fmt.Fprintf(out, "DOMAIN_MIN %s %s %s\n", formatLUTValue(l.DomainMin[0]), formatLUTValue(l.DomainMin[1]), formatLUTValue(l.DomainMin[2]))
+		fmt.Fprintf(out, "DOMAIN_MAX %s %s %s\n", formatLUTValue(l.DomainMax[0]), formatLUTValue(l.DomainMax[1]), formatLUTValue(l.DomainMax[2]))
+	}
+	for i := 0; i < len(l.Data); i += 3 {
+		fmt.Fprintf(out, "%s %s %s\n", formatLUTValue(l.Data[i]), formatLUTValue(l.Data[i+1]), formatLUTValue(l.Data[i+2]))
+	}
+	return out.Flush()
+}
+
+// formatLUTValue formats a value with the precision used by most .cube writers.
+func formatLUTValue(v float32) string {
+	return strconv.FormatFloat(float64(v), 'f', 6, 32)
+}
+
+// FXSaveCubeLUT writes the table to a .cube file.
+func FXSaveCubeLUT(path string, lut *FXLUT) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := lut.WriteCube(file); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,344 @@
+package fxcolor
+
+import (
+	"fmt"
+
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxcore"
+	"kdfx/pkg/fximage"
+	"kdfx/pkg/fxnode"
+)
+
+// FXLUTInterpolation represents how a 3D table is interpolated between its entries.
+type FXLUTInterpolation int
+
+const (
+	// FXLUTTrilinear blends the 8 entries around the color.
+	FXLUTTrilinear FXLUTInterpolation = iota
+	// FXLUTTetrahedral blends the 4 entries of the tetrahedron containing the color.
+	// It is the usual choice for grading, and keeps grays on the neutral axis.
+	FXLUTTetrahedral
+)
+
+// FXLUTFS is the fragment shader for color lookup tables.
+// GLES 2 has no 3D textures, so cubes are stored as a row of blue slices: entry (r, g, b)
+// is the texel (r + b * size, g). 1D tables are stored as a single row.
+// Entries are read at texel centers and interpolated in the shader, which works for float
+// textures without linear filtering and allows tetrahedral interpolation.
+const FXLUTFS = `
+#ifdef GL_FRAGMENT_PRECISION_HIGH
+precision highp float;
+#else
+precision mediump float;
+#endif
+varying vec2 v_texCoord;
+uniform sampler2D u_texture;
+uniform sampler2D u_lut;
+
+uniform int u_dimensions;
+uniform float u_size;
+uniform vec3 u_domainMin;
+uniform vec3 u_domainMax;
+uniform int u_interpolation;
+uniform float u_mix;
+
+// entry returns the cube entry at integer coordinates.
+vec3 entry(vec3 i) {
+	vec2 texel = vec2(i.r + i.b * u_size, i.g) + 0.5;
+	return texture2D(u_lut, texel / vec2(u_size * u_size, u_size)).rgb;
+}
+
+// curve returns the 1D table entry at an integer index.
+vec3 curve(float i) {
+	return texture2D(u_lut, vec2((i + 0.5) / u_size, 0.5)).rgb;
+}
+
+vec3 trilinear(vec3 i, vec3 f) {
+	vec3 c00 = mix(entry(i), entry(i + vec3(1.0, 0.0, 0.0)), f.r);
+	vec3 c10 = mix(entry(i + vec3(0.0, 1.0, 0.0)), entry(i + vec3(1.0, 1.0, 0.0)), f.r);
+	vec3 c01 = mix(entry(i + vec3(0.0, 0.0, 1.0)), entry(i + vec3(1.0, 0.0, 1.0)), f.r);
+	vec3 c11 = mix(entry(i + vec3(0.0, 1.0, 1.0)), entry(i + vec3(1.0, 1.0, 1.0)), f.r);
+	return mix(mix(c00, c10, f.g), mix(c01, c11, f.g), f.b);
+}
+
+vec3 tetrahedral(vec3 i, vec3 f) {
+	vec3 c000 = entry(i);
+	vec3 c111 = entry(i + 1.0);
+	if (f.r > f.g) {
+		if (f.g > f.b) { // r > g > b
+			return (1.0 - f.r) * c000 + (f.r - f.g) * entry(i + vec3(1.0, 0.0, 0.0))
+				+ (f.g - f.b) * entry(i + vec3(1.0, 1.0, 0.0)) + f.b * c111;
+		} else if (f.r > f.b) { // r > b >= g
+			return (1.0 - f.r) * c000 + (f.r - f.b) * entry(i + vec3(1.0, 0.0, 0.0))
+				+ (f.b - f.g) * entry(i + vec3(1.0, 0.0, 1.0)) + f.g * c111;
+		}
+		// b >= r > g
+		return (1.0 - f.b) * c000 + (f.b - f.r) * entry(i + vec3(0.0, 0.0, 1.0))
+			+ (f.r - f.g) * entry(i + vec3(1.0, 0.0, 1.0)) + f.g * c111;
+	}
+	if (f.b > f.g) { // b > g >= r
+		return (1.0 - f.b) * c000 + (f.b - f.g) * entry(i + vec3(0.0, 0.0, 1.0))
+			+ (f.g - f.r) * entry(i + vec3(0.0, 1.0, 1.0)) + f.r * c111;
+	} else if (f.b > f.r) { // g >= b > r
+		return (1.0 - f.g) * c000 + (f.g - f.b) * entry(i + vec3(0.0, 1.0, 0.0))
+			+ (f.b - f.r) * entry(i + vec3(0.0, 1.0, 1.0)) + f.r * c111;
+	}
+	// g >= r >= b
+	return (1.0 - f.g) * c000 + (f.g - f.r) * entry(i + vec3(0.0, 1.0, 0.0))
+		+ (f.r - f.b) * entry(i + vec3(1.0, 1.0, 0.0)) + f.b * c111;
+}
+
+void main() {
+	vec4 color = texture2D(u_texture, v_texCoord);
+
+	// Position in the table, split into the lower entry and the fraction towards the next.
+	vec3 x = clamp((color.rgb - u_domainMin) / (u_domainMax - u_domainMin), 0.0, 1.0) * (u_size - 1.0);
+	vec3 i = min(floor(x), u_size - 2.0);
+	vec3 f = x - i;
+
+	vec3 graded;
+	if (u_dimensions == 1) {
+		graded.r = mix(curve(i.r).r, curve(i.r + 1.0).r, f.r);
+		graded.g = mix(curve(i.g).g, curve(i.g + 1.0).g, f.g);
+		graded.b = mix(curve(i.b).b, curve(i.b + 1.0).b, f.b);
+	} else if (u_interpolation == 1) {
+		graded = tetrahedral(i, f);
+	} else {
+		graded = trilinear(i, f);
+	}
+
+	gl_FragColor = vec4(mix(color.rgb, graded, u_mix), color.a);
+}
+`
+
+// FXLUTNode applies a color lookup table to the input texture.
+type FXLUTNode interface {
+	fxnode.FXNode
+	// SetLUT uploads a 1D or 3D table and applies it instead of the current one.
+	// 1D tables longer than the maximum texture size are resampled to fit.
+	SetLUT(lut *FXLUT) error
+	// LoadLUT loads a .cube or .3dl file and applies it.
+	LoadLUT(path string) error
+	// GetLUT returns the table being applied. It must not be modified.
+	GetLUT() *FXLUT
+	// SetInterpolation sets how 3D tables are interpolated between entries.
+	// See FXLUTInterpolation constants for available modes.
+	SetInterpolation(interpolation FXLUTInterpolation)
+	// SetMix sets how much of the graded color replaces the input (0.0 to 1.0).
+	SetMix(amount float32)
+}
+
+// fxLUTNode implements FXLUTNode.
+type fxLUTNode struct {
+	fxnode.FXNode
+	// lut is the table being applied.
+	lut *FXLUT
+	// texture holds the entries of the table.
+	texture fxcore.FXTexture
+}
+
+// NewFXLUTNode creates a new lookup table fxnode. It applies an identity table until SetLUT is called.
+func NewFXLUTNode(ctx fxcontext.FXContext, width, height int) (FXLUTNode, error) {
+	base, err := fxnode.NewFXBaseNode(ctx, width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := fxcore.NewFXShaderProgram(fxcore.FXSimpleVS, FXLUTFS)
+	if err != nil {
+		base.Release()
+		return nil, err
+	}
+
+	base.SetShaderProgram(program)
+
+	n := &fxLUTNode{
+		FXNode: base,
+	}
+
+	// Set defaults
+	if err := n.SetLUT(NewFXIdentityLUT(3, 2)); err != nil {
+		base.Release()
+		return nil, err
+	}
+	n.SetInterpolation(FXLUTTetrahedral)
+	n.SetMix(1.0)
+
+	return n, nil
+}
+
+func (n *fxLUTNode) SetLUT(lut *FXLUT) error {
+	if err := lut.validate(); err != nil {
+		return err
+	}
+
+	// 1. Fit the table in a texture
+	maxSize := fxcore.FXMaxTextureSize()
+	table := lut
+	if lut.Dimensions == 1 && lut.Size > maxSize {
+		table = resampleLUT1D(lut, maxSize)
+	}
+	width, height := table.Size, 1
+	if table.Dimensions == 3 {
+		width, height = table.Size*table.Size, table.Size
+	}
+	if width > maxSize {
+		return fmt.Errorf("table of size %d needs a texture %d wide, the maximum is %d", table.Size, width, maxSize)
+	}
+
+	// 2. Upload the entries
+	texture, err := fxcore.NewFXTextureWithFormat(width, height, lutTextureFormat())
+	if err != nil {
+		return err
+	}
+	if err := texture.UploadFloat(lutPixels(table)); err != nil {
+		texture.Release()
+		return fmt.Errorf("failed to upload table: %w", err)
+	}
+
+	// 3. Replace the previous table
+	if n.texture != nil {
+		n.texture.Release()
+	}
+	n.texture = texture
+	n.lut = lut
+	n.SetInput("u_lut", fximage.NewFXImageInput(texture))
+	n.SetUniform("u_dimensions", table.Dimensions)
+	n.SetUniform("u_size", float32(table.Size))
+	n.SetUniform("u_domainMin", []float32{table.DomainMin[0], table.DomainMin[1], table.DomainMin[2]})
+	n.SetUniform("u_domainMax", []float32{table.DomainMax[0], table.DomainMax[1], table.DomainMax[2]})
+	return nil
+}
+
+func (n *fxLUTNode) LoadLUT(path string) error {
+	lut, err := FXLoadLUT(path)
+	if err != nil {
+		return err
+	}
+	return n.SetLUT(lut)
+}
+
+func (n *fxLUTNode) GetLUT() *FXLUT {
+	return n.lut
+}
+
+func (n *fxLUTNode) SetInterpolation(interpolation FXLUTInterpolation) {
+	n.SetUniform("u_interpolation", int(interpolation))
+}
+
+func (n *fxLUTNode) SetMix(amount float32) {
+	n.SetUniform("u_mix", amount)
+}
+
+func (n *fxLUTNode) Release() {
+	if n.texture != nil {
+		n.texture.Release()
+	}
+	n.FXNode.Release()
+}
+
+// lutTextureFormat returns the most precise texture format available for tables.
+// Float formats keep entries outside 0 to 1.
+func lutTextureFormat() fxcore.FXTextureFormat {
+	for _, format := range []fxcore.FXTextureFormat{fxcore.FXTextureRGBA32F, fxcore.FXTextureRGBA16F} {
+		if fxcore.FXTextureFormatSupported(format) {
+			return format
+		}
+	}
+	return fxcore.FXTextureRGBA8
+}
+
+// lutPixels lays out the entries of a table as RGBA texels, in the layout read by FXLUTFS.
+func lutPixels(lut *FXLUT) []float32 {
+	pixels := make([]float32, lut.entries()*4)
+	for i := 0; i < lut.entries(); i++ {
+		texel := i
+		if lut.Dimensions == 3 {
+			// Entry (r, g, b) goes to texel (r + b * size, g).
+			r, g, b := i%lut.Size, i/lut.Size%lut.Size, i/(lut.Size*lut.Size)
+			texel = g*lut.Size*lut.Size + b*lut.Size + r
+		}
+		copy(pixels[texel*4:], lut.Data[i*3:i*3+3])
+		pixels[texel*4+3] = 1
+	}
+	return pixels
+}
+
+// resampleLUT1D linearly resamples a 1D table to size entries.
+func resampleLUT1D(lut *FXLUT, size int) *FXLUT {
+	resampled := &FXLUT{
+		Title:      lut.Title,
+		Dimensions: 1,
+		Size:       size,
+		DomainMin:  lut.DomainMin,
+		DomainMax:  lut.DomainMax,
+		Data:       make([]float32, size*3),
+	}
+	for i := 0; i < size; i++ {
+		x := float32(i) * float32(lut.Size-1) / float32(size-1)
+		j := min(int(x), lut.Size-2)
+		f := x - float32(j)
+		for c := 0; c < 3; c++ {
+			a, b := lut.Data[j*3+c], lut.Data[(j+1)*3+c]
+			resampled.Data[i*3+c] = a + (b-a)*f
+		}
+	}
+	return resampled
+}
+
+// FXBakeLUT captures a color transform as a 3D table with size entries per axis, for example to
+// save a graph of color nodes as a .cube file with WriteCube.
+// build receives an input holding every entry of an identity table and the size its nodes must be
+// created at, connects it to the transform and returns the node with the result. That node is
+// processed once and its output read back. The transform must treat each pixel independently:
+// blurs, transforms and other spatial effects mix up the entries. Use float formats for the
+// nodes to keep values outside 0 to 1 and full precision. The caller keeps ownership of the
+// nodes created by build.
+func FXBakeLUT(ctx fxcontext.FXContext, size int, build func(input fxnode.FXInput, width, height int) (fxnode.FXNode, error)) (*FXLUT, error) {
+	if size < 2 || size > FXMaxLUTSize {
+		return nil, fmt.Errorf("table size %d is out of range 2 to %d", size, FXMaxLUTSize)
+	}
+	width, height := size*size, size
+	if maxSize := fxcore.FXMaxTextureSize(); width > maxSize {
+		return nil, fmt.Errorf("table of size %d needs a texture %d wide, the maximum is %d", size, width, maxSize)
+	}
+
+	// 1. Upload the identity table as an image
+	texture, err := fxcore.NewFXTextureWithFormat(width, height, lutTextureFormat())
+	if err != nil {
+		return nil, err
+	}
+	defer texture.Release()
+	if err := texture.UploadFloat(lutPixels(NewFXIdentityLUT(3, size))); err != nil {
+		return nil, fmt.Errorf("failed to upload identity table: %w", err)
+	}
+
+	// 2. Run it through the transform
+	node, err := build(fximage.NewFXImageInput(texture), width, height)
+	if err != nil {
+		return nil, err
+	}
+	if err := node.Process(ctx); err != nil {
+		return nil, fmt.Errorf("failed to process transform: %w", err)
+	}
+	if w, h := node.GetTexture().GetSize(); w != width || h != height {
+		return nil, fmt.Errorf("transform output is %dx%d, expected %dx%d", w, h, width, height)
+	}
+	pixels, err := node.GetTexture().DownloadFloat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transform output: %w", err)
+	}
+
+	// 3. Collect the entries from the texels
+	// Rendering keeps texture coordinates, and downloads flip rows, so row g is read back as size-1-g.
+	lut := NewFXIdentityLUT(3, size)
+	for i := 0; i < lut.entries(); i++ {
+		r, g, b := i%size, i/size%size, i/(size*size)
+		texel := (size-1-g)*size*size + b*size + r
+		copy(lut.Data[i*3:i*3+3], pixels[texel*4:texel*4+3])
+	}
+	if err := lut.validate(); err != nil {
+		return nil, fmt.Errorf("transform produced an invalid table: %w", err)
+	}
+	return lut, nil
+}
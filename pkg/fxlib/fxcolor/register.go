@@ -0,0 +1,390 @@
+package fxcolor
+
+import (
+	"reflect"
+
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxnode"
+)
+
+// init registers the color nodes so they can be created by type name.
+func init() {
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "colorAdjustment",
+		Category:    "color",
+		Description: "Adjusts brightness, contrast, hue, saturation, gamma and exposure.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxColorAdjustmentNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXColorAdjustmentNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				Name:        "brightness",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Brightness offset.",
+				Default:     float32(0),
+				Min:         -1,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_brightness"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXColorAdjustmentNode).SetBrightness(v.(float32)) },
+			},
+			{
+				Name:        "contrast",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Contrast factor.",
+				Default:     float32(1),
+				Min:         0,
+				Max:         2,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_contrast"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXColorAdjustmentNode).SetContrast(v.(float32)) },
+			},
+			{
+				Name:        "hue",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Hue rotation in turns.",
+				Default:     float32(0),
+				Min:         -0.5,
+				Max:         0.5,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_hue"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXColorAdjustmentNode).SetHue(v.(float32)) },
+			},
+			{
+				Name:        "saturation",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Saturation factor; 0 is grayscale.",
+				Default:     float32(1),
+				Min:         0,
+				Max:         2,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_saturation"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXColorAdjustmentNode).SetSaturation(v.(float32)) },
+			},
+			{
+				Name:        "gamma",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Gamma correction.",
+				Default:     float32(1),
+				Min:         0.1,
+				Max:         3,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_gamma"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXColorAdjustmentNode).SetGamma(v.(float32)) },
+			},
+			{
+				Name:        "exposure",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Exposure factor.",
+				Default:     float32(1),
+				Min:         0,
+				Max:         4,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_exposure"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXColorAdjustmentNode).SetExposure(v.(float32)) },
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "colorBalance",
+		Category:    "color",
+		Description: "Shifts the color balance of shadows, midtones and highlights.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxColorBalanceNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXColorBalanceNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				Name:        "shadows",
+				Kind:        fxnode.FXParamVec3,
+				Description: "Cyan/red, magenta/green and yellow/blue shift of the shadows.",
+				Default:     []float32{0, 0, 0},
+				Min:         -1,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamVec3, "u_shadows"),
+				Set: func(node fxnode.FXNode, v interface{}) {
+					c := v.([]float32)
+					node.(FXColorBalanceNode).SetShadows(c[0], c[1], c[2])
+				},
+			},
+			{
+				Name:        "midtones",
+				Kind:        fxnode.FXParamVec3,
+				Description: "Cyan/red, magenta/green and yellow/blue shift of the midtones.",
+				Default:     []float32{0, 0, 0},
+				Min:         -1,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamVec3, "u_midtones"),
+				Set: func(node fxnode.FXNode, v interface{}) {
+					c := v.([]float32)
+					node.(FXColorBalanceNode).SetMidtones(c[0], c[1], c[2])
+				},
+			},
+			{
+				Name:        "highlights",
+				Kind:        fxnode.FXParamVec3,
+				Description: "Cyan/red, magenta/green and yellow/blue shift of the highlights.",
+				Default:     []float32{0, 0, 0},
+				Min:         -1,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamVec3, "u_highlights"),
+				Set: func(node fxnode.FXNode, v interface{}) {
+					c := v.([]float32)
+					node.(FXColorBalanceNode).SetHighlights(c[0], c[1], c[2])
+				},
+			},
+			{
+				Name:        "preserveLuminosity",
+				Kind:        fxnode.FXParamBool,
+				Description: "Keep the original luminosity.",
+				Default:     true,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamBool, "u_preserveLuminosity"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXColorBalanceNode).SetPreserveLuminosity(v.(bool)) },
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "colorFilter",
+		Category:    "color",
+		Description: "Applies a simple color filter such as sepia or posterize.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxColorFilterNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXColorFilterNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				Name:        "mode",
+				Kind:        fxnode.FXParamInt,
+				Description: "Filter mode.",
+				Default:     0,
+				Options:     fxFilterModeNames,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamInt, "u_mode"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXColorFilterNode).SetMode(FXFilterMode(v.(int))) },
+			},
+			{
+				Name:        "param",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Threshold (0 to 1) or posterize levels, depending on the mode.",
+				Default:     float32(0.5),
+				Min:         0,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_param"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXColorFilterNode).SetParam(v.(float32)) },
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "levels",
+		Category:    "color",
+		Description: "Remaps input black and white points to output levels.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxLevelsNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXLevelsNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				// Black and white points are stored in separate uniforms but set together.
+				Name:        "inputLevels",
+				Kind:        fxnode.FXParamVec2,
+				Description: "Input black and white points.",
+				Default:     []float32{0, 1},
+				Min:         0,
+				Max:         1,
+				Get: func(node fxnode.FXNode) interface{} {
+					return []float32{fxnode.FXUniformFloat(node, "u_inBlack"), fxnode.FXUniformFloat(node, "u_inWhite")}
+				},
+				Set: func(node fxnode.FXNode, v interface{}) {
+					c := v.([]float32)
+					node.(FXLevelsNode).SetInputLevels(c[0], c[1])
+				},
+			},
+			{
+				Name:        "outputLevels",
+				Kind:        fxnode.FXParamVec2,
+				Description: "Output black and white points.",
+				Default:     []float32{0, 1},
+				Min:         0,
+				Max:         1,
+				Get: func(node fxnode.FXNode) interface{} {
+					return []float32{fxnode.FXUniformFloat(node, "u_outBlack"), fxnode.FXUniformFloat(node, "u_outWhite")}
+				},
+				Set: func(node fxnode.FXNode, v interface{}) {
+					c := v.([]float32)
+					node.(FXLevelsNode).SetOutputLevels(c[0], c[1])
+				},
+			},
+			{
+				Name:        "gamma",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Gamma correction of the midtones.",
+				Default:     float32(1),
+				Min:         0.1,
+				Max:         3,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_gamma"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXLevelsNode).SetGamma(v.(float32)) },
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "curves",
+		Category:    "color",
+		Description: "Remaps colors with master, red, green, blue and hue curves, set with SetCurve.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxCurvesNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXCurvesNode(ctx, width, height)
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "chromaKey",
+		Category:    "color",
+		Description: "Keys out a screen color into alpha, with choke, feather and spill suppression.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxChromaKeyNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXChromaKeyNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				// The key parameters are uniforms of the key pass, so read them from there.
+				Name:        "keyColor",
+				Kind:        fxnode.FXParamVec3,
+				Description: "Color of the screen.",
+				Default:     []float32{0, 1, 0},
+				Min:         0,
+				Max:         1,
+				Get: func(node fxnode.FXNode) interface{} {
+					return fxnode.FXUniformVec(node.(*fxChromaKeyNode).key, "u_keyColor", 3)
+				},
+				Set: func(node fxnode.FXNode, v interface{}) {
+					c := v.([]float32)
+					node.(FXChromaKeyNode).SetKeyColor(c[0], c[1], c[2])
+				},
+			},
+			{
+				Name:        "colorSpace",
+				Kind:        fxnode.FXParamInt,
+				Description: "Color space in which colors are compared with the key color.",
+				Default:     int(FXChromaKeyYCbCr),
+				Options:     fxChromaKeySpaceNames,
+				Get: func(node fxnode.FXNode) interface{} {
+					return fxnode.FXUniformInt(node.(*fxChromaKeyNode).key, "u_space")
+				},
+				Set: func(node fxnode.FXNode, v interface{}) {
+					node.(FXChromaKeyNode).SetColorSpace(FXChromaKeySpace(v.(int)))
+				},
+			},
+			{
+				Name:        "tolerance",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Distance from the key color within which colors are removed.",
+				Default:     float32(0.2),
+				Min:         0,
+				Max:         1,
+				Get: func(node fxnode.FXNode) interface{} {
+					return fxnode.FXUniformFloat(node.(*fxChromaKeyNode).key, "u_tolerance")
+				},
+				Set: func(node fxnode.FXNode, v interface{}) { node.(FXChromaKeyNode).SetTolerance(v.(float32)) },
+			},
+			{
+				Name:        "softness",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Distance beyond the tolerance over which colors fade in.",
+				Default:     float32(0.1),
+				Min:         0,
+				Max:         1,
+				Get: func(node fxnode.FXNode) interface{} {
+					return fxnode.FXUniformFloat(node.(*fxChromaKeyNode).key, "u_softness")
+				},
+				Set: func(node fxnode.FXNode, v interface{}) { node.(FXChromaKeyNode).SetSoftness(v.(float32)) },
+			},
+			{
+				Name:        "choke",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Pixels by which the matte shrinks, or grows if negative.",
+				Default:     float32(0),
+				Min:         -FXMaxMatteRadius,
+				Max:         FXMaxMatteRadius,
+				Get:         func(node fxnode.FXNode) interface{} { return node.(*fxChromaKeyNode).choke },
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXChromaKeyNode).SetChoke(v.(float32)) },
+			},
+			{
+				Name:        "feather",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Pixels over which the edge of the matte is blurred.",
+				Default:     float32(0),
+				Min:         0,
+				Max:         FXMaxMatteRadius,
+				Get:         func(node fxnode.FXNode) interface{} { return node.(*fxChromaKeyNode).feather },
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXChromaKeyNode).SetFeather(v.(float32)) },
+			},
+			{
+				Name:        "spill",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Amount of key color spill removed from the foreground.",
+				Default:     float32(1),
+				Min:         0,
+				Max:         1,
+				Get: func(node fxnode.FXNode) interface{} {
+					return fxnode.FXUniformFloat(node.(*fxChromaKeyNode).key, "u_spill")
+				},
+				Set: func(node fxnode.FXNode, v interface{}) { node.(FXChromaKeyNode).SetSpillSuppression(v.(float32)) },
+			},
+			{
+				Name:        "matteOnly",
+				Kind:        fxnode.FXParamBool,
+				Description: "Output the matte instead of the foreground.",
+				Default:     false,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamBool, "u_matteOnly"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXChromaKeyNode).SetMatteOnly(v.(bool)) },
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "lut",
+		Category:    "color",
+		Description: "Applies a 1D or 3D color lookup table, set with SetLUT or LoadLUT.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxLUTNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXLUTNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				Name:        "interpolation",
+				Kind:        fxnode.FXParamInt,
+				Description: "Interpolation between the entries of 3D tables.",
+				Default:     int(FXLUTTetrahedral),
+				Options:     fxLUTInterpolationNames,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamInt, "u_interpolation"),
+				Set: func(node fxnode.FXNode, v interface{}) {
+					node.(FXLUTNode).SetInterpolation(FXLUTInterpolation(v.(int)))
+				},
+			},
+			{
+				Name:        "mix",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Amount of the graded color mixed over the input.",
+				Default:     float32(1),
+				Min:         0,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_mix"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXLUTNode).SetMix(v.(float32)) },
+			},
+		},
+	})
+}
+
+// fxFilterModeNames names the filter modes in FXFilterMode order.
+var fxFilterModeNames = []string{"none", "invert", "sepia", "grayscale", "threshold", "posterize"}
+
+// fxLUTInterpolationNames names the interpolation modes in FXLUTInterpolation order.
+var fxLUTInterpolationNames = []string{"trilinear", "tetrahedral"}
+
+// fxChromaKeySpaceNames names the key color spaces in FXChromaKeySpace order.
+var fxChromaKeySpaceNames = []string{"ycbcr", "hsv"}
@@ -0,0 +1,108 @@
+package fxdistortion
+
+import (
+	"reflect"
+
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxnode"
+)
+
+// init registers the distortion nodes so they can be created by type name.
+func init() {
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "ripple",
+		Category:    "distortion",
+		Description: "Distorts the image with animated circular waves.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxRippleNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXRippleNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				Name:        "amplitude",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Strength of the ripple.",
+				Default:     float32(0.01),
+				Min:         0,
+				Max:         0.1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_amplitude"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXRippleNode).SetAmplitude(v.(float32)) },
+			},
+			{
+				Name:        "frequency",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Frequency of the waves.",
+				Default:     float32(20),
+				Min:         0,
+				Max:         100,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_frequency"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXRippleNode).SetFrequency(v.(float32)) },
+			},
+			{
+				Name:        "speed",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Animation speed.",
+				Default:     float32(1),
+				Min:         0,
+				Max:         10,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_speed"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXRippleNode).SetSpeed(v.(float32)) },
+			},
+			{
+				Name:        "time",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Current animation time in seconds.",
+				Default:     float32(0),
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_time"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXRippleNode).SetTime(v.(float32)) },
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "twirl",
+		Category:    "distortion",
+		Description: "Rotates the image around a center, more strongly near the center.",
+		Inputs:      []string{"u_texture"},
+		GoType:      reflect.TypeOf(&fxTwirlNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXTwirlNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			{
+				Name:        "angle",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Rotation at the center in radians.",
+				Default:     float32(3.14),
+				Min:         -6.283,
+				Max:         6.283,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_angle"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXTwirlNode).SetAngle(v.(float32)) },
+			},
+			{
+				Name:        "radius",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Radius of the effect.",
+				Default:     float32(0.5),
+				Min:         0,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_radius"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXTwirlNode).SetRadius(v.(float32)) },
+			},
+			{
+				Name:        "center",
+				Kind:        fxnode.FXParamVec2,
+				Description: "Center of the twirl in normalized coordinates.",
+				Default:     []float32{0.5, 0.5},
+				Min:         0,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamVec2, "u_center"),
+				Set: func(node fxnode.FXNode, v interface{}) {
+					c := v.([]float32)
+					node.(FXTwirlNode).SetCenter(c[0], c[1])
+				},
+			},
+		},
+	})
+}
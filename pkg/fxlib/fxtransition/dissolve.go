@@ -0,0 +1,131 @@
+package fxtransition
+
+import (
+	"image"
+	"math/rand"
+
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxcore"
+	"kdfx/pkg/fximage"
+)
+
+// fxNoiseSize is the width and height of the noise texture; the pattern repeats after this many cells.
+const fxNoiseSize = 256
+
+// FXDissolveFS is the fragment shader for the noise dissolve transition.
+// Each pixel switches to the incoming texture when the progress passes its noise value.
+// The noise is read from a random texture, because hashing in the shader needs more precision
+// than mediump guarantees.
+const FXDissolveFS = `
+precision mediump float;
+varying vec2 v_texCoord;
+uniform sampler2D u_noise;
+uniform sampler2D u_texture1; // Outgoing
+uniform sampler2D u_texture2; // Incoming
+uniform float u_progress;
+uniform float u_aspect;
+uniform float u_cells;
+uniform float u_feather;
+uniform int u_smooth;
+
+const float NOISE_SIZE = 256.0;
+
+void main() {
+	// Square cells, u_cells of them across the height.
+	vec2 p = v_texCoord * vec2(u_aspect, 1.0) * u_cells;
+	float t;
+	if (u_smooth == 1) {
+		// Linear filtering interpolates between the cells.
+		t = texture2D(u_noise, fract(p / NOISE_SIZE)).r;
+	} else {
+		// Sample the center of a texel, so every cell gets one random value.
+		t = texture2D(u_noise, (mod(floor(p), NOISE_SIZE) + 0.5) / NOISE_SIZE).r;
+	}
+
+	float feather = max(u_feather, 0.0001);
+	float edge = u_progress * (1.0 + feather) - feather;
+	float amount = 1.0 - smoothstep(edge, edge + feather, t);
+
+	vec4 from = texture2D(u_texture1, v_texCoord);
+	vec4 to = texture2D(u_texture2, v_texCoord);
+	gl_FragColor = mix(from, to, amount);
+}
+`
+
+// FXDissolveNode switches from the outgoing texture to the incoming one pixel by pixel,
+// in an order given by noise.
+type FXDissolveNode interface {
+	FXTransitionNode
+	// SetScale sets the number of noise cells across the height of the frame.
+	// Use the height in pixels to dissolve single pixels.
+	SetScale(scale float32)
+	// SetSeed sets the seed of the noise; different seeds give different patterns.
+	SetSeed(seed int)
+	// SetFeather sets how gradually each cell switches, as a fraction of the transition (0.0 to 1.0).
+	SetFeather(feather float32)
+	// SetSmooth interpolates the noise between cells, for organic blobs instead of square blocks.
+	SetSmooth(smooth bool)
+}
+
+// fxDissolveNode implements FXDissolveNode.
+type fxDissolveNode struct {
+	fxTransitionNode
+	// noise holds a random value for each cell.
+	noise fxcore.FXTexture
+	// seed is the seed the noise was generated with.
+	seed int
+}
+
+// NewFXDissolveNode creates a new noise dissolve fxnode.
+func NewFXDissolveNode(ctx fxcontext.FXContext, width, height int) (FXDissolveNode, error) {
+	base, err := newFXTransitionNode(ctx, width, height, FXDissolveFS)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &fxDissolveNode{
+		fxTransitionNode: base,
+		noise:            fxcore.NewFXTexture(fxNoiseSize, fxNoiseSize),
+	}
+	n.SetInput("u_noise", fximage.NewFXImageInput(n.noise))
+	n.SetScale(64)
+	n.SetSeed(0)
+	n.SetFeather(0)
+	n.SetSmooth(false)
+
+	return n, nil
+}
+
+func (n *fxDissolveNode) SetScale(scale float32) {
+	n.SetUniform("u_cells", scale)
+}
+
+func (n *fxDissolveNode) SetSeed(seed int) {
+	// Generate the same noise for the same seed.
+	random := rand.New(rand.NewSource(int64(seed)))
+	img := image.NewRGBA(image.Rect(0, 0, fxNoiseSize, fxNoiseSize))
+	for i := 0; i < len(img.Pix); i += 4 {
+		v := uint8(random.Intn(256))
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = v, v, v, 255
+	}
+	n.noise.Upload(img)
+	n.seed = seed
+	n.MarkDirty()
+}
+
+func (n *fxDissolveNode) SetFeather(feather float32) {
+	n.SetUniform("u_feather", feather)
+}
+
+func (n *fxDissolveNode) SetSmooth(smooth bool) {
+	val := 0
+	if smooth {
+		val = 1
+	}
+	n.SetUniform("u_smooth", val)
+}
+
+func (n *fxDissolveNode) Release() {
+	n.noise.Release()
+	n.fxTransitionNode.Release()
+}
@@ -0,0 +1,88 @@
+package fxtransition
+
+import (
+	"kdfx/pkg/fxcontext"
+)
+
+// FXCrossfadeFS is the fragment shader for the crossfade transition.
+const FXCrossfadeFS = `
+precision mediump float;
+varying vec2 v_texCoord;
+uniform sampler2D u_texture1; // Outgoing
+uniform sampler2D u_texture2; // Incoming
+uniform float u_progress;
+
+void main() {
+	vec4 from = texture2D(u_texture1, v_texCoord);
+	vec4 to = texture2D(u_texture2, v_texCoord);
+	gl_FragColor = mix(from, to, u_progress);
+}
+`
+
+// FXCrossfadeNode fades from the outgoing texture to the incoming one.
+type FXCrossfadeNode interface {
+	FXTransitionNode
+}
+
+// fxCrossfadeNode implements FXCrossfadeNode.
+type fxCrossfadeNode struct {
+	fxTransitionNode
+}
+
+// NewFXCrossfadeNode creates a new crossfade fxnode.
+func NewFXCrossfadeNode(ctx fxcontext.FXContext, width, height int) (FXCrossfadeNode, error) {
+	base, err := newFXTransitionNode(ctx, width, height, FXCrossfadeFS)
+	if err != nil {
+		return nil, err
+	}
+	return &fxCrossfadeNode{fxTransitionNode: base}, nil
+}
+
+// FXDipFS is the fragment shader for the dip to color transition.
+const FXDipFS = `
+precision mediump float;
+varying vec2 v_texCoord;
+uniform sampler2D u_texture1; // Outgoing
+uniform sampler2D u_texture2; // Incoming
+uniform float u_progress;
+uniform vec3 u_color;
+
+void main() {
+	vec4 color = vec4(u_color, 1.0);
+	if (u_progress < 0.5) {
+		gl_FragColor = mix(texture2D(u_texture1, v_texCoord), color, u_progress * 2.0);
+	} else {
+		gl_FragColor = mix(color, texture2D(u_texture2, v_texCoord), u_progress * 2.0 - 1.0);
+	}
+}
+`
+
+// FXDipNode fades the outgoing texture to a color in the first half of the transition,
+// and from the color to the incoming texture in the second half.
+type FXDipNode interface {
+	FXTransitionNode
+	// SetColor sets the color to dip to (0.0 to 1.0). The default is black.
+	SetColor(r, g, b float32)
+}
+
+// fxDipNode implements FXDipNode.
+type fxDipNode struct {
+	fxTransitionNode
+}
+
+// NewFXDipNode creates a new dip to color fxnode.
+func NewFXDipNode(ctx fxcontext.FXContext, width, height int) (FXDipNode, error) {
+	base, err := newFXTransitionNode(ctx, width, height, FXDipFS)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &fxDipNode{fxTransitionNode: base}
+	n.SetColor(0, 0, 0)
+
+	return n, nil
+}
+
+func (n *fxDipNode) SetColor(r, g, b float32) {
+	n.SetUniform("u_color", []float32{r, g, b})
+}
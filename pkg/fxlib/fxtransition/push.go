@@ -0,0 +1,117 @@
+package fxtransition
+
+import (
+	"kdfx/pkg/fxcontext"
+)
+
+// FXPushMode represents which textures move during a push transition.
+type FXPushMode int
+
+const (
+	// FXPushBoth moves the outgoing texture out while the incoming texture pushes in behind it.
+	FXPushBoth FXPushMode = iota
+	// FXPushSlide slides the incoming texture in over the outgoing texture, which stays in place.
+	FXPushSlide
+	// FXPushUncover slides the outgoing texture out, uncovering the incoming texture in place.
+	FXPushUncover
+)
+
+// FXPushDirection represents the direction in which the textures move.
+type FXPushDirection int
+
+const (
+	// FXPushLeft moves the textures to the left; the incoming texture enters from the right.
+	FXPushLeft FXPushDirection = iota
+	// FXPushRight moves the textures to the right; the incoming texture enters from the left.
+	FXPushRight
+	// FXPushUp moves the textures up; the incoming texture enters from the bottom.
+	FXPushUp
+	// FXPushDown moves the textures down; the incoming texture enters from the top.
+	FXPushDown
+)
+
+// fxPushDirections maps the directions to the motion in texture coordinates, where v grows downwards.
+var fxPushDirections = [][]float32{
+	FXPushLeft:  {-1, 0},
+	FXPushRight: {1, 0},
+	FXPushUp:    {0, -1},
+	FXPushDown:  {0, 1},
+}
+
+// FXPushFS is the fragment shader for push and slide transitions.
+const FXPushFS = `
+precision mediump float;
+varying vec2 v_texCoord;
+uniform sampler2D u_texture1; // Outgoing
+uniform sampler2D u_texture2; // Incoming
+uniform float u_progress;
+uniform int u_mode;
+uniform vec2 u_direction;
+
+// inside returns true if the texture coordinate is in the frame.
+bool inside(vec2 uv) {
+	return uv.x >= 0.0 && uv.x <= 1.0 && uv.y >= 0.0 && uv.y <= 1.0;
+}
+
+void main() {
+	// The outgoing texture moves out by the progress, the incoming one starts a frame behind it.
+	vec2 from = v_texCoord;
+	vec2 to = v_texCoord;
+	if (u_mode != 1) { // Push or Uncover
+		from -= u_direction * u_progress;
+	}
+	if (u_mode != 2) { // Push or Slide
+		to += u_direction * (1.0 - u_progress);
+	}
+
+	if (u_mode == 2) { // Uncover: the outgoing texture is on top
+		gl_FragColor = inside(from) ? texture2D(u_texture1, from) : texture2D(u_texture2, to);
+	} else {
+		gl_FragColor = inside(to) ? texture2D(u_texture2, to) : texture2D(u_texture1, from);
+	}
+}
+`
+
+// FXPushNode moves the incoming texture into the frame, the outgoing texture out of it, or both.
+type FXPushNode interface {
+	FXTransitionNode
+	// SetMode sets which textures move.
+	// See FXPushMode constants for available modes.
+	SetMode(mode FXPushMode)
+	// SetDirection sets the direction in which the textures move.
+	// See FXPushDirection constants for available directions.
+	SetDirection(direction FXPushDirection)
+}
+
+// fxPushNode implements FXPushNode.
+type fxPushNode struct {
+	fxTransitionNode
+	// direction is the direction in which the textures move.
+	direction FXPushDirection
+}
+
+// NewFXPushNode creates a new push fxnode.
+func NewFXPushNode(ctx fxcontext.FXContext, width, height int) (FXPushNode, error) {
+	base, err := newFXTransitionNode(ctx, width, height, FXPushFS)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &fxPushNode{fxTransitionNode: base}
+	n.SetMode(FXPushBoth)
+	n.SetDirection(FXPushLeft)
+
+	return n, nil
+}
+
+func (n *fxPushNode) SetMode(mode FXPushMode) {
+	n.SetUniform("u_mode", int(mode))
+}
+
+func (n *fxPushNode) SetDirection(direction FXPushDirection) {
+	if direction < 0 || int(direction) >= len(fxPushDirections) {
+		return
+	}
+	n.direction = direction
+	n.SetUniform("u_direction", append([]float32(nil), fxPushDirections[direction]...))
+}
@@ -0,0 +1,259 @@
+package fxtransition
+
+import (
+	"reflect"
+
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxnode"
+)
+
+// fxTransitionInputs are the input slots of every transition: outgoing, then incoming.
+var fxTransitionInputs = []string{"u_texture1", "u_texture2"}
+
+// fxProgressParam is the progress parameter shared by all transitions.
+var fxProgressParam = fxnode.FXParam{
+	Name:        "progress",
+	Kind:        fxnode.FXParamFloat,
+	Description: "How far the transition has advanced, from the outgoing to the incoming texture.",
+	Default:     float32(0),
+	Min:         0,
+	Max:         1,
+	Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_progress"),
+	Set:         func(node fxnode.FXNode, v interface{}) { node.(FXTransitionNode).SetProgress(v.(float32)) },
+}
+
+// init registers the transition nodes so they can be created by type name.
+func init() {
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "crossfade",
+		Category:    "transition",
+		Description: "Fades from one texture to another.",
+		Inputs:      fxTransitionInputs,
+		GoType:      reflect.TypeOf(&fxCrossfadeNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXCrossfadeNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{fxProgressParam},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "dip",
+		Category:    "transition",
+		Description: "Fades to a color and then to the incoming texture.",
+		Inputs:      fxTransitionInputs,
+		GoType:      reflect.TypeOf(&fxDipNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXDipNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			fxProgressParam,
+			{
+				Name:        "color",
+				Kind:        fxnode.FXParamVec3,
+				Description: "Color to dip to.",
+				Default:     []float32{0, 0, 0},
+				Min:         0,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamVec3, "u_color"),
+				Set: func(node fxnode.FXNode, v interface{}) {
+					c := v.([]float32)
+					node.(FXDipNode).SetColor(c[0], c[1], c[2])
+				},
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "wipe",
+		Category:    "transition",
+		Description: "Reveals the incoming texture behind a moving linear, radial or clock edge.",
+		Inputs:      fxTransitionInputs,
+		GoType:      reflect.TypeOf(&fxWipeNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXWipeNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			fxProgressParam,
+			{
+				Name:        "shape",
+				Kind:        fxnode.FXParamInt,
+				Description: "Shape of the edge.",
+				Default:     0,
+				Options:     fxWipeShapeNames,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamInt, "u_shape"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXWipeNode).SetShape(FXWipeShape(v.(int))) },
+			},
+			{
+				Name:        "angle",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Direction of a linear edge in radians; 0 moves from left to right.",
+				Default:     float32(0),
+				Min:         -6.283,
+				Max:         6.283,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_angle"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXWipeNode).SetAngle(v.(float32)) },
+			},
+			{
+				Name:        "center",
+				Kind:        fxnode.FXParamVec2,
+				Description: "Center of radial and clock wipes in normalized coordinates.",
+				Default:     []float32{0.5, 0.5},
+				Min:         0,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamVec2, "u_center"),
+				Set: func(node fxnode.FXNode, v interface{}) {
+					c := v.([]float32)
+					node.(FXWipeNode).SetCenter(c[0], c[1])
+				},
+			},
+			{
+				Name:        "feather",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Width of the soft edge.",
+				Default:     float32(0.05),
+				Min:         0,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_feather"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXWipeNode).SetFeather(v.(float32)) },
+			},
+			{
+				Name:        "reverse",
+				Kind:        fxnode.FXParamBool,
+				Description: "Run the wipe backwards.",
+				Default:     false,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamBool, "u_reverse"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXWipeNode).SetReverse(v.(bool)) },
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "dissolve",
+		Category:    "transition",
+		Description: "Switches pixels to the incoming texture in a random order.",
+		Inputs:      fxTransitionInputs,
+		GoType:      reflect.TypeOf(&fxDissolveNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXDissolveNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			fxProgressParam,
+			{
+				Name:        "scale",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Number of noise cells across the height.",
+				Default:     float32(64),
+				Min:         1,
+				Max:         1080,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_cells"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXDissolveNode).SetScale(v.(float32)) },
+			},
+			{
+				// The seed generates the noise texture, so read it from the node.
+				Name:        "seed",
+				Kind:        fxnode.FXParamInt,
+				Description: "Seed of the noise pattern.",
+				Default:     0,
+				Get:         func(node fxnode.FXNode) interface{} { return node.(*fxDissolveNode).seed },
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXDissolveNode).SetSeed(v.(int)) },
+			},
+			{
+				Name:        "feather",
+				Kind:        fxnode.FXParamFloat,
+				Description: "How gradually each cell switches.",
+				Default:     float32(0),
+				Min:         0,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_feather"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXDissolveNode).SetFeather(v.(float32)) },
+			},
+			{
+				Name:        "smooth",
+				Kind:        fxnode.FXParamBool,
+				Description: "Interpolate the noise for organic blobs instead of square cells.",
+				Default:     false,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamBool, "u_smooth"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXDissolveNode).SetSmooth(v.(bool)) },
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "push",
+		Category:    "transition",
+		Description: "Pushes or slides the incoming texture into the frame.",
+		Inputs:      fxTransitionInputs,
+		GoType:      reflect.TypeOf(&fxPushNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXPushNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			fxProgressParam,
+			{
+				Name:        "mode",
+				Kind:        fxnode.FXParamInt,
+				Description: "Which textures move.",
+				Default:     0,
+				Options:     fxPushModeNames,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamInt, "u_mode"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXPushNode).SetMode(FXPushMode(v.(int))) },
+			},
+			{
+				// The direction is passed to the shader as a vector, so read it from the node.
+				Name:        "direction",
+				Kind:        fxnode.FXParamInt,
+				Description: "Direction in which the textures move.",
+				Default:     0,
+				Options:     fxPushDirectionNames,
+				Get:         func(node fxnode.FXNode) interface{} { return int(node.(*fxPushNode).direction) },
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXPushNode).SetDirection(FXPushDirection(v.(int))) },
+			},
+		},
+	})
+
+	fxnode.FXRegisterNodeType(fxnode.FXNodeType{
+		Name:        "zoom",
+		Category:    "transition",
+		Description: "Zooms into the outgoing texture and out of the incoming one.",
+		Inputs:      fxTransitionInputs,
+		GoType:      reflect.TypeOf(&fxZoomNode{}),
+		New: func(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+			return NewFXZoomNode(ctx, width, height)
+		},
+		Params: []fxnode.FXParam{
+			fxProgressParam,
+			{
+				Name:        "strength",
+				Kind:        fxnode.FXParamFloat,
+				Description: "Zoom factor of each texture.",
+				Default:     float32(4),
+				Min:         1,
+				Max:         20,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamFloat, "u_strength"),
+				Set:         func(node fxnode.FXNode, v interface{}) { node.(FXZoomNode).SetStrength(v.(float32)) },
+			},
+			{
+				Name:        "center",
+				Kind:        fxnode.FXParamVec2,
+				Description: "Point zoomed into, in normalized coordinates.",
+				Default:     []float32{0.5, 0.5},
+				Min:         0,
+				Max:         1,
+				Get:         fxnode.FXUniformGetter(fxnode.FXParamVec2, "u_center"),
+				Set: func(node fxnode.FXNode, v interface{}) {
+					c := v.([]float32)
+					node.(FXZoomNode).SetCenter(c[0], c[1])
+				},
+			},
+		},
+	})
+}
+
+// fxWipeShapeNames names the wipe shapes in FXWipeShape order.
+var fxWipeShapeNames = []string{"linear", "radial", "clock"}
+
+// fxPushModeNames names the push modes in FXPushMode order.
+var fxPushModeNames = []string{"push", "slide", "uncover"}
+
+// fxPushDirectionNames names the push directions in FXPushDirection order.
+var fxPushDirectionNames = []string{"left", "right", "up", "down"}
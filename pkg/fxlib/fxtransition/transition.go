@@ -0,0 +1,65 @@
+// Package fxtransition provides transitions between two textures, such as crossfades and wipes.
+package fxtransition
+
+import (
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxcore"
+	"kdfx/pkg/fxnode"
+)
+
+// FXTransitionNode mixes two inputs by a progress value. The outgoing texture is connected to
+// slot "u_texture1" and the incoming texture to slot "u_texture2".
+// Transitions can be used as clip transitions on an fxvideo timeline.
+type FXTransitionNode interface {
+	fxnode.FXNode
+	// SetProgress sets how far the transition has advanced,
+	// from 0.0 (only the outgoing texture) to 1.0 (only the incoming texture).
+	SetProgress(progress float32)
+	// SetInput1 sets the outgoing texture input.
+	SetInput1(input fxnode.FXInput)
+	// SetInput2 sets the incoming texture input.
+	SetInput2(input fxnode.FXInput)
+}
+
+// fxTransitionNode implements the methods shared by all transitions.
+type fxTransitionNode struct {
+	fxnode.FXNode
+}
+
+// newFXTransitionNode creates a transition node that renders the given fragment shader.
+// Shaders that need the shape of the frame get it as the u_aspect uniform, the width over the height.
+func newFXTransitionNode(ctx fxcontext.FXContext, width, height int, fs string) (fxTransitionNode, error) {
+	base, err := fxnode.NewFXBaseNode(ctx, width, height)
+	if err != nil {
+		return fxTransitionNode{}, err
+	}
+
+	program, err := fxcore.NewFXShaderProgram(fxcore.FXSimpleVS, fs)
+	if err != nil {
+		base.Release()
+		return fxTransitionNode{}, err
+	}
+
+	base.SetShaderProgram(program)
+	base.SetUniform("u_aspect", float32(width)/float32(height))
+
+	n := fxTransitionNode{
+		FXNode: base,
+	}
+	// Start with the outgoing texture.
+	n.SetProgress(0)
+
+	return n, nil
+}
+
+func (n fxTransitionNode) SetProgress(progress float32) {
+	n.SetUniform("u_progress", progress)
+}
+
+func (n fxTransitionNode) SetInput1(input fxnode.FXInput) {
+	n.SetInput("u_texture1", input)
+}
+
+func (n fxTransitionNode) SetInput2(input fxnode.FXInput) {
+	n.SetInput("u_texture2", input)
+}
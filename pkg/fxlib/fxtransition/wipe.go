@@ -0,0 +1,131 @@
+package fxtransition
+
+import (
+	"kdfx/pkg/fxcontext"
+)
+
+// FXWipeShape represents the shape of the edge of a wipe.
+type FXWipeShape int
+
+const (
+	// FXWipeLinear moves a straight edge across the frame.
+	FXWipeLinear FXWipeShape = iota
+	// FXWipeRadial grows a circle from the center, like an iris.
+	FXWipeRadial
+	// FXWipeClock sweeps a hand clockwise around the center, starting at twelve o'clock.
+	FXWipeClock
+)
+
+// FXWipeFS is the fragment shader for wipe transitions.
+// Every shape maps the pixel to a value t from 0.0 to 1.0; the incoming texture covers the pixels
+// whose t is behind the edge, which moves from 0.0 to 1.0 with the progress.
+const FXWipeFS = `
+precision mediump float;
+varying vec2 v_texCoord;
+uniform sampler2D u_texture1; // Outgoing
+uniform sampler2D u_texture2; // Incoming
+uniform float u_progress;
+uniform float u_aspect;
+uniform int u_shape;
+uniform float u_angle;
+uniform vec2 u_center;
+uniform float u_feather;
+uniform int u_reverse;
+
+const float PI = 3.14159265;
+
+void main() {
+	float t;
+	if (u_shape == 1) { // Radial
+		// Distance from the center relative to the farthest corner, with round circles.
+		vec2 scale = vec2(u_aspect, 1.0);
+		vec2 far = max(u_center, 1.0 - u_center) * scale;
+		t = length((v_texCoord - u_center) * scale) / length(far);
+	} else if (u_shape == 2) { // Clock
+		// Clockwise angle from twelve o'clock; v grows downwards.
+		vec2 d = (v_texCoord - u_center) * vec2(u_aspect, 1.0);
+		t = fract(atan(d.x, -d.y) / (2.0 * PI) + 1.0);
+	} else { // Linear
+		// Position along the direction of motion, from the first corner to the last.
+		vec2 dir = vec2(cos(u_angle), sin(u_angle));
+		float extent = 0.5 * (abs(dir.x) + abs(dir.y));
+		t = (dot(v_texCoord - 0.5, dir) + extent) / (2.0 * extent);
+	}
+	if (u_reverse == 1) {
+		t = 1.0 - t;
+	}
+
+	// The edge starts one feather width early, so that both ends are clean.
+	float feather = max(u_feather, 0.0001);
+	float edge = u_progress * (1.0 + feather) - feather;
+	float amount = 1.0 - smoothstep(edge, edge + feather, t);
+
+	vec4 from = texture2D(u_texture1, v_texCoord);
+	vec4 to = texture2D(u_texture2, v_texCoord);
+	gl_FragColor = mix(from, to, amount);
+}
+`
+
+// FXWipeNode reveals the incoming texture behind a moving edge.
+type FXWipeNode interface {
+	FXTransitionNode
+	// SetShape sets the shape of the edge.
+	// See FXWipeShape constants for available shapes.
+	SetShape(shape FXWipeShape)
+	// SetAngle sets the direction in which a linear edge moves, in radians.
+	// 0 moves from left to right and Pi/2 from top to bottom.
+	SetAngle(angle float32)
+	// SetCenter sets the center of radial and clock wipes in normalized coordinates (0.0 to 1.0).
+	SetCenter(x, y float32)
+	// SetFeather sets the width of the soft edge as a fraction of the wipe (0.0 to 1.0).
+	SetFeather(feather float32)
+	// SetReverse runs the wipe backwards: linear wipes move the other way,
+	// radial wipes close towards the center and clock wipes sweep counterclockwise.
+	SetReverse(reverse bool)
+}
+
+// fxWipeNode implements FXWipeNode.
+type fxWipeNode struct {
+	fxTransitionNode
+}
+
+// NewFXWipeNode creates a new wipe fxnode.
+func NewFXWipeNode(ctx fxcontext.FXContext, width, height int) (FXWipeNode, error) {
+	base, err := newFXTransitionNode(ctx, width, height, FXWipeFS)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &fxWipeNode{fxTransitionNode: base}
+	n.SetShape(FXWipeLinear)
+	n.SetAngle(0)
+	n.SetCenter(0.5, 0.5)
+	n.SetFeather(0.05)
+	n.SetReverse(false)
+
+	return n, nil
+}
+
+func (n *fxWipeNode) SetShape(shape FXWipeShape) {
+	n.SetUniform("u_shape", int(shape))
+}
+
+func (n *fxWipeNode) SetAngle(angle float32) {
+	n.SetUniform("u_angle", angle)
+}
+
+func (n *fxWipeNode) SetCenter(x, y float32) {
+	n.SetUniform("u_center", []float32{x, y})
+}
+
+func (n *fxWipeNode) SetFeather(feather float32) {
+	n.SetUniform("u_feather", feather)
+}
+
+func (n *fxWipeNode) SetReverse(reverse bool) {
+	val := 0
+	if reverse {
+		val = 1
+	}
+	n.SetUniform("u_reverse", val)
+}
@@ -0,0 +1,66 @@
+package fxtransition
+
+import (
+	"kdfx/pkg/fxcontext"
+)
+
+// FXZoomFS is the fragment shader for the zoom transition.
+// The outgoing texture zooms in from 1 to u_strength, and the incoming texture starts zoomed in
+// by u_strength and settles to 1. Both stay at least as large as the frame, so no edges show.
+const FXZoomFS = `
+precision mediump float;
+varying vec2 v_texCoord;
+uniform sampler2D u_texture1; // Outgoing
+uniform sampler2D u_texture2; // Incoming
+uniform float u_progress;
+uniform float u_strength;
+uniform vec2 u_center;
+
+void main() {
+	// Zooming by a constant factor per unit of progress looks like a steady motion.
+	float strength = max(u_strength, 1.0);
+	float fromZoom = pow(strength, u_progress);
+	float toZoom = pow(strength, 1.0 - u_progress);
+
+	vec4 from = texture2D(u_texture1, u_center + (v_texCoord - u_center) / fromZoom);
+	vec4 to = texture2D(u_texture2, u_center + (v_texCoord - u_center) / toZoom);
+	gl_FragColor = mix(from, to, smoothstep(0.25, 0.75, u_progress));
+}
+`
+
+// FXZoomNode zooms through the cut: the outgoing texture zooms in towards a center and the
+// incoming texture, fading in halfway, zooms back out from the same center.
+type FXZoomNode interface {
+	FXTransitionNode
+	// SetStrength sets how far each texture zooms, as a scale factor (1.0 or more).
+	SetStrength(strength float32)
+	// SetCenter sets the point zoomed into, in normalized coordinates (0.0 to 1.0).
+	SetCenter(x, y float32)
+}
+
+// fxZoomNode implements FXZoomNode.
+type fxZoomNode struct {
+	fxTransitionNode
+}
+
+// NewFXZoomNode creates a new zoom fxnode.
+func NewFXZoomNode(ctx fxcontext.FXContext, width, height int) (FXZoomNode, error) {
+	base, err := newFXTransitionNode(ctx, width, height, FXZoomFS)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &fxZoomNode{fxTransitionNode: base}
+	n.SetStrength(4)
+	n.SetCenter(0.5, 0.5)
+
+	return n, nil
+}
+
+func (n *fxZoomNode) SetStrength(strength float32) {
+	n.SetUniform("u_strength", strength)
+}
+
+func (n *fxZoomNode) SetCenter(x, y float32) {
+	n.SetUniform("u_center", []float32{x, y})
+}
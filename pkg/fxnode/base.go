@@ -1,8 +1,10 @@
 package fxnode
 
 import (
+	"fmt"
 	"kdfx/pkg/fxcontext"
 	"kdfx/pkg/fxcore"
+	"sort"
 )
 
 // fxBaseNode implements common logic for Nodes.
@@ -15,6 +17,9 @@ type fxBaseNode struct {
 	output fxcore.FXFramebuffer
 	// program is the shader program used by the node.
 	program fxcore.FXShaderProgram
+	// samplerSlots lists the input slots in texture unit order for the current program.
+	// Slot i is bound to texture unit i. It is nil when the mapping must be rebuilt.
+	samplerSlots []string
 	// quad is the full-screen quad used for rendering.
 	quad fxcore.FXQuad
 	// dirty indicates if the node needs to be re-processed.
@@ -58,6 +63,10 @@ func NewFXBaseNode(ctx fxcontext.FXContext, width, height int) (FXNode, error) {
 }
 
 func (n *fxBaseNode) SetInput(name string, input FXInput) {
+	if _, ok := n.inputs[name]; !ok {
+		// A new slot changes the sampler-to-unit mapping.
+		n.samplerSlots = nil
+	}
 	n.inputs[name] = input
 	n.dirty = true
 }
@@ -70,11 +79,35 @@ func (n *fxBaseNode) GetFramebuffer() fxcore.FXFramebuffer {
 	return n.output
 }
 
+func (n *fxBaseNode) SetFormat(format fxcore.FXTextureFormat) error {
+	if n.output.GetTexture().GetFormat() == format {
+		return nil
+	}
+	// Reallocate the output at the same size with the new format.
+	w, h := n.output.GetTexture().GetSize()
+	fbo, err := fxcore.NewFXFramebufferWithFormat(w, h, format)
+	if err != nil {
+		return err
+	}
+	n.output.Release()
+	n.output = fbo
+	n.dirty = true
+	return nil
+}
+
+func (n *fxBaseNode) GetFormat() fxcore.FXTextureFormat {
+	return n.output.GetTexture().GetFormat()
+}
+
 func (n *fxBaseNode) SetUniform(name string, value interface{}) {
 	n.uniforms[name] = value
 	n.dirty = true
 }
 
+func (n *fxBaseNode) GetUniform(name string) interface{} {
+	return n.uniforms[name]
+}
+
 func (n *fxBaseNode) SetPosition(x, y float32) {
 	n.posX = x
 	n.posY = y
@@ -92,8 +125,22 @@ func (n *fxBaseNode) SetRotation(angle float32) {
 	n.dirty = true
 }
 
+func (n *fxBaseNode) GetPosition() (float32, float32) {
+	return n.posX, n.posY
+}
+
+func (n *fxBaseNode) GetSize() (float32, float32) {
+	return n.scaleX, n.scaleY
+}
+
+func (n *fxBaseNode) GetRotation() float32 {
+	return n.rotation
+}
+
 func (n *fxBaseNode) SetShaderProgram(program fxcore.FXShaderProgram) {
 	n.program = program
+	// Sampler uniforms are per-program state, so the new program needs them set again.
+	n.samplerSlots = nil
 }
 
 func (n *fxBaseNode) UpdateTransformationUniforms(program fxcore.FXShaderProgram) {
@@ -131,6 +178,10 @@ func (n *fxBaseNode) Release() {
 	}
 }
 
+func (n *fxBaseNode) MarkDirty() {
+	n.dirty = true
+}
+
 // CheckDirty checks if processing is needed.
 func (n *fxBaseNode) CheckDirty() bool {
 	isDirty := n.IsDirty()
@@ -142,15 +193,18 @@ func (n *fxBaseNode) CheckDirty() bool {
 
 // Process executes the node's operation.
 func (n *fxBaseNode) Process(ctx fxcontext.FXContext) error {
-	// 1. Process Inputs
+	// 1. Check Dirty
+	// This must happen before processing inputs, as that clears their dirty flags.
+	isDirty := n.CheckDirty()
+
+	// 2. Process Inputs
 	// Ensure all upstream nodes have processed their data.
 	if err := n.ProcessInputs(ctx); err != nil {
 		return err
 	}
 
-	// 2. Check Dirty
 	// If neither this node nor its inputs have changed, skip processing.
-	if !n.CheckDirty() {
+	if !isDirty {
 		return nil
 	}
 
@@ -161,14 +215,15 @@ func (n *fxBaseNode) Process(ctx fxcontext.FXContext) error {
 		n.program.Use()
 
 		// 4. Bind Inputs
-		// Bind input textures to texture units and set uniforms.
-		textureUnit := 0
-		for name, input := range n.inputs {
-			tex := input.GetTexture()
+		// Bind input textures to their texture units.
+		if err := n.updateSamplerUnits(); err != nil {
+			n.output.Unbind()
+			return err
+		}
+		for unit, name := range n.samplerSlots {
+			tex := n.inputs[name].GetTexture()
 			if tex != nil {
-				tex.BindToUnit(textureUnit)
-				n.program.SetUniform1i(name, int32(textureUnit))
-				textureUnit++
+				tex.BindToUnit(unit)
 			}
 		}
 
@@ -208,14 +263,37 @@ func (n *fxBaseNode) Process(ctx fxcontext.FXContext) error {
 	return nil
 }
 
+// updateSamplerUnits assigns texture units to input slots in sorted slot order
+// and sets the sampler uniforms. The mapping is cached until the inputs or program change.
+// The program must be in use.
+func (n *fxBaseNode) updateSamplerUnits() error {
+	if n.samplerSlots != nil {
+		return nil
+	}
+
+	slots := make([]string, 0, len(n.inputs))
+	for name := range n.inputs {
+		slots = append(slots, name)
+	}
+	sort.Strings(slots)
+
+	if maxUnits := fxcore.FXMaxTextureUnits(); len(slots) > maxUnits {
+		return fmt.Errorf("node has %d inputs but only %d texture units are available", len(slots), maxUnits)
+	}
+
+	for unit, name := range slots {
+		n.program.SetUniform1i(name, int32(unit))
+	}
+	n.samplerSlots = slots
+	return nil
+}
+
 // ProcessInputs ensures that all input nodes are processed.
 func (n *fxBaseNode) ProcessInputs(ctx fxcontext.FXContext) error {
 	for _, input := range n.inputs {
-		// Recursively call Process on input nodes.
-		if node, ok := input.(FXNode); ok {
-			if err := node.Process(ctx); err != nil {
-				return err
-			}
+		// Recursively process input nodes, unless the pipeline already did this frame.
+		if err := FXProcessInput(ctx, input); err != nil {
+			return err
 		}
 	}
 	return nil
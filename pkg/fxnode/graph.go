@@ -3,18 +3,50 @@ package fxnode
 import (
 	"fmt"
 	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxcore"
+	"sort"
+	"strings"
 )
 
+// fxFrameContext is the context a pipeline passes to its nodes while it executes.
+// It records the graph nodes already processed in the current frame, so nodes that reach
+// them again through their inputs do not process them a second time.
+type fxFrameContext struct {
+	fxcontext.FXContext
+	// processed holds the nodes processed in this frame.
+	processed map[FXNode]bool
+}
+
+// FXProcessInput processes an input if it is a node, so that its texture is up to date.
+// While a pipeline executes, nodes it has already processed in the current frame are skipped.
+// Nodes that process their inputs themselves should use it instead of calling Process.
+func FXProcessInput(ctx fxcontext.FXContext, input FXInput) error {
+	node, ok := input.(FXNode)
+	if !ok {
+		return nil
+	}
+	if frame, ok := ctx.(*fxFrameContext); ok && frame.processed[node] {
+		return nil
+	}
+	return node.Process(ctx)
+}
+
 // fxGraph represents a collection of nodes and their connections.
 type fxGraph struct {
 	// nodes maps node names to FXNode instances.
 	nodes map[string]FXNode
+	// externals maps names to external inputs, such as images, that are not processed.
+	externals map[string]FXInput
+	// inputs maps target node names to their connected slots and source names.
+	inputs map[string]map[string]string
 }
 
 // NewFXGraph creates a new empty fxGraph.
 func NewFXGraph() FXGraph {
 	return &fxGraph{
-		nodes: make(map[string]FXNode),
+		nodes:     make(map[string]FXNode),
+		externals: make(map[string]FXInput),
+		inputs:    make(map[string]map[string]string),
 	}
 }
 
@@ -23,11 +55,22 @@ func (g *fxGraph) AddNode(name string, node FXNode) {
 	g.nodes[name] = node
 }
 
+// AddInput adds an external input to the fxGraph with a unique name.
+func (g *fxGraph) AddInput(name string, input FXInput) {
+	g.externals[name] = input
+}
+
 // Connect connects the output of sourceNode to the input slot of targetNode.
+// The source can be a node or an external input added with AddInput.
 // The inputSlot string typically matches a uniform name in the target node's shader.
+// It returns an error if the connection would introduce a cycle.
 func (g *fxGraph) Connect(sourceNodeName, targetNodeName, inputSlot string) error {
-	source, ok := g.nodes[sourceNodeName]
-	if !ok {
+	var input FXInput
+	if source, ok := g.nodes[sourceNodeName]; ok {
+		input = source
+	} else if external, ok := g.externals[sourceNodeName]; ok {
+		input = external
+	} else {
 		return fmt.Errorf("source node %s not found", sourceNodeName)
 	}
 	target, ok := g.nodes[targetNodeName]
@@ -35,8 +78,58 @@ func (g *fxGraph) Connect(sourceNodeName, targetNodeName, inputSlot string) erro
 		return fmt.Errorf("target node %s not found", targetNodeName)
 	}
 
-	// Set the source node as an input to the target node.
-	target.SetInput(inputSlot, source)
+	// Reject the connection if the target already feeds the source.
+	if path := g.findPath(targetNodeName, sourceNodeName); path != nil {
+		cycle := append(path, targetNodeName)
+		return fmt.Errorf("connecting %s to %s.%s would create a cycle: %s",
+			sourceNodeName, targetNodeName, inputSlot, strings.Join(cycle, " -> "))
+	}
+
+	if g.inputs[targetNodeName] == nil {
+		g.inputs[targetNodeName] = make(map[string]string)
+	}
+	g.inputs[targetNodeName][inputSlot] = sourceNodeName
+
+	// Set the source as an input to the target node.
+	target.SetInput(inputSlot, input)
+	return nil
+}
+
+// findPath returns the chain of node names from "from" to "to" following connections
+// downstream, or nil if "to" is not reachable from "from".
+// It runs a breadth-first search, so every node is visited at most once.
+func (g *fxGraph) findPath(from, to string) []string {
+	// 1. Map each source to its targets, in the sorted connection order
+	targets := make(map[string][]string)
+	for _, conn := range g.GetConnections() {
+		targets[conn.Source] = append(targets[conn.Source], conn.Target)
+	}
+
+	// 2. Search downstream, recording the node each one was reached from
+	parents := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if name == to {
+			// 3. Walk the parents back to rebuild the path
+			var path []string
+			for ; name != from; name = parents[name] {
+				path = append(path, name)
+			}
+			path = append(path, from)
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path
+		}
+		for _, target := range targets[name] {
+			if _, seen := parents[target]; !seen {
+				parents[target] = name
+				queue = append(queue, target)
+			}
+		}
+	}
 	return nil
 }
 
@@ -44,6 +137,122 @@ func (g *fxGraph) GetNode(name string) FXNode {
 	return g.nodes[name]
 }
 
+func (g *fxGraph) GetInput(name string) FXInput {
+	return g.externals[name]
+}
+
+// GetNodeNames returns the names of all nodes in the fxGraph, sorted.
+func (g *fxGraph) GetNodeNames() []string {
+	names := make([]string, 0, len(g.nodes))
+	for name := range g.nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetInputNames returns the names of all external inputs in the fxGraph, sorted.
+func (g *fxGraph) GetInputNames() []string {
+	names := make([]string, 0, len(g.externals))
+	for name := range g.externals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetConnections returns all connections in the fxGraph, sorted by target and slot.
+func (g *fxGraph) GetConnections() []FXConnection {
+	var conns []FXConnection
+	for target, slots := range g.inputs {
+		for slot, source := range slots {
+			conns = append(conns, FXConnection{Source: source, Target: target, Slot: slot})
+		}
+	}
+	sort.Slice(conns, func(i, j int) bool {
+		if conns[i].Target != conns[j].Target {
+			return conns[i].Target < conns[j].Target
+		}
+		return conns[i].Slot < conns[j].Slot
+	})
+	return conns
+}
+
+// GetSources returns the names of the nodes and inputs connected to a node, in slot name order.
+func (g *fxGraph) GetSources(nodeName string) []string {
+	slots := make([]string, 0, len(g.inputs[nodeName]))
+	for slot := range g.inputs[nodeName] {
+		slots = append(slots, slot)
+	}
+	sort.Strings(slots)
+	sources := make([]string, len(slots))
+	for i, slot := range slots {
+		sources[i] = g.inputs[nodeName][slot]
+	}
+	return sources
+}
+
+// SetFormat sets the storage format of every node in the fxGraph, in name order.
+func (g *fxGraph) SetFormat(format fxcore.FXTextureFormat) error {
+	for _, name := range g.GetNodeNames() {
+		if err := g.nodes[name].SetFormat(format); err != nil {
+			return fmt.Errorf("node %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// TopologicalOrder returns the names of the nodes the output node depends on,
+// including the output node itself, ordered so that every node follows its sources.
+// Inputs are visited in slot name order, so the result is deterministic.
+func (g *fxGraph) TopologicalOrder(outputNodeName string) ([]string, error) {
+	if _, ok := g.nodes[outputNodeName]; !ok {
+		return nil, fmt.Errorf("output node %s not found", outputNodeName)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var order []string
+	var stack []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			// Connect rejects cycles, so this only guards against inconsistent state.
+			return fmt.Errorf("cycle detected: %s -> %s", strings.Join(stack, " -> "), name)
+		}
+		state[name] = visiting
+		stack = append(stack, name)
+
+		for _, source := range g.GetSources(name) {
+			if _, ok := g.nodes[source]; !ok {
+				// External inputs are not part of the processing order.
+				continue
+			}
+			if err := visit(source); err != nil {
+				return err
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+
+	if err := visit(outputNodeName); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
 func (g *fxGraph) Release() {
 	for _, node := range g.nodes {
 		node.Release()
@@ -67,16 +276,46 @@ func NewFXPipeline(ctx fxcontext.FXContext, fxGraph FXGraph) FXPipeline {
 }
 
 // Execute runs the fxPipeline for a specific output fxnode.
-// It recursively processes dependencies.
+// Every node the output depends on is processed exactly once, in topological order.
 func (p *fxPipeline) Execute(outputNodeName string) error {
-	node := p.fxGraph.GetNode(outputNodeName)
-	if node == nil {
-		return fmt.Errorf("output node %s not found", outputNodeName)
+	order, err := p.fxGraph.TopologicalOrder(outputNodeName)
+	if err != nil {
+		return err
 	}
 
-	// Trigger the processing chain starting from the output node.
-	// The Process method of the node will recursively call Process on its inputs.
-	return node.Process(p.context)
+	// frame stops nodes from processing their sources again through their inputs.
+	frame := &fxFrameContext{FXContext: p.context, processed: make(map[FXNode]bool)}
+
+	// changed records which nodes produced new output during this frame.
+	changed := make(map[string]bool)
+	for _, name := range order {
+		node := p.fxGraph.GetNode(name)
+		if node == nil {
+			return fmt.Errorf("node %s not found", name)
+		}
+
+		// Sources have already been processed, which clears their dirty flags,
+		// so mark the node dirty explicitly when any of them changed.
+		for _, source := range p.fxGraph.GetSources(name) {
+			sourceChanged, ok := changed[source]
+			if !ok {
+				// External inputs are never processed, so their dirty flag is reported directly.
+				sourceChanged = p.fxGraph.GetInput(source).IsDirty()
+			}
+			if sourceChanged {
+				node.MarkDirty()
+				break
+			}
+		}
+		changed[name] = node.IsDirty()
+
+		if err := node.Process(frame); err != nil {
+			return fmt.Errorf("failed to process node %s: %w", name, err)
+		}
+		frame.processed[node] = true
+	}
+
+	return nil
 }
 
 // Release releases all resources in the fxGraph.
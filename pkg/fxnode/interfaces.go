@@ -22,16 +22,25 @@ type FXNode interface {
 
 	// SetInput connects an input to a named slot.
 	// The name usually corresponds to a sampler2D uniform in the shader.
+	// Inputs are bound to texture units in slot name order, starting at unit 0.
 	SetInput(name string, input FXInput)
 	// GetInput returns the input connected to a named slot.
 	GetInput(name string) FXInput
 	// GetFramebuffer returns the node's output framebuffer.
 	// This contains the result of the node's processing.
 	GetFramebuffer() fxcore.FXFramebuffer
+	// SetFormat reallocates the node's output (and any intermediate buffers) with the given
+	// storage format. Float formats keep values outside 0 to 1 and avoid banding between nodes.
+	// It returns an error if the format is not supported by the context.
+	SetFormat(format fxcore.FXTextureFormat) error
+	// GetFormat returns the storage format of the node's output.
+	GetFormat() fxcore.FXTextureFormat
 
 	// SetUniform sets a uniform value for the node's shader.
 	// Supported types: float32, int, int32, []float32 (vec2, vec3).
 	SetUniform(name string, value interface{})
+	// GetUniform returns the value of a uniform, or nil if it has not been set.
+	GetUniform(name string) interface{}
 
 	// SetPosition sets the position of the node in normalized coordinates (-1 to 1).
 	// This affects the rendering of the node's quad.
@@ -42,6 +51,12 @@ type FXNode interface {
 	// SetRotation sets the rotation of the node in radians.
 	// This rotates the node's quad.
 	SetRotation(angle float32)
+	// GetPosition returns the position of the node in normalized coordinates.
+	GetPosition() (float32, float32)
+	// GetSize returns the size (scale factor) of the node.
+	GetSize() (float32, float32)
+	// GetRotation returns the rotation of the node in radians.
+	GetRotation() float32
 
 	// SetShaderProgram sets the shader program for the fxnode.
 	// This program defines how the node processes its inputs.
@@ -51,6 +66,10 @@ type FXNode interface {
 	// Uniforms: u_translation (vec2), u_scale (vec2), u_rotation (float).
 	UpdateTransformationUniforms(program fxcore.FXShaderProgram)
 
+	// MarkDirty flags the node for re-processing on the next Process call.
+	// The pipeline uses this when an upstream node has produced new output.
+	MarkDirty()
+
 	// Process executes the node's operation if necessary.
 	// It checks if the node or any of its inputs are dirty.
 	// If so, it renders the result to the output framebuffer.
@@ -61,14 +80,47 @@ type FXNode interface {
 	Release()
 }
 
+// FXConnection describes a connection between two nodes in an FXGraph.
+type FXConnection struct {
+	// Source is the name of the node or graph input providing the texture.
+	Source string
+	// Target is the name of the node receiving the texture.
+	Target string
+	// Slot is the input slot on the target node.
+	Slot string
+}
+
 // FXGraph represents a collection of nodes and their connections.
+// Connections always form a directed acyclic graph.
 type FXGraph interface {
 	// AddNode adds a node to the graph.
 	AddNode(name string, node FXNode)
-	// Connect connects two nodes.
+	// AddInput adds an external input, such as an image, to the graph.
+	// Inputs can be used as the source of connections but are not processed by the pipeline.
+	AddInput(name string, input FXInput)
+	// Connect connects a node or input to a slot of a node.
+	// It returns an error if the connection would create a cycle.
+	// A node processes its sources when it is processed on its own; an FXPipeline processes
+	// each node once per frame instead.
 	Connect(sourceNodeName, targetNodeName, inputSlot string) error
 	// GetNode returns a node by name.
 	GetNode(name string) FXNode
+	// GetInput returns an external input by name.
+	GetInput(name string) FXInput
+	// GetNodeNames returns the names of all nodes, sorted.
+	GetNodeNames() []string
+	// GetInputNames returns the names of all external inputs, sorted.
+	GetInputNames() []string
+	// GetConnections returns all connections, sorted by target and slot.
+	GetConnections() []FXConnection
+	// GetSources returns the names of the nodes and inputs connected to a node, in slot order.
+	GetSources(nodeName string) []string
+	// SetFormat sets the storage format of every node currently in the graph.
+	// Nodes can still be given a different format individually afterwards.
+	SetFormat(format fxcore.FXTextureFormat) error
+	// TopologicalOrder returns the output node and all nodes it depends on,
+	// ordered so that every node comes after its sources.
+	TopologicalOrder(outputNodeName string) ([]string, error)
 	// Release frees resources held by all nodes in the fxGraph.
 	Release()
 }
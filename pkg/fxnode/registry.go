@@ -0,0 +1,337 @@
+package fxnode
+
+import (
+	"fmt"
+	"kdfx/pkg/fxcontext"
+	"reflect"
+	"sort"
+)
+
+// FXParamKind is the value type of a node parameter.
+type FXParamKind int
+
+const (
+	FXParamFloat FXParamKind = iota // float32 value.
+	FXParamInt                      // int value.
+	FXParamBool                     // bool value.
+	FXParamVec2                     // []float32 value with 2 components.
+	FXParamVec3                     // []float32 value with 3 components.
+)
+
+// String returns the name of the parameter kind.
+func (k FXParamKind) String() string {
+	switch k {
+	case FXParamFloat:
+		return "float"
+	case FXParamInt:
+		return "int"
+	case FXParamBool:
+		return "bool"
+	case FXParamVec2:
+		return "vec2"
+	case FXParamVec3:
+		return "vec3"
+	}
+	return fmt.Sprintf("FXParamKind(%d)", int(k))
+}
+
+// FXParam describes a node parameter that can be read and written generically.
+// Values passed to Set and returned by Get have the Go type given by Kind.
+type FXParam struct {
+	// Name is the parameter name, e.g. "radius".
+	Name string
+	// Kind is the value type of the parameter.
+	Kind FXParamKind
+	// Description is a short human readable description of the parameter.
+	Description string
+	// Default is the value set by the node constructor, of the Go type given by Kind.
+	Default interface{}
+	// Min is the lower end of the suggested range, per component for vectors.
+	Min float32
+	// Max is the upper end of the suggested range, per component for vectors.
+	// The range is a hint for UIs and is not enforced. Min == Max means no range is suggested.
+	Max float32
+	// Options names the values of an enumerated int parameter: value i is named Options[i].
+	// Enumerated parameters only accept values in range, and also accept option names.
+	Options []string
+	// Get returns the current value of the parameter on the node.
+	Get func(node FXNode) interface{}
+	// Set applies a value to the node, typically through its Set* method.
+	Set func(node FXNode, value interface{})
+}
+
+// FXUniformGetter returns a parameter getter that reads the value from a uniform of the node.
+// Bool parameters are read from int uniforms (0 or 1).
+func FXUniformGetter(kind FXParamKind, uniform string) func(node FXNode) interface{} {
+	return func(node FXNode) interface{} {
+		switch kind {
+		case FXParamInt:
+			return FXUniformInt(node, uniform)
+		case FXParamBool:
+			return FXUniformInt(node, uniform) != 0
+		case FXParamVec2:
+			return FXUniformVec(node, uniform, 2)
+		case FXParamVec3:
+			return FXUniformVec(node, uniform, 3)
+		}
+		return FXUniformFloat(node, uniform)
+	}
+}
+
+// FXNodeType describes a node type that can be instantiated by name.
+type FXNodeType struct {
+	// Name is the unique type name, e.g. "gaussianBlur".
+	Name string
+	// Category groups related types, e.g. "blur" or "color".
+	Category string
+	// Description is a short human readable description of the node type.
+	Description string
+	// Inputs lists the input slots the node reads, in texture unit order.
+	Inputs []string
+	// GoType is the concrete Go type of the nodes returned by New.
+	// It is used to find the type of an existing node.
+	GoType reflect.Type
+	// New creates a new node of this type.
+	New func(ctx fxcontext.FXContext, width, height int) (FXNode, error)
+	// Params lists the parameters of the node type.
+	Params []FXParam
+}
+
+// fxNodeTypes maps type names to registered node types.
+var fxNodeTypes = make(map[string]FXNodeType)
+
+// fxNodeGoTypes maps concrete Go types to registered type names.
+var fxNodeGoTypes = make(map[reflect.Type]string)
+
+// FXRegisterNodeType registers a node type.
+// It is intended to be called from package init functions and panics on duplicate names
+// or invalid parameter descriptors.
+func FXRegisterNodeType(nodeType FXNodeType) {
+	if _, ok := fxNodeTypes[nodeType.Name]; ok {
+		panic(fmt.Sprintf("fxnode: node type %s registered twice", nodeType.Name))
+	}
+	if nodeType.Name == "" || nodeType.New == nil {
+		panic("fxnode: node type needs a name and a constructor")
+	}
+	seen := make(map[string]bool)
+	for _, param := range nodeType.Params {
+		if param.Name == "" || param.Get == nil || param.Set == nil || seen[param.Name] {
+			panic(fmt.Sprintf("fxnode: invalid parameter %q of node type %s", param.Name, nodeType.Name))
+		}
+		seen[param.Name] = true
+		if _, err := param.Convert(param.Default); err != nil {
+			panic(fmt.Sprintf("fxnode: invalid default for %s.%s: %v", nodeType.Name, param.Name, err))
+		}
+	}
+	fxNodeTypes[nodeType.Name] = nodeType
+	if nodeType.GoType != nil {
+		fxNodeGoTypes[nodeType.GoType] = nodeType.Name
+	}
+}
+
+// FXLookupNodeType returns the registered node type with the given name.
+func FXLookupNodeType(name string) (FXNodeType, bool) {
+	nodeType, ok := fxNodeTypes[name]
+	return nodeType, ok
+}
+
+// FXNodeTypes returns all registered node types, sorted by name.
+func FXNodeTypes() []FXNodeType {
+	types := make([]FXNodeType, 0, len(fxNodeTypes))
+	for _, nodeType := range fxNodeTypes {
+		types = append(types, nodeType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	return types
+}
+
+// FXNodeTypeOf returns the registered node type of an existing node.
+func FXNodeTypeOf(node FXNode) (FXNodeType, bool) {
+	name, ok := fxNodeGoTypes[reflect.TypeOf(node)]
+	if !ok {
+		return FXNodeType{}, false
+	}
+	return fxNodeTypes[name], true
+}
+
+// GetParam returns the parameter with the given name.
+func (t FXNodeType) GetParam(name string) (FXParam, bool) {
+	for _, param := range t.Params {
+		if param.Name == name {
+			return param, true
+		}
+	}
+	return FXParam{}, false
+}
+
+// SetParam converts a decoded value (e.g. from JSON or YAML) to the parameter's kind
+// and applies it to the node.
+func (t FXNodeType) SetParam(node FXNode, name string, value interface{}) error {
+	param, ok := t.GetParam(name)
+	if !ok {
+		return fmt.Errorf("node type %s has no parameter %s", t.Name, name)
+	}
+	converted, err := param.Convert(value)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s.%s: %v", t.Name, name, err)
+	}
+	param.Set(node, converted)
+	return nil
+}
+
+// GetParams returns the current values of all parameters of the node.
+func (t FXNodeType) GetParams(node FXNode) map[string]interface{} {
+	values := make(map[string]interface{}, len(t.Params))
+	for _, param := range t.Params {
+		values[param.Name] = param.Get(node)
+	}
+	return values
+}
+
+// ResetParams sets all parameters of the node back to their defaults.
+func (t FXNodeType) ResetParams(node FXNode) {
+	for _, param := range t.Params {
+		// Defaults are validated on registration.
+		value, _ := param.Convert(param.Default)
+		param.Set(node, value)
+	}
+}
+
+// Convert converts a decoded value to the Go type of the parameter.
+// Enumerated parameters accept option names and reject values out of range.
+func (p FXParam) Convert(value interface{}) (interface{}, error) {
+	if len(p.Options) > 0 {
+		if name, ok := value.(string); ok {
+			for i, option := range p.Options {
+				if option == name {
+					return i, nil
+				}
+			}
+			return nil, fmt.Errorf("unknown option %q (expected one of %v)", name, p.Options)
+		}
+	}
+	converted, err := FXConvertParam(p.Kind, value)
+	if err != nil {
+		return nil, err
+	}
+	if i, ok := converted.(int); ok && len(p.Options) > 0 && (i < 0 || i >= len(p.Options)) {
+		return nil, fmt.Errorf("option %d out of range (0 to %d)", i, len(p.Options)-1)
+	}
+	return converted, nil
+}
+
+// OptionName returns the name of an enumerated value, or "" if the parameter is not enumerated
+// or the value is out of range.
+func (p FXParam) OptionName(value interface{}) string {
+	i, ok := value.(int)
+	if !ok || i < 0 || i >= len(p.Options) {
+		return ""
+	}
+	return p.Options[i]
+}
+
+// FXConvertParam converts a decoded value to the Go type of the given parameter kind.
+// Numbers may be any integer or float type; vectors may be []float32, []float64 or []interface{}.
+func FXConvertParam(kind FXParamKind, value interface{}) (interface{}, error) {
+	switch kind {
+	case FXParamFloat:
+		f, err := fxToFloat(value)
+		return float32(f), err
+	case FXParamInt:
+		f, err := fxToFloat(value)
+		if err != nil {
+			return nil, err
+		}
+		if f != float64(int(f)) {
+			return nil, fmt.Errorf("expected integer, got %v", value)
+		}
+		return int(f), nil
+	case FXParamBool:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected bool, got %T", value)
+		}
+		return b, nil
+	case FXParamVec2:
+		return fxToVec(value, 2)
+	case FXParamVec3:
+		return fxToVec(value, 3)
+	}
+	return nil, fmt.Errorf("unknown parameter kind %d", kind)
+}
+
+// fxToFloat converts a numeric value to float64.
+func fxToFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("expected number, got %T", value)
+}
+
+// fxToVec converts a list value to a []float32 of the given size.
+func fxToVec(value interface{}, size int) ([]float32, error) {
+	var items []interface{}
+	switch v := value.(type) {
+	case []float32:
+		for _, f := range v {
+			items = append(items, f)
+		}
+	case []float64:
+		for _, f := range v {
+			items = append(items, f)
+		}
+	case []interface{}:
+		items = v
+	default:
+		return nil, fmt.Errorf("expected list of %d numbers, got %T", size, value)
+	}
+	if len(items) != size {
+		return nil, fmt.Errorf("expected list of %d numbers, got %d", size, len(items))
+	}
+	vec := make([]float32, size)
+	for i, item := range items {
+		f, err := fxToFloat(item)
+		if err != nil {
+			return nil, err
+		}
+		vec[i] = float32(f)
+	}
+	return vec, nil
+}
+
+// FXUniformFloat returns a float uniform of the node, or 0 if it is not set.
+func FXUniformFloat(node FXNode, name string) float32 {
+	v, _ := node.GetUniform(name).(float32)
+	return v
+}
+
+// FXUniformInt returns an int uniform of the node, or 0 if it is not set.
+func FXUniformInt(node FXNode, name string) int {
+	switch v := node.GetUniform(name).(type) {
+	case int:
+		return v
+	case int32:
+		return int(v)
+	}
+	return 0
+}
+
+// FXUniformVec returns a vector uniform of the node, or a zero vector of the given size if it is not set.
+func FXUniformVec(node FXNode, name string, size int) []float32 {
+	v, ok := node.GetUniform(name).([]float32)
+	if !ok || len(v) != size {
+		return make([]float32, size)
+	}
+	return append([]float32(nil), v...)
+}
@@ -0,0 +1,332 @@
+package fxpreset
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxcore"
+	"kdfx/pkg/fximage"
+	"kdfx/pkg/fxnode"
+
+	// Register the built-in node types.
+	_ "kdfx/pkg/fxlib/fxartistic"
+	_ "kdfx/pkg/fxlib/fxblend"
+	_ "kdfx/pkg/fxlib/fxblur"
+	_ "kdfx/pkg/fxlib/fxcolor"
+	_ "kdfx/pkg/fxlib/fxdistortion"
+	_ "kdfx/pkg/fxlib/fxtransition"
+)
+
+// fxPresetGraph is an FXGraph built from a definition.
+// It also owns the textures loaded for file inputs and releases them with the nodes.
+type fxPresetGraph struct {
+	fxnode.FXGraph
+	// textures are the textures loaded from input files.
+	textures []fxcore.FXTexture
+}
+
+func (g *fxPresetGraph) Release() {
+	g.FXGraph.Release()
+	for _, tex := range g.textures {
+		tex.Release()
+	}
+	g.textures = nil
+}
+
+// FXBuildGraph instantiates a graph definition.
+// Inputs provided by the caller take precedence over inputs loaded from files; input paths
+// are resolved relative to dir. Every node type must have been registered with fxnode.
+func FXBuildGraph(ctx fxcontext.FXContext, def *FXGraphDef, dir string, inputs map[string]fxnode.FXInput) (fxnode.FXGraph, error) {
+	graph := &fxPresetGraph{FXGraph: fxnode.NewFXGraph()}
+	if err := buildGraph(ctx, graph, def, dir, inputs); err != nil {
+		graph.Release()
+		return nil, err
+	}
+	return graph, nil
+}
+
+// buildGraph adds the inputs, nodes and connections of the definition to the graph.
+func buildGraph(ctx fxcontext.FXContext, graph *fxPresetGraph, def *FXGraphDef, dir string, inputs map[string]fxnode.FXInput) error {
+	// 1. Inputs
+	names := make(map[string]bool)
+	for _, in := range def.Inputs {
+		if in.Name == "" {
+			return fmt.Errorf("input without a name")
+		}
+		if names[in.Name] {
+			return fmt.Errorf("duplicate name %s", in.Name)
+		}
+		names[in.Name] = true
+
+		if input, ok := inputs[in.Name]; ok {
+			graph.AddInput(in.Name, input)
+			continue
+		}
+		if in.Path == "" {
+			return fmt.Errorf("input %s has no path and was not provided", in.Name)
+		}
+		path := in.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		image, err := fximage.NewFXImageInputFromFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to load input %s: %v", in.Name, err)
+		}
+		graph.textures = append(graph.textures, image.Texture)
+		graph.AddInput(in.Name, image)
+	}
+	// Inputs provided by the caller can be connected even if they are not declared.
+	for name, input := range inputs {
+		if !names[name] {
+			names[name] = true
+			graph.AddInput(name, input)
+		}
+	}
+
+	// 2. Nodes
+	for _, nodeDef := range def.Nodes {
+		if nodeDef.Name == "" {
+			return fmt.Errorf("node without a name")
+		}
+		if names[nodeDef.Name] {
+			return fmt.Errorf("duplicate name %s", nodeDef.Name)
+		}
+		names[nodeDef.Name] = true
+
+		node, err := buildNode(ctx, def, nodeDef)
+		if err != nil {
+			return fmt.Errorf("node %s: %v", nodeDef.Name, err)
+		}
+		graph.AddNode(nodeDef.Name, node)
+	}
+
+	// 3. Connections
+	for _, conn := range def.Connections {
+		if err := graph.Connect(conn.Source, conn.Target, conn.Slot); err != nil {
+			return err
+		}
+	}
+
+	if def.Output != "" && graph.GetNode(def.Output) == nil {
+		return fmt.Errorf("output node %s not found", def.Output)
+	}
+	return nil
+}
+
+// buildNode creates a node and applies its parameters and transform.
+func buildNode(ctx fxcontext.FXContext, def *FXGraphDef, nodeDef FXNodeDef) (fxnode.FXNode, error) {
+	nodeType, ok := fxnode.FXLookupNodeType(nodeDef.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown node type %q", nodeDef.Type)
+	}
+
+	width, height := def.Width, def.Height
+	if nodeDef.Width != 0 {
+		width = nodeDef.Width
+	}
+	if nodeDef.Height != 0 {
+		height = nodeDef.Height
+	}
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid size %dx%d", width, height)
+	}
+
+	node, err := nodeType.New(ctx, width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	format := def.Format
+	if nodeDef.Format != "" {
+		format = nodeDef.Format
+	}
+	if format != "" {
+		f, err := fxcore.FXParseTextureFormat(format)
+		if err == nil {
+			err = node.SetFormat(f)
+		}
+		if err != nil {
+			node.Release()
+			return nil, err
+		}
+	}
+
+	// Apply parameters in name order so errors are reported deterministically.
+	params := make([]string, 0, len(nodeDef.Params))
+	for name := range nodeDef.Params {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	for _, name := range params {
+		if err := nodeType.SetParam(node, name, nodeDef.Params[name]); err != nil {
+			node.Release()
+			return nil, err
+		}
+	}
+
+	if t := nodeDef.Transform; t != nil {
+		if t.Position != nil {
+			if len(t.Position) != 2 {
+				node.Release()
+				return nil, fmt.Errorf("transform position must have 2 components")
+			}
+			node.SetPosition(t.Position[0], t.Position[1])
+		}
+		if t.Size != nil {
+			if len(t.Size) != 2 {
+				node.Release()
+				return nil, fmt.Errorf("transform size must have 2 components")
+			}
+			node.SetSize(t.Size[0], t.Size[1])
+		}
+		node.SetRotation(t.Rotation)
+	}
+
+	return node, nil
+}
+
+// FXLoadGraph reads a graph file and instantiates it.
+// Input paths in the file are resolved relative to the file. The definition is returned
+// alongside the graph so the caller can find the output node.
+func FXLoadGraph(ctx fxcontext.FXContext, path string, inputs map[string]fxnode.FXInput) (fxnode.FXGraph, *FXGraphDef, error) {
+	def, err := FXReadGraphFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	graph, err := FXBuildGraph(ctx, def, filepath.Dir(path), inputs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return graph, def, nil
+}
+
+// FXDescribeGraph creates a definition from an existing graph.
+// Every node must be of a registered type. Image inputs loaded from files keep their path;
+// other inputs are written without a path and must be provided when the graph is built.
+func FXDescribeGraph(graph fxnode.FXGraph, output string) (*FXGraphDef, error) {
+	def := &FXGraphDef{
+		Version: FXPresetVersion,
+		Output:  output,
+	}
+
+	nodeNames := graph.GetNodeNames()
+	if len(nodeNames) == 0 {
+		return nil, fmt.Errorf("graph has no nodes")
+	}
+
+	// Use the size of the output node (or the first node) as the graph size.
+	sizeNode := nodeNames[0]
+	if output != "" {
+		if graph.GetNode(output) == nil {
+			return nil, fmt.Errorf("output node %s not found", output)
+		}
+		sizeNode = output
+	}
+	def.Width, def.Height = graph.GetNode(sizeNode).GetTexture().GetSize()
+	// Likewise for the storage format, which is omitted when it is the default.
+	format := graph.GetNode(sizeNode).GetFormat()
+	if format != fxcore.FXTextureRGBA8 {
+		def.Format = format.String()
+	}
+
+	for _, name := range graph.GetInputNames() {
+		inDef := FXInputDef{Name: name}
+		if image, ok := graph.GetInput(name).(*fximage.FXImageInput); ok {
+			inDef.Path = image.Path
+		}
+		def.Inputs = append(def.Inputs, inDef)
+	}
+
+	for _, name := range nodeNames {
+		node := graph.GetNode(name)
+		nodeType, ok := fxnode.FXNodeTypeOf(node)
+		if !ok {
+			return nil, fmt.Errorf("node %s has unregistered type %T", name, node)
+		}
+
+		nodeDef := FXNodeDef{
+			Name: name,
+			Type: nodeType.Name,
+		}
+		if w, h := node.GetTexture().GetSize(); w != def.Width || h != def.Height {
+			nodeDef.Width, nodeDef.Height = w, h
+		}
+		if f := node.GetFormat(); f != format {
+			nodeDef.Format = f.String()
+		}
+		if len(nodeType.Params) > 0 {
+			nodeDef.Params = nodeType.GetParams(node)
+			// Write enumerated values by name so the file stays readable.
+			for _, param := range nodeType.Params {
+				if name := param.OptionName(nodeDef.Params[param.Name]); name != "" {
+					nodeDef.Params[param.Name] = name
+				}
+			}
+		}
+		nodeDef.Transform = describeTransform(node)
+		def.Nodes = append(def.Nodes, nodeDef)
+	}
+
+	for _, conn := range graph.GetConnections() {
+		def.Connections = append(def.Connections, FXConnectionDef{
+			Source: conn.Source,
+			Target: conn.Target,
+			Slot:   conn.Slot,
+		})
+	}
+
+	return def, nil
+}
+
+// describeTransform returns the transform of a node, or nil if it is the identity.
+func describeTransform(node fxnode.FXNode) *FXTransformDef {
+	t := &FXTransformDef{}
+	identity := true
+	if x, y := node.GetPosition(); x != 0 || y != 0 {
+		t.Position = []float32{x, y}
+		identity = false
+	}
+	if w, h := node.GetSize(); w != 1 || h != 1 {
+		t.Size = []float32{w, h}
+		identity = false
+	}
+	if r := node.GetRotation(); r != 0 {
+		t.Rotation = r
+		identity = false
+	}
+	if identity {
+		return nil
+	}
+	return t
+}
+
+// FXSaveGraph writes the definition of a graph to a .json, .yaml or .yml file.
+// Input paths are written relative to the file where possible.
+func FXSaveGraph(path string, graph fxnode.FXGraph, output string) error {
+	def, err := FXDescribeGraph(graph, output)
+	if err != nil {
+		return err
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	for i, in := range def.Inputs {
+		if in.Path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(in.Path)
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(dir, abs); err == nil {
+			def.Inputs[i].Path = filepath.ToSlash(rel)
+		}
+	}
+
+	return FXWriteGraphFile(path, def)
+}
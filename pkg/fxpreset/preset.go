@@ -0,0 +1,188 @@
+// Package fxpreset reads and writes declarative graph definitions (presets) in JSON or YAML
+// and instantiates them as FXGraphs using the registered node types.
+package fxpreset
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FXPresetVersion is the version of the graph file format written by this package.
+const FXPresetVersion = 1
+
+// FXFormat is the encoding of a graph file.
+type FXFormat int
+
+const (
+	FXFormatJSON FXFormat = iota // JSON encoding.
+	FXFormatYAML                 // YAML encoding.
+)
+
+// FXGraphDef is the serializable definition of a graph.
+//
+// A minimal JSON definition looks like:
+//
+//	{
+//	  "version": 1,
+//	  "width": 512, "height": 512,
+//	  "inputs": [{"name": "image", "path": "input.png"}],
+//	  "nodes": [{"name": "blur", "type": "gaussianBlur", "params": {"radius": 10}}],
+//	  "connections": [{"source": "image", "target": "blur", "slot": "u_texture"}],
+//	  "output": "blur"
+//	}
+type FXGraphDef struct {
+	// Version is the file format version. Zero is treated as the current version.
+	Version int `json:"version" yaml:"version"`
+	// Width is the default width of the node outputs in pixels.
+	Width int `json:"width" yaml:"width"`
+	// Height is the default height of the node outputs in pixels.
+	Height int `json:"height" yaml:"height"`
+	// Format is the default storage format of the node outputs: "rgba8" (the default),
+	// "rgba16f" or "rgba32f".
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// Inputs are the external inputs of the graph.
+	Inputs []FXInputDef `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+	// Nodes are the processing nodes of the graph.
+	Nodes []FXNodeDef `json:"nodes" yaml:"nodes"`
+	// Connections connect inputs and nodes to node input slots.
+	Connections []FXConnectionDef `json:"connections,omitempty" yaml:"connections,omitempty"`
+	// Output is the name of the node that produces the final result.
+	Output string `json:"output,omitempty" yaml:"output,omitempty"`
+}
+
+// FXInputDef describes an external input of a graph.
+type FXInputDef struct {
+	// Name is the name used to reference the input in connections.
+	Name string `json:"name" yaml:"name"`
+	// Path is the image file to load. It is resolved relative to the graph file.
+	// It can be empty if the input is always provided by the caller.
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+}
+
+// FXNodeDef describes a node of a graph.
+type FXNodeDef struct {
+	// Name is the unique name of the node in the graph.
+	Name string `json:"name" yaml:"name"`
+	// Type is the registered node type name, e.g. "gaussianBlur".
+	Type string `json:"type" yaml:"type"`
+	// Width overrides the graph width for this node if non-zero.
+	Width int `json:"width,omitempty" yaml:"width,omitempty"`
+	// Height overrides the graph height for this node if non-zero.
+	Height int `json:"height,omitempty" yaml:"height,omitempty"`
+	// Format overrides the graph storage format for this node if not empty.
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// Params maps parameter names to values.
+	// Values are numbers, booleans or lists of numbers depending on the parameter kind.
+	// Enumerated parameters, such as blend modes, can also be given by option name.
+	Params map[string]interface{} `json:"params,omitempty" yaml:"params,omitempty"`
+	// Transform is the optional quad transformation of the node.
+	Transform *FXTransformDef `json:"transform,omitempty" yaml:"transform,omitempty"`
+}
+
+// FXTransformDef describes the transformation of a node's quad.
+// Omitted fields keep their defaults.
+type FXTransformDef struct {
+	// Position is the [x, y] position in normalized coordinates (-1 to 1).
+	Position []float32 `json:"position,omitempty" yaml:"position,omitempty,flow"`
+	// Size is the [w, h] scale factor.
+	Size []float32 `json:"size,omitempty" yaml:"size,omitempty,flow"`
+	// Rotation is the rotation in radians.
+	Rotation float32 `json:"rotation,omitempty" yaml:"rotation,omitempty"`
+}
+
+// FXConnectionDef describes a connection in a graph.
+type FXConnectionDef struct {
+	// Source is the name of the node or input providing the texture.
+	Source string `json:"source" yaml:"source"`
+	// Target is the name of the node receiving the texture.
+	Target string `json:"target" yaml:"target"`
+	// Slot is the input slot on the target node, e.g. "u_texture".
+	Slot string `json:"slot" yaml:"slot"`
+}
+
+// FXFormatFromPath returns the format matching the extension of a file path.
+// ".json" is JSON; ".yaml" and ".yml" are YAML.
+func FXFormatFromPath(path string) (FXFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FXFormatJSON, nil
+	case ".yaml", ".yml":
+		return FXFormatYAML, nil
+	}
+	return 0, fmt.Errorf("unsupported graph file extension: %s", path)
+}
+
+// FXDecodeGraphDef decodes a graph definition in the given format.
+func FXDecodeGraphDef(data []byte, format FXFormat) (*FXGraphDef, error) {
+	def := &FXGraphDef{}
+	var err error
+	switch format {
+	case FXFormatJSON:
+		err = json.Unmarshal(data, def)
+	case FXFormatYAML:
+		err = yaml.Unmarshal(data, def)
+	default:
+		return nil, fmt.Errorf("unknown graph format: %d", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode graph: %v", err)
+	}
+
+	if def.Version == 0 {
+		def.Version = FXPresetVersion
+	}
+	if def.Version > FXPresetVersion {
+		return nil, fmt.Errorf("unsupported graph version %d (latest supported is %d)", def.Version, FXPresetVersion)
+	}
+	return def, nil
+}
+
+// FXEncodeGraphDef encodes a graph definition in the given format.
+func FXEncodeGraphDef(def *FXGraphDef, format FXFormat) ([]byte, error) {
+	switch format {
+	case FXFormatJSON:
+		data, err := json.MarshalIndent(def, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case FXFormatYAML:
+		return yaml.Marshal(def)
+	}
+	return nil, fmt.Errorf("unknown graph format: %d", format)
+}
+
+// FXReadGraphFile reads a graph definition from a .json, .yaml or .yml file.
+func FXReadGraphFile(path string) (*FXGraphDef, error) {
+	format, err := FXFormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	def, err := FXDecodeGraphDef(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return def, nil
+}
+
+// FXWriteGraphFile writes a graph definition to a .json, .yaml or .yml file.
+func FXWriteGraphFile(path string, def *FXGraphDef) error {
+	format, err := FXFormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	data, err := FXEncodeGraphDef(def, format)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,243 @@
+// Package fxpreview provides an interactive preview loop that displays node output in a window.
+package fxpreview
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-gl/gl/v3.1/gles2"
+
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxcore"
+	"kdfx/pkg/fxnode"
+)
+
+// FXPreviewFS is the fragment shader used to blit a node texture to the window.
+const FXPreviewFS = `
+precision mediump float;
+varying vec2 v_texCoord;
+uniform sampler2D u_texture;
+
+void main() {
+	gl_FragColor = texture2D(u_texture, v_texCoord);
+}
+`
+
+// FXPreview repeatedly processes a node and displays its texture in a window.
+//
+// Built-in keys:
+//   - Space pauses and resumes playback.
+//   - Right arrow steps a single frame while paused.
+//   - Tab switches to the next registered node.
+//   - Escape closes the window.
+type FXPreview interface {
+	// AddNode registers a node that can be displayed.
+	// The first registered node is displayed initially; Tab cycles through them in order.
+	AddNode(name string, node fxnode.FXNode)
+	// SetUpdate sets the function called before each frame to update the scene.
+	// It receives the current preview time, which advances by one frame per rendered frame.
+	SetUpdate(update func(t time.Duration))
+	// SetKeyHandler sets a function called for every key press after the built-in handling.
+	// This is where uniforms can be tweaked live.
+	SetKeyHandler(handler fxcontext.FXKeyCallback)
+	// Run runs the preview loop until the window is closed or an error occurs.
+	Run() error
+	// Release frees the OpenGL resources held by the preview.
+	Release()
+}
+
+// fxPreviewEntry is a node registered for display.
+type fxPreviewEntry struct {
+	// name is the display name of the node.
+	name string
+	// node is the node to process and display.
+	node fxnode.FXNode
+}
+
+// fxPreview implements FXPreview.
+type fxPreview struct {
+	// ctx is the window context used for display.
+	ctx fxcontext.FXWindowContext
+	// fps is the preview frame rate.
+	fps int
+	// entries are the nodes that can be displayed.
+	entries []fxPreviewEntry
+	// current is the index of the displayed node.
+	current int
+	// update is the function called to update the scene at each frame.
+	update func(t time.Duration)
+	// onKey is the user key handler.
+	onKey fxcontext.FXKeyCallback
+	// paused indicates that time is not advancing.
+	paused bool
+	// step requests a single frame while paused.
+	step bool
+	// title is the last window title, to avoid resetting it every frame.
+	title string
+	// program is the shader program used for blitting.
+	program fxcore.FXShaderProgram
+	// quad is the full-screen quad used for blitting.
+	quad fxcore.FXQuad
+}
+
+// NewFXPreview creates a new preview running at the specified frame rate in the given window.
+func NewFXPreview(ctx fxcontext.FXWindowContext, fps int) (FXPreview, error) {
+	if fps <= 0 {
+		return nil, fmt.Errorf("invalid fps: %d", fps)
+	}
+
+	// Compile the blit program.
+	program, err := fxcore.NewFXShaderProgram(fxcore.FXSimpleVS, FXPreviewFS)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &fxPreview{
+		ctx:     ctx,
+		fps:     fps,
+		program: program,
+		quad:    fxcore.NewFXQuad(),
+	}
+	ctx.SetKeyCallback(p.handleKey)
+	return p, nil
+}
+
+func (p *fxPreview) AddNode(name string, node fxnode.FXNode) {
+	p.entries = append(p.entries, fxPreviewEntry{name: name, node: node})
+}
+
+func (p *fxPreview) SetUpdate(update func(t time.Duration)) {
+	p.update = update
+}
+
+func (p *fxPreview) SetKeyHandler(handler fxcontext.FXKeyCallback) {
+	p.onKey = handler
+}
+
+// handleKey implements the built-in key bindings and forwards keys to the user handler.
+func (p *fxPreview) handleKey(key fxcontext.FXKey) {
+	switch key {
+	case fxcontext.FXKeySpace:
+		p.paused = !p.paused
+	case fxcontext.FXKeyRight:
+		if p.paused {
+			p.step = true
+		}
+	case fxcontext.FXKeyTab:
+		if len(p.entries) > 0 {
+			p.current = (p.current + 1) % len(p.entries)
+		}
+	case fxcontext.FXKeyEscape:
+		p.ctx.SetShouldClose(true)
+	}
+
+	if p.onKey != nil {
+		p.onKey(key)
+	}
+}
+
+// Run runs the preview loop until the window is closed or an error occurs.
+func (p *fxPreview) Run() error {
+	if len(p.entries) == 0 {
+		return fmt.Errorf("no nodes to preview")
+	}
+
+	dt := time.Second / time.Duration(p.fps)
+	var currentTime time.Duration
+	first := true
+
+	for !p.ctx.ShouldClose() {
+		frameStart := time.Now()
+		p.ctx.PollEvents()
+
+		// Advance time unless paused. The first frame is always rendered at t=0.
+		advance := !p.paused || p.step
+		if first || advance {
+			if !first {
+				currentTime += dt
+			}
+			if p.update != nil {
+				p.update(currentTime)
+			}
+			first = false
+			p.step = false
+		}
+
+		entry := p.entries[p.current]
+		p.updateTitle(entry.name, currentTime)
+
+		// Process the graph
+		// Nodes skip rendering when nothing is dirty, so a paused preview is cheap.
+		if err := entry.node.Process(p.ctx); err != nil {
+			return fmt.Errorf("failed to process node %s: %w", entry.name, err)
+		}
+
+		// Display the node texture.
+		p.blit(entry.node.GetTexture())
+		p.ctx.SwapBuffers()
+
+		// Pace the loop to the preview frame rate.
+		if elapsed := time.Since(frameStart); elapsed < dt {
+			time.Sleep(dt - elapsed)
+		}
+	}
+
+	return nil
+}
+
+// updateTitle shows the displayed node, time and playback state in the window title.
+func (p *fxPreview) updateTitle(name string, t time.Duration) {
+	state := "playing"
+	if p.paused {
+		state = "paused"
+	}
+	title := fmt.Sprintf("kdfx preview - %s - %v (%s)", name, t.Round(time.Millisecond), state)
+	if title != p.title {
+		p.ctx.SetTitle(title)
+		p.title = title
+	}
+}
+
+// blit draws the texture to the window, preserving its aspect ratio.
+func (p *fxPreview) blit(tex fxcore.FXTexture) {
+	// Render to the default framebuffer (the window).
+	gles2.BindFramebuffer(gles2.FRAMEBUFFER, 0)
+	winW, winH := p.ctx.GetSize()
+	p.ctx.Viewport(0, 0, winW, winH)
+	gles2.ClearColor(0.1, 0.1, 0.1, 1.0)
+	gles2.Clear(gles2.COLOR_BUFFER_BIT)
+
+	if tex == nil || winW == 0 || winH == 0 {
+		return
+	}
+
+	// Letterbox the texture into the window.
+	texW, texH := tex.GetSize()
+	scaleX, scaleY := float32(1.0), float32(1.0)
+	if texW > 0 && texH > 0 {
+		texAspect := float32(texW) / float32(texH)
+		winAspect := float32(winW) / float32(winH)
+		if winAspect > texAspect {
+			scaleX = texAspect / winAspect
+		} else {
+			scaleY = winAspect / texAspect
+		}
+	}
+
+	p.program.Use()
+	tex.BindToUnit(0)
+	p.program.SetUniform1i("u_texture", 0)
+	p.program.SetUniform2f("u_translation", 0.0, 0.0)
+	p.program.SetUniform2f("u_scale", scaleX, scaleY)
+	p.program.SetUniform1f("u_rotation", 0.0)
+
+	posLoc := p.program.GetAttribLocation("a_position")
+	texLoc := p.program.GetAttribLocation("a_texCoord")
+	p.quad.Draw(posLoc, texLoc)
+}
+
+func (p *fxPreview) Release() {
+	p.ctx.SetKeyCallback(nil)
+	p.quad.Release()
+	p.program.Release()
+}
@@ -1,18 +1,70 @@
 package fxvideo
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"time"
 
+	"kdfx/pkg/fxanim"
 	"kdfx/pkg/fxcontext"
 	"kdfx/pkg/fxnode"
 )
 
+// FXRenderProgress reports the progress of a render.
+type FXRenderProgress struct {
+	// Frame is the number of frames rendered so far.
+	Frame int
+	// FrameCount is the total number of frames.
+	FrameCount int
+	// Elapsed is the time since the render started.
+	Elapsed time.Duration
+	// ETA is the estimated time until the render finishes, based on the average speed so far.
+	ETA time.Duration
+	// FPS is the average number of frames rendered per second.
+	FPS float64
+}
+
+// newFXRenderProgress computes the progress after frame frames of frameCount took elapsed.
+func newFXRenderProgress(frame, frameCount int, elapsed time.Duration) FXRenderProgress {
+	p := FXRenderProgress{
+		Frame:      frame,
+		FrameCount: frameCount,
+		Elapsed:    elapsed,
+	}
+	if frame > 0 && elapsed > 0 {
+		p.FPS = float64(frame) / elapsed.Seconds()
+		p.ETA = time.Duration(float64(elapsed) / float64(frame) * float64(frameCount-frame))
+	}
+	return p
+}
+
 // FXAnimation defines the interface for an fxAnimation.
 type FXAnimation interface {
+	// AddTrack adds a keyframe track that is applied at the time of every frame,
+	// before the update function is called.
+	AddTrack(track fxanim.FXTrack)
+	// SetAudio sets the audio to mux into the rendered video, or nil for no audio.
+	// Without a duration, the audio is padded or cut to the length of the animation.
+	// It takes precedence over the audio of the encoder options.
+	SetAudio(audio *FXAudioSource)
+	// SetEncoderOptions sets the codec, quality and container of the rendered video.
+	// The default is FXDefaultEncoderOptions.
+	SetEncoderOptions(options FXEncoderOptions)
+	// SetEncoderFactory sets a factory for the encoder, such as FXGIFEncoderFactory,
+	// to render without ffmpeg. The encoder options and audio are then not used.
+	// If factory is nil, ffmpeg is used with the encoder options.
+	SetEncoderFactory(factory FXEncoderFactory)
+	// SetProgress sets a function that is called after every rendered frame, or nil for none.
+	// It is called on the rendering thread, so it should return quickly; to report progress
+	// to another goroutine, send it on a channel without blocking.
+	SetProgress(progress func(FXRenderProgress))
 	// Render renders the fxAnimation to the provided writer using the specified node as output.
 	Render(ctx fxcontext.FXContext, node fxnode.FXNode, writer io.Writer) error
+	// RenderContext renders like Render, but stops when cancel is done. Encoders that support it,
+	// such as ffmpeg, are aborted immediately, even if they are stuck, and the output is incomplete.
+	// The returned error then wraps cancel.Err().
+	RenderContext(cancel context.Context, ctx fxcontext.FXContext, node fxnode.FXNode, writer io.Writer) error
 }
 
 // fxAnimation implements FXAnimation.
@@ -23,6 +75,16 @@ type fxAnimation struct {
 	fps int
 	// update is the function called to update the scene at each frame.
 	update func(t time.Duration)
+	// tracks are the keyframe tracks applied at each frame.
+	tracks []fxanim.FXTrack
+	// audio is the audio muxed into the output, or nil.
+	audio *FXAudioSource
+	// options are the encoder options.
+	options FXEncoderOptions
+	// factory creates the encoder instead of ffmpeg, or is nil.
+	factory FXEncoderFactory
+	// progress is called after every frame, or is nil.
+	progress func(FXRenderProgress)
 }
 
 // NewFXAnimation creates a new fxAnimation.
@@ -31,29 +93,104 @@ func NewFXAnimation(duration time.Duration, fps int, update func(t time.Duration
 		duration: duration,
 		fps:      fps,
 		update:   update,
+		options:  FXDefaultEncoderOptions(),
 	}
 }
 
+func (a *fxAnimation) AddTrack(track fxanim.FXTrack) {
+	a.tracks = append(a.tracks, track)
+}
+
+func (a *fxAnimation) SetAudio(audio *FXAudioSource) {
+	a.audio = audio
+}
+
+func (a *fxAnimation) SetEncoderOptions(options FXEncoderOptions) {
+	a.options = options
+}
+
+func (a *fxAnimation) SetEncoderFactory(factory FXEncoderFactory) {
+	a.factory = factory
+}
+
+func (a *fxAnimation) SetProgress(progress func(FXRenderProgress)) {
+	a.progress = progress
+}
+
 // Render renders the fxAnimation to the provided writer using the specified node as output.
 func (a *fxAnimation) Render(ctx fxcontext.FXContext, node fxnode.FXNode, writer io.Writer) error {
+	return a.RenderContext(context.Background(), ctx, node, writer)
+}
+
+// RenderContext renders like Render, but stops when cancel is done.
+func (a *fxAnimation) RenderContext(cancel context.Context, ctx fxcontext.FXContext, node fxnode.FXNode, writer io.Writer) error {
 	width, height := ctx.GetSize()
 
 	// Initialize the video encoder.
-	encoder, err := NewFXMP4StreamEncoder(writer, width, height, a.fps)
+	var encoder FXStreamEncoder
+	var err error
+	if a.factory != nil {
+		if a.audio != nil {
+			return fmt.Errorf("audio requires the ffmpeg encoder")
+		}
+		encoder, err = a.factory(writer, width, height, a.fps)
+	} else {
+		options := a.options
+		if a.audio != nil {
+			options.Audio = a.audio
+		}
+		encoder, err = NewFXStreamEncoder(writer, width, height, a.fps, options)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create encoder: %w", err)
 	}
-	defer encoder.Close()
+
+	// Abort the encoder as soon as the context is done, even while the render loop is blocked
+	// writing to an encoder that is stuck. Killing ffmpeg makes the pending writes fail.
+	// closeEncoder waits for a running abort, so it does not race with Close.
+	closeEncoder := encoder.Close
+	if abortable, ok := encoder.(FXAbortableStreamEncoder); ok {
+		aborted := make(chan struct{})
+		stop := context.AfterFunc(cancel, func() {
+			abortable.Abort()
+			close(aborted)
+		})
+		closeEncoder = func() error {
+			if !stop() {
+				<-aborted
+			}
+			return encoder.Close()
+		}
+	}
 
 	// Calculate total frames and time step per frame.
 	frameCount := int(a.duration.Seconds() * float64(a.fps))
 	dt := time.Second / time.Duration(a.fps)
 
+	// Read frames back asynchronously, so rendering the next frame overlaps
+	// the download and encoding of the previous ones.
+	frames := newFXFrameWriter(encoder, width, height, fxReadbackDepth)
+	// abort stops encoding after an error.
+	abort := func(err error) error {
+		frames.Close()
+		closeEncoder()
+		return err
+	}
+	start := time.Now()
+
 	for i := 0; i < frameCount; i++ {
+		// Stop if the render was cancelled. The encoder is already aborted.
+		if err := cancel.Err(); err != nil {
+			return abort(fmt.Errorf("render cancelled at frame %d: %w", i, err))
+		}
+
 		currentTime := time.Duration(i) * dt
 
 		// Update scene state
-		// Call the user-provided update function to animate parameters.
+		// Apply the keyframe tracks, then call the user-provided update function to animate parameters.
+		for _, track := range a.tracks {
+			track.Apply(currentTime)
+		}
 		if a.update != nil {
 			a.update(currentTime)
 		}
@@ -61,28 +198,39 @@ func (a *fxAnimation) Render(ctx fxcontext.FXContext, node fxnode.FXNode, writer
 		// Process the graph
 		// Render the current frame.
 		if err := node.Process(ctx); err != nil {
-			return fmt.Errorf("failed to process frame %d: %w", i, err)
+			return abort(fmt.Errorf("failed to process frame %d: %w", i, err))
 		}
 
-		// Download the result
+		// Read back the result
 		// Get the texture from the output node.
 		tex := node.GetTexture()
 		if tex == nil {
-			return fmt.Errorf("node returned nil texture at frame %d", i)
+			return abort(fmt.Errorf("node returned nil texture at frame %d", i))
 		}
 
-		// Download the texture to CPU memory.
-		img, err := tex.Download()
-		if err != nil {
-			return fmt.Errorf("failed to download texture at frame %d: %w", i, err)
+		// Queue the texture for download and encoding.
+		if err := frames.Write(tex); err != nil {
+			// Writes fail once the encoder is aborted; report the cancellation instead.
+			if cancelErr := cancel.Err(); cancelErr != nil {
+				err = fmt.Errorf("render cancelled at frame %d: %w", i, cancelErr)
+			}
+			return abort(err)
 		}
 
-		// Add frame to encoder
-		// Encode the frame into the video stream.
-		if err := encoder.AddFrame(img); err != nil {
-			return fmt.Errorf("failed to add frame %d to encoder: %w", i, err)
+		// Report progress
+		if a.progress != nil {
+			a.progress(newFXRenderProgress(i+1, frameCount, time.Since(start)))
 		}
 	}
 
-	return nil
+	// Encode the frames still in flight, then finish the file. Encoders such as GIF
+	// write the whole file on Close, so its error must not be lost.
+	err = frames.Close()
+	if closeErr := closeEncoder(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to finish encoding: %w", closeErr)
+	}
+	if cancelErr := cancel.Err(); err != nil && cancelErr != nil {
+		return fmt.Errorf("render cancelled while finishing: %w", cancelErr)
+	}
+	return err
 }
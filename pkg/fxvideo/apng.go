@@ -0,0 +1,320 @@
+package fxvideo
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"io"
+	"sync/atomic"
+)
+
+// fxPNGSignature is the 8-byte signature at the start of every PNG file.
+var fxPNGSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// FXAPNGOptions configures the APNG encoder.
+type FXAPNGOptions struct {
+	// LoopCount is the number of times the animation plays; 0 loops forever.
+	LoopCount int
+	// CompressionLevel is the zlib compression level, from zlib.BestSpeed to zlib.BestCompression.
+	// Zero means zlib.DefaultCompression.
+	CompressionLevel int
+}
+
+// fxAPNGStreamEncoder implements FXStreamEncoder for animated PNG output.
+// Frames are compressed as they are added and the file is written on Close,
+// because the frame count precedes the frames in the file.
+// Frames are stored losslessly with their alpha channel.
+type fxAPNGStreamEncoder struct {
+	// writer receives the APNG file.
+	writer io.Writer
+	// width is the width of the animation.
+	width int
+	// height is the height of the animation.
+	height int
+	// fps is the frame rate of the animation.
+	fps int
+	// options are the encoder options.
+	options FXAPNGOptions
+	// frames holds the compressed image data of each frame.
+	frames [][]byte
+	// rows holds the filtered scanlines of the frame being compressed, reused between frames.
+	rows []byte
+	// unpremultiplied holds two scanlines converted to straight alpha: the current one and the one above.
+	unpremultiplied [2][]byte
+	// scratch holds the scanline filtered with each of the five filter types.
+	scratch [5][]byte
+	// closed is true after Close.
+	closed bool
+	// aborted is set by Abort; the file is then not written.
+	aborted atomic.Bool
+}
+
+// NewFXAPNGStreamEncoder creates an encoder that writes an animated PNG to the provided writer.
+// It does not need ffmpeg.
+func NewFXAPNGStreamEncoder(writer io.Writer, width, height, fps int, options FXAPNGOptions) (FXStreamEncoder, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid size %dx%d", width, height)
+	}
+	// Frame delays are stored as 1/fps in 16 bits.
+	if fps <= 0 || fps > 65535 {
+		return nil, fmt.Errorf("invalid frame rate %d", fps)
+	}
+	if options.LoopCount < 0 {
+		return nil, fmt.Errorf("loop count must not be negative: %d", options.LoopCount)
+	}
+	if options.CompressionLevel == 0 {
+		options.CompressionLevel = zlib.DefaultCompression
+	}
+	if options.CompressionLevel < zlib.HuffmanOnly || options.CompressionLevel > zlib.BestCompression {
+		return nil, fmt.Errorf("invalid compression level %d", options.CompressionLevel)
+	}
+
+	e := &fxAPNGStreamEncoder{
+		writer:  writer,
+		width:   width,
+		height:  height,
+		fps:     fps,
+		options: options,
+		rows:    make([]byte, height*(1+width*4)),
+	}
+	for i := range e.scratch {
+		e.scratch[i] = make([]byte, width*4)
+	}
+	for i := range e.unpremultiplied {
+		e.unpremultiplied[i] = make([]byte, width*4)
+	}
+	return e, nil
+}
+
+func (e *fxAPNGStreamEncoder) AddFrame(img *image.RGBA) error {
+	if e.closed {
+		return fmt.Errorf("encoder is closed")
+	}
+	if img.Rect.Dx() != e.width || img.Rect.Dy() != e.height {
+		return fmt.Errorf("frame dimension mismatch: expected %dx%d, got %dx%d", e.width, e.height, img.Rect.Dx(), img.Rect.Dy())
+	}
+
+	// 1. Filter
+	// Every scanline starts with its filter type, followed by the filtered RGBA bytes.
+	// image.RGBA is premultiplied, while PNG stores straight alpha.
+	stride := e.width * 4
+	var prev []byte
+	for y := 0; y < e.height; y++ {
+		offset := img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+y)
+		cur := e.unpremultiplied[y%2]
+		unpremultiply(cur, img.Pix[offset:offset+stride])
+		filterRow(e.rows[y*(1+stride):(y+1)*(1+stride)], cur, prev, &e.scratch)
+		prev = cur
+	}
+
+	// 2. Compress
+	var buf bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&buf, e.options.CompressionLevel)
+	if err != nil {
+		return fmt.Errorf("failed to create compressor: %w", err)
+	}
+	if _, err := zw.Write(e.rows); err != nil {
+		return fmt.Errorf("failed to compress frame: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress frame: %w", err)
+	}
+	e.frames = append(e.frames, buf.Bytes())
+	return nil
+}
+
+// Abort discards the frames, so Close writes nothing.
+func (e *fxAPNGStreamEncoder) Abort() error {
+	e.aborted.Store(true)
+	return nil
+}
+
+func (e *fxAPNGStreamEncoder) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	if e.aborted.Load() {
+		return nil
+	}
+	if len(e.frames) == 0 {
+		return fmt.Errorf("no frames to encode")
+	}
+	if err := e.writeFile(); err != nil {
+		return fmt.Errorf("failed to write APNG: %w", err)
+	}
+	return nil
+}
+
+// writeFile writes the signature, the header, the animation control chunk and all frames.
+func (e *fxAPNGStreamEncoder) writeFile() error {
+	if _, err := e.writer.Write(fxPNGSignature); err != nil {
+		return err
+	}
+
+	// IHDR: width, height, 8 bits per channel, color type 6 (RGBA), deflate, adaptive filtering, no interlace.
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(e.width))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(e.height))
+	ihdr[8], ihdr[9] = 8, 6
+	if err := writeChunk(e.writer, "IHDR", ihdr); err != nil {
+		return err
+	}
+
+	// acTL: number of frames and number of plays, where 0 loops forever.
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(e.frames)))
+	binary.BigEndian.PutUint32(actl[4:], uint32(e.options.LoopCount))
+	if err := writeChunk(e.writer, "acTL", actl); err != nil {
+		return err
+	}
+
+	// The fcTL and fdAT chunks share one sequence number counter.
+	seq := uint32(0)
+	for i, data := range e.frames {
+		// fcTL: the frame covers the whole canvas, lasts 1/fps seconds, is not disposed
+		// and replaces the previous frame, including its alpha.
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], seq)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(e.width))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(e.height))
+		binary.BigEndian.PutUint16(fctl[20:], 1)
+		binary.BigEndian.PutUint16(fctl[22:], uint16(e.fps))
+		seq++
+		if err := writeChunk(e.writer, "fcTL", fctl); err != nil {
+			return err
+		}
+
+		// The first frame is the default image, which viewers without APNG support show.
+		if i == 0 {
+			if err := writeChunk(e.writer, "IDAT", data); err != nil {
+				return err
+			}
+			continue
+		}
+		fdat := make([]byte, 4+len(data))
+		binary.BigEndian.PutUint32(fdat, seq)
+		copy(fdat[4:], data)
+		seq++
+		if err := writeChunk(e.writer, "fdAT", fdat); err != nil {
+			return err
+		}
+	}
+
+	return writeChunk(e.writer, "IEND", nil)
+}
+
+// unpremultiply converts a scanline of premultiplied RGBA pixels in src to straight alpha in dst,
+// rounding like color.NRGBAModel.
+func unpremultiply(dst, src []byte) {
+	for i := 0; i < len(src); i += 4 {
+		a := uint32(src[i+3])
+		switch a {
+		case 0xff:
+			copy(dst[i:i+4], src[i:i+4])
+		case 0:
+			dst[i], dst[i+1], dst[i+2], dst[i+3] = 0, 0, 0, 0
+		default:
+			// Work in 16 bits, as color.NRGBAModel does, so the result matches image/png.
+			a16 := a * 0x101
+			// Shaders can output colors brighter than their alpha, so clamp.
+			for c := 0; c < 3; c++ {
+				dst[i+c] = uint8(min(uint32(src[i+c])*0x101*0xffff/a16, 0xffff) >> 8)
+			}
+			dst[i+3] = uint8(a)
+		}
+	}
+}
+
+// writeChunk writes a PNG chunk: the data length, the type, the data and the CRC of type and data.
+func writeChunk(w io.Writer, kind string, data []byte) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(data)))
+	copy(header[4:], kind)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.BigEndian, crc.Sum32())
+}
+
+// filterRow writes the filter type and filtered bytes of the scanline cur to dst, using prev as
+// the scanline above (nil for the first) and scratch to hold the candidates. Like the standard
+// library, it picks the filter with the smallest sum of absolute values, which usually compresses best.
+func filterRow(dst, cur, prev []byte, scratch *[5][]byte) {
+	const bpp = 4
+	best, bestSum := 0, -1
+
+	for filter := 0; filter < 5; filter++ {
+		// Up, Average and Paeth predict from the previous row, so they do not help on the first.
+		if prev == nil && (filter == 2 || filter == 3 || filter == 4) {
+			continue
+		}
+		out := scratch[filter]
+		sum := 0
+		for i := range cur {
+			var a, b, c byte
+			if i >= bpp {
+				a = cur[i-bpp]
+			}
+			if prev != nil {
+				b = prev[i]
+				if i >= bpp {
+					c = prev[i-bpp]
+				}
+			}
+			var v byte
+			switch filter {
+			case 0:
+				v = cur[i]
+			case 1:
+				v = cur[i] - a
+			case 2:
+				v = cur[i] - b
+			case 3:
+				v = cur[i] - byte((int(a)+int(b))/2)
+			case 4:
+				v = cur[i] - paeth(a, b, c)
+			}
+			out[i] = v
+			// Treat the bytes as signed, so small negative differences count as small.
+			sum += abs(int(int8(v)))
+		}
+		if bestSum < 0 || sum < bestSum {
+			best, bestSum = filter, sum
+		}
+	}
+
+	dst[0] = byte(best)
+	copy(dst[1:], scratch[best])
+}
+
+// paeth returns whichever of a (left), b (above) and c (upper left) is closest to a+b-c.
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+// abs returns the absolute value of x.
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,109 @@
+package fxvideo
+
+import (
+	"fmt"
+	"time"
+)
+
+// FXAudioSource describes audio to mux into an encoded video.
+// The audio can come from the video being processed or from a separate audio file.
+// Audio is taken linearly from Start; it does not follow looping or stretching of video inputs.
+type FXAudioSource struct {
+	// Path is the file to take the audio from.
+	Path string
+	// Streams are the indexes of the audio streams to take, counting audio streams only.
+	// If empty, all audio streams are taken, and a file without audio is not an error.
+	Streams []int
+	// Start is the position in the source where the audio starts (trims the beginning).
+	Start time.Duration
+	// Delay is the position in the output where the audio starts.
+	Delay time.Duration
+	// Duration is the length of audio to take from Start. If zero, the audio is
+	// padded with silence or cut to end with the video.
+	Duration time.Duration
+	// Codec is the ffmpeg audio codec, such as "aac", "libopus" or "copy". If empty,
+	// "libopus" is used for WebM and "aac" otherwise.
+	// With "copy", the audio cannot be padded and may end before the video.
+	Codec string
+	// Bitrate is the audio bitrate, such as "192k". If empty, the codec default is used.
+	Bitrate string
+}
+
+// Validate checks that the audio source is usable.
+func (a *FXAudioSource) Validate() error {
+	if a.Path == "" {
+		return fmt.Errorf("audio source has no path")
+	}
+	if a.Start < 0 {
+		return fmt.Errorf("audio start must not be negative: %v", a.Start)
+	}
+	if a.Delay < 0 {
+		return fmt.Errorf("audio delay must not be negative: %v", a.Delay)
+	}
+	if a.Duration < 0 {
+		return fmt.Errorf("audio duration must not be negative: %v", a.Duration)
+	}
+	for _, stream := range a.Streams {
+		if stream < 0 {
+			return fmt.Errorf("invalid audio stream index %d", stream)
+		}
+	}
+	return nil
+}
+
+// inputArgs returns the ffmpeg arguments that add the audio source as an input.
+// They must follow the video input.
+func (a *FXAudioSource) inputArgs() []string {
+	// Options before -i apply to that input:
+	// -ss: Seek the source to Start. Seeking in audio is sample accurate.
+	// -t: Read at most Duration from Start.
+	// -itsoffset: Shift the audio timestamps by Delay.
+	var args []string
+	if a.Start > 0 {
+		args = append(args, "-ss", formatSeconds(a.Start))
+	}
+	if a.Duration > 0 {
+		args = append(args, "-t", formatSeconds(a.Duration))
+	}
+	if a.Delay > 0 {
+		args = append(args, "-itsoffset", formatSeconds(a.Delay))
+	}
+	return append(args, "-i", a.Path)
+}
+
+// outputArgs returns the ffmpeg arguments that map and encode the audio of input index input,
+// using defaultCodec if the source has no codec. They must follow the video codec options.
+func (a *FXAudioSource) outputArgs(input int, defaultCodec string) []string {
+	var args []string
+	if len(a.Streams) == 0 {
+		// The "?" makes the mapping optional, for sources without audio.
+		args = append(args, "-map", fmt.Sprintf("%d:a?", input))
+	}
+	for _, stream := range a.Streams {
+		args = append(args, "-map", fmt.Sprintf("%d:a:%d", input, stream))
+	}
+
+	codec := a.Codec
+	if codec == "" {
+		codec = defaultCodec
+	}
+	args = append(args, "-c:a", codec)
+	if a.Bitrate != "" && codec != "copy" {
+		args = append(args, "-b:a", a.Bitrate)
+	}
+
+	// Without a duration, pad the audio with silence and stop at the end of the video,
+	// so the output is exactly as long as the rendered frames.
+	if a.Duration == 0 {
+		if codec != "copy" {
+			args = append(args, "-af", "apad")
+		}
+		args = append(args, "-shortest")
+	}
+	return args
+}
+
+// formatSeconds formats a duration as seconds for ffmpeg.
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.6f", d.Seconds())
+}
@@ -2,6 +2,7 @@
 package fxvideo
 
 import (
+	"errors"
 	"fmt"
 	"image"
 	"io"
@@ -11,13 +12,21 @@ import (
 
 // FXStreamDecoder decodes a video stream.
 type FXStreamDecoder interface {
-	// Seek seeks to the specified timestamp.
-	// If t < current, restarts ffmpeg.
-	// If t > current, reads and discards frames.
+	// Seek seeks to the frame displayed at the specified time, relative to the start of the video.
+	// It is equivalent to SeekFrame(Info().FrameIndex(t)).
 	Seek(t time.Duration) error
+	// SeekFrame seeks so that the next ReadFrame returns frame n (counting from 0).
+	// The ffmpeg decoder restarts ffmpeg at the frame if n is before the next frame or far ahead,
+	// and reads and discards frames if n is a short distance ahead.
+	SeekFrame(n int) error
+	// FrameAt reads frame n into the provided image buffer.
+	// It is equivalent to SeekFrame(n) followed by ReadFrame(img).
+	FrameAt(n int, img *image.RGBA) error
 	// ReadFrame reads the next frame into the provided image buffer.
 	// The image buffer must match the video resolution.
 	ReadFrame(img *image.RGBA) error
+	// FrameIndex returns the index of the frame the next ReadFrame returns.
+	FrameIndex() int
 	// Close stops the decoding process and releases resources.
 	Close() error
 	// Info returns metadata about the video stream.
@@ -34,13 +43,26 @@ type fxFfmpegStreamDecoder struct {
 	cmd *exec.Cmd
 	// stdout is the stdout pipe from ffmpeg.
 	stdout io.ReadCloser
-	// currentTime is the current playback time.
-	currentTime time.Duration
+	// stderr keeps the end of ffmpeg's error output.
+	stderr *fxStderrTail
+	// waited is true once ffmpeg has exited and been waited for.
+	waited bool
+	// err is the error reads return after ffmpeg exited: the end of the output, or the failure.
+	err error
+	// frame is the index of the next frame to be read.
+	frame int
+	// discard is a buffer for frames that are skipped.
+	discard []byte
 }
 
 // NewFXStreamDecoder creates a new StreamDecoder for the given video file.
+// Y4M files (.y4m) are decoded natively; other formats need ffmpeg.
 func NewFXStreamDecoder(path string) (FXStreamDecoder, error) {
-	// Probe the video to get metadata like resolution and FPS.
+	if isY4MPath(path) {
+		return NewFXY4MFileDecoder(path, FXY4MOptions{})
+	}
+
+	// Probe the video to get metadata like resolution and frame rate.
 	info, err := FXProbeVideo(path)
 	if err != nil {
 		return nil, err
@@ -59,72 +81,138 @@ func NewFXStreamDecoder(path string) (FXStreamDecoder, error) {
 	return decoder, nil
 }
 
-func (d *fxFfmpegStreamDecoder) startFFmpeg(startTime time.Duration) error {
+// fxSeekPreroll is how far before the target frame ffmpeg seeks on the input side.
+// The frames in between are decoded and dropped exactly.
+const fxSeekPreroll = 3 * time.Second
+
+func (d *fxFfmpegStreamDecoder) startFFmpeg(frame int) error {
 	// Close existing process if any.
 	if d.cmd != nil {
 		d.Close()
 	}
 
-	// Start ffmpeg at the specified time
-	// -ss before -i is faster (keyframe seeking) but less accurate.
-	// -ss after -i is slower (decoding) but accurate.
-	// For exact frame, we might need accurate seeking.
-	// Let's try -ss before -i for now, it's usually good enough for playback.
+	// Start ffmpeg at the specified frame
+	// -ss before -i jumps to a keyframe and decodes from there, so it is fast on long files;
+	// it drops frames before the time and shifts the timestamps so the time becomes 0.
+	// It seeks to a few seconds early, and -ss after -i drops the frames up to the target,
+	// which is exact. Output timestamps start at 0 for the first frame, so frame n is
+	// presented at FrameTime(n) on both sides of the coarse seek.
+	// Seeking half a frame early makes the result robust to rounding of the timestamps.
+	// -r forces a constant frame rate output on the probed grid, so frame indexes match
+	// even if the stream has irregular timestamps.
 	// We output rawvideo in RGBA format to stdout.
+	// -hide_banner and -nostats keep the error output short, so the captured tail holds the errors.
+	// -noautorotate keeps frames at their stored size; the input node applies the rotation.
 	args := []string{
-		"-ss", fmt.Sprintf("%f", startTime.Seconds()),
-		"-i", d.path,
+		"-hide_banner",
+		"-nostats",
+		"-noautorotate",
+	}
+	seek := d.info.FrameTime(frame) - d.info.FrameRate.FrameDuration()/2
+	var coarse time.Duration
+	if frame > 0 && seek > fxSeekPreroll {
+		coarse = seek - fxSeekPreroll
+		args = append(args, "-ss", fmt.Sprintf("%.6f", coarse.Seconds()))
+	}
+	args = append(args, "-i", d.path)
+	if frame > 0 {
+		args = append(args, "-ss", fmt.Sprintf("%.6f", (seek-coarse).Seconds()))
+	}
+	// Decode the probed stream; ffmpeg would otherwise pick the largest one.
+	args = append(args,
+		"-map", fmt.Sprintf("0:%d", d.info.StreamIndex),
+		"-an",
+		"-r", d.info.FrameRate.String(),
 		"-f", "rawvideo",
 		"-pix_fmt", "rgba",
 		"-",
-	}
+	)
 
 	cmd := exec.Command("ffmpeg", args...)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
+	// Keep the end of stderr to explain failures.
+	stderr := &fxStderrTail{}
+	cmd.Stderr = stderr
 
 	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start ffmpeg: %w", err)
+		d.cmd = nil
+		return newFXFFmpegError("ffmpeg", args, "", err)
 	}
 
 	d.cmd = cmd
 	d.stdout = stdout
-	d.currentTime = startTime
+	d.stderr = stderr
+	d.waited = false
+	d.err = nil
+	d.frame = frame
 	return nil
 }
 
+// readError converts an error reading frames into an FXFFmpegError if ffmpeg failed.
+// The end of the output is reported as is when ffmpeg exited successfully, so callers
+// can tell the end of the video from a failure.
+func (d *fxFfmpegStreamDecoder) readError(err error) error {
+	// Waiting closes the pipe, so later reads fail differently; repeat the first result.
+	if d.err != nil {
+		return d.err
+	}
+	if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	// ffmpeg closed its output, so it has exited or is about to.
+	d.waited = true
+	d.err = err
+	if waitErr := d.cmd.Wait(); waitErr != nil {
+		d.err = newFXFFmpegError("ffmpeg", d.cmd.Args[1:], d.stderr.String(), waitErr)
+	}
+	return d.err
+}
+
 func (d *fxFfmpegStreamDecoder) Seek(t time.Duration) error {
-	// If seeking backwards, we MUST restart.
-	// If seeking forwards, we could skip frames, but restarting with -ss is often more efficient for large jumps.
-	// For small jumps, skipping might be better.
-	// For simplicity and robustness, let's always restart for now if the difference is significant (> 1 sec).
-	// If difference is small and positive, we can read and discard.
+	return d.SeekFrame(d.info.FrameIndex(t))
+}
+
+func (d *fxFfmpegStreamDecoder) SeekFrame(n int) error {
+	if n < 0 {
+		return fmt.Errorf("invalid frame index %d", n)
+	}
 
-	delta := t - d.currentTime
-	if delta < 0 || delta > 1*time.Second {
-		return d.startFFmpeg(t)
+	// If seeking backwards, we MUST restart.
+	// For large forward jumps, restarting is cheaper than converting and piping every skipped frame.
+	// For small jumps (up to about a second), read and discard.
+	delta := n - d.frame
+	if delta < 0 || delta > d.info.FPS {
+		return d.startFFmpeg(n)
 	}
 
 	// Skip frames
-	// Read and discard frames until we reach the target time.
-	framesToSkip := int(delta.Seconds() * float64(d.info.FPS))
-	if framesToSkip > 0 {
+	// Read and discard frames until we reach the target frame.
+	if delta > 0 {
 		frameSize := d.info.Width * d.info.Height * 4
-		discard := make([]byte, frameSize)
-
-		for i := 0; i < framesToSkip; i++ {
-			if _, err := io.ReadFull(d.stdout, discard); err != nil {
-				return err
+		if len(d.discard) != frameSize {
+			d.discard = make([]byte, frameSize)
+		}
+		for i := 0; i < delta; i++ {
+			if _, err := io.ReadFull(d.stdout, d.discard); err != nil {
+				return d.readError(err)
 			}
-			d.currentTime += time.Second / time.Duration(d.info.FPS)
+			d.frame++
 		}
 	}
 
 	return nil
 }
 
+func (d *fxFfmpegStreamDecoder) FrameAt(n int, img *image.RGBA) error {
+	if err := d.SeekFrame(n); err != nil {
+		return err
+	}
+	return d.ReadFrame(img)
+}
+
 func (d *fxFfmpegStreamDecoder) ReadFrame(img *image.RGBA) error {
 	if img.Rect.Dx() != d.info.Width || img.Rect.Dy() != d.info.Height {
 		return fmt.Errorf("image dimension mismatch: expected %dx%d, got %dx%d", d.info.Width, d.info.Height, img.Rect.Dx(), img.Rect.Dy())
@@ -133,20 +221,25 @@ func (d *fxFfmpegStreamDecoder) ReadFrame(img *image.RGBA) error {
 	// Read exactly one frame of raw RGBA data from ffmpeg stdout.
 	_, err := io.ReadFull(d.stdout, img.Pix)
 	if err != nil {
-		return err
+		return d.readError(err)
 	}
 
-	d.currentTime += time.Second / time.Duration(d.info.FPS)
+	d.frame++
 	return nil
 }
 
+func (d *fxFfmpegStreamDecoder) FrameIndex() int {
+	return d.frame
+}
+
 func (d *fxFfmpegStreamDecoder) Close() error {
 	if d.stdout != nil {
 		d.stdout.Close()
 	}
-	if d.cmd != nil {
+	if d.cmd != nil && !d.waited {
 		d.cmd.Process.Kill() // Force kill if still running
 		d.cmd.Wait()
+		d.waited = true
 	}
 	return nil
 }
@@ -1,9 +1,11 @@
 package fxvideo
 
 import (
+	"errors"
 	"fmt"
 	"image"
 	"io"
+	"os"
 	"os/exec"
 )
 
@@ -15,12 +17,61 @@ type FXStreamEncoder interface {
 	Close() error
 }
 
-// fxMp4StreamEncoder implements FXStreamEncoder for MP4 output using ffmpeg.
-type fxMp4StreamEncoder struct {
+// FXAbortableStreamEncoder is implemented by encoders that can stop without finishing their output,
+// e.g. when a render is cancelled.
+type FXAbortableStreamEncoder interface {
+	FXStreamEncoder
+	// Abort stops encoding and discards the output that is not written yet.
+	// It may be called concurrently with AddFrame, to unblock an encoder that is stuck.
+	// Close must still be called afterwards to release resources.
+	Abort() error
+}
+
+// FXEncoderFactory creates a stream encoder for frames of the given size and rate.
+// It lets FXAnimation render with encoders other than ffmpeg.
+type FXEncoderFactory func(writer io.Writer, width, height, fps int) (FXStreamEncoder, error)
+
+// FXGIFEncoderFactory returns a factory for GIF encoders with the given options.
+func FXGIFEncoderFactory(options FXGIFOptions) FXEncoderFactory {
+	return func(writer io.Writer, width, height, fps int) (FXStreamEncoder, error) {
+		return NewFXGIFStreamEncoder(writer, width, height, fps, options)
+	}
+}
+
+// FXAPNGEncoderFactory returns a factory for APNG encoders with the given options.
+func FXAPNGEncoderFactory(options FXAPNGOptions) FXEncoderFactory {
+	return func(writer io.Writer, width, height, fps int) (FXStreamEncoder, error) {
+		return NewFXAPNGStreamEncoder(writer, width, height, fps, options)
+	}
+}
+
+// FXY4MEncoderFactory returns a factory for Y4M encoders with the given options.
+func FXY4MEncoderFactory(options FXY4MOptions) FXEncoderFactory {
+	return func(writer io.Writer, width, height, fps int) (FXStreamEncoder, error) {
+		return NewFXY4MStreamEncoder(writer, width, height, fps, options)
+	}
+}
+
+// FXImageSequenceEncoderFactory returns a factory for image sequence encoders writing to the pattern.
+// The encoders write files, so the writer passed to the factory is not used.
+func FXImageSequenceEncoderFactory(pattern string, options FXImageSequenceOptions) FXEncoderFactory {
+	return func(writer io.Writer, width, height, fps int) (FXStreamEncoder, error) {
+		return NewFXImageSequenceEncoder(pattern, width, height, options)
+	}
+}
+
+// fxFfmpegStreamEncoder implements FXStreamEncoder using ffmpeg.
+type fxFfmpegStreamEncoder struct {
 	// cmd is the ffmpeg command.
 	cmd *exec.Cmd
 	// stdin is the stdin pipe to ffmpeg.
 	stdin io.WriteCloser
+	// stderr keeps the end of ffmpeg's error output.
+	stderr *fxStderrTail
+	// waited is true once ffmpeg has exited and been waited for.
+	waited bool
+	// err is the error ffmpeg failed with, or nil.
+	err error
 	// width is the width of the video.
 	width int
 	// height is the height of the video.
@@ -28,35 +79,67 @@ type fxMp4StreamEncoder struct {
 }
 
 // NewFXMP4StreamEncoder creates a new MP4StreamEncoder that writes to the provided writer.
+// It uses FXDefaultEncoderOptions.
 func NewFXMP4StreamEncoder(writer io.Writer, width, height, fps int) (FXStreamEncoder, error) {
-	// ffmpeg command to read raw rgba video from stdin and output mp4 to stdout
+	return NewFXStreamEncoder(writer, width, height, fps, FXDefaultEncoderOptions())
+}
+
+// NewFXMP4StreamEncoderWithAudio creates a new MP4StreamEncoder that also muxes audio from
+// the given source into the output. If audio is nil, the output has no audio.
+func NewFXMP4StreamEncoderWithAudio(writer io.Writer, width, height, fps int, audio *FXAudioSource) (FXStreamEncoder, error) {
+	options := FXDefaultEncoderOptions()
+	options.Audio = audio
+	return NewFXStreamEncoder(writer, width, height, fps, options)
+}
+
+// NewFXStreamEncoder creates a new encoder that writes a video encoded with the given options
+// to the provided writer. It returns an error if the options are invalid.
+func NewFXStreamEncoder(writer io.Writer, width, height, fps int, options FXEncoderOptions) (FXStreamEncoder, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+	audio := options.Audio
+
+	// ffmpeg command to read raw rgba video from stdin and write the encoded video to stdout
 	// -y: Overwrite output.
+	// -hide_banner, -nostats: Keep the error output short, so the captured tail holds the errors.
 	// -f rawvideo: Input format is raw video.
 	// -pix_fmt rgba: Input pixel format is RGBA.
 	// -s: Input resolution.
 	// -r: Input frame rate.
 	// -i -: Read from stdin.
-	// -c:v libx264: Use H.264 codec.
-	// -preset ultrafast: Encode as fast as possible.
-	// -f mp4: Output format is MP4.
-	// -movflags frag_keyframe+empty_moov: Enable fragmented MP4 for streaming (writing to pipe).
 	args := []string{
 		"-y", // Overwrite output files without asking
+		"-hide_banner",
+		"-nostats",
 		"-f", "rawvideo",
 		"-pix_fmt", "rgba",
 		"-s", fmt.Sprintf("%dx%d", width, height),
 		"-r", fmt.Sprintf("%d", fps),
 		"-i", "-",
-		"-c:v", "libx264",
-		"-preset", "ultrafast",
-		"-f", "mp4",
-		"-movflags", "frag_keyframe+empty_moov",
-		"-",
+	}
+	// The audio source is the second input.
+	if audio != nil {
+		args = append(args, audio.inputArgs()...)
+		args = append(args, "-map", "0:v:0")
 	}
 
+	// Codec, rate control, pixel format and color metadata.
+	args = append(args, options.videoArgs()...)
+	if audio != nil {
+		args = append(args, audio.outputArgs(1, options.defaultAudioCodec())...)
+	}
+
+	// Container, written to stdout.
+	args = append(args, options.containerArgs()...)
+	args = append(args, "-")
+
 	cmd := exec.Command("ffmpeg", args...)
 	// Redirect stdout to the provided writer.
 	cmd.Stdout = writer
+	// Keep the end of stderr to explain failures.
+	stderr := &fxStderrTail{}
+	cmd.Stderr = stderr
 
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
@@ -64,42 +147,76 @@ func NewFXMP4StreamEncoder(writer io.Writer, width, height, fps int) (FXStreamEn
 	}
 
 	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+		return nil, newFXFFmpegError("ffmpeg", args, "", err)
 	}
 
-	return &fxMp4StreamEncoder{
+	return &fxFfmpegStreamEncoder{
 		cmd:    cmd,
 		stdin:  stdin,
+		stderr: stderr,
 		width:  width,
 		height: height,
 	}, nil
 }
 
 // AddFrame writes a single frame to the encoder.
-func (e *fxMp4StreamEncoder) AddFrame(img *image.RGBA) error {
+func (e *fxFfmpegStreamEncoder) AddFrame(img *image.RGBA) error {
 	if img.Rect.Dx() != e.width || img.Rect.Dy() != e.height {
 		return fmt.Errorf("frame dimension mismatch: expected %dx%d, got %dx%d", e.width, e.height, img.Rect.Dx(), img.Rect.Dy())
 	}
 
+	if e.err != nil {
+		return e.err
+	}
+
 	// Write raw pixels to ffmpeg stdin
 	// This sends the frame data to the running ffmpeg process.
 	_, err := e.stdin.Write(img.Pix)
 	if err != nil {
-		return fmt.Errorf("failed to write frame to ffmpeg: %w", err)
+		// The write fails when ffmpeg has exited, so its exit status and error output explain why.
+		e.stdin.Close()
+		if waitErr := e.wait(); waitErr != nil {
+			err = waitErr
+		}
+		e.err = newFXFFmpegError("ffmpeg", e.cmd.Args[1:], e.stderr.String(), err)
+		return e.err
+	}
+	return nil
+}
+
+// wait waits for ffmpeg to exit, once.
+func (e *fxFfmpegStreamEncoder) wait() error {
+	if e.waited {
+		return nil
+	}
+	e.waited = true
+	return e.cmd.Wait()
+}
+
+// Abort kills ffmpeg. Pending writes fail, and Close reaps the process.
+func (e *fxFfmpegStreamEncoder) Abort() error {
+	if err := e.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("failed to kill ffmpeg: %w", err)
 	}
 	return nil
 }
 
 // Close closes the input stream and waits for the encoding to finish.
-func (e *fxMp4StreamEncoder) Close() error {
+func (e *fxFfmpegStreamEncoder) Close() error {
+	// ffmpeg already failed while frames were written.
+	if e.waited {
+		return e.err
+	}
+
 	// Closing stdin signals EOF to ffmpeg, causing it to finish encoding and exit.
 	if err := e.stdin.Close(); err != nil {
 		return fmt.Errorf("failed to close stdin: %w", err)
 	}
 
 	// Wait for the process to exit.
-	if err := e.cmd.Wait(); err != nil {
-		return fmt.Errorf("ffmpeg process failed: %w", err)
+	if err := e.wait(); err != nil {
+		e.err = newFXFFmpegError("ffmpeg", e.cmd.Args[1:], e.stderr.String(), err)
+		return e.err
 	}
 
 	return nil
@@ -0,0 +1,193 @@
+package fxvideo
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fxStderrTailSize is the number of bytes of ffmpeg's error output that are kept.
+// Errors are printed last, so the tail holds the cause without buffering long logs.
+const fxStderrTailSize = 8 << 10
+
+// FXFFmpegErrorCause is the likely cause of an ffmpeg failure, parsed from its error output.
+type FXFFmpegErrorCause int
+
+const (
+	// FXCauseUnknown means the output did not match a known failure.
+	FXCauseUnknown FXFFmpegErrorCause = iota
+	// FXCauseNotInstalled means the program could not be started, usually because it is not in PATH.
+	FXCauseNotInstalled
+	// FXCauseMissingCodec means ffmpeg was built without the requested encoder or decoder.
+	FXCauseMissingCodec
+	// FXCauseUnsupportedPixelFormat means the codec does not support the requested pixel format.
+	FXCauseUnsupportedPixelFormat
+	// FXCauseInvalidInput means the input file is missing, unreadable or not a supported media file.
+	FXCauseInvalidInput
+	// FXCauseInvalidOption means an option or its value was rejected, e.g. a preset the codec does not have.
+	FXCauseInvalidOption
+)
+
+// String returns a short description of the cause.
+func (c FXFFmpegErrorCause) String() string {
+	switch c {
+	case FXCauseUnknown:
+		return "unknown cause"
+	case FXCauseNotInstalled:
+		return "not installed"
+	case FXCauseMissingCodec:
+		return "missing codec"
+	case FXCauseUnsupportedPixelFormat:
+		return "unsupported pixel format"
+	case FXCauseInvalidInput:
+		return "invalid input"
+	case FXCauseInvalidOption:
+		return "invalid option"
+	default:
+		return fmt.Sprintf("FXFFmpegErrorCause(%d)", int(c))
+	}
+}
+
+// fxCausePatterns maps messages in ffmpeg's output to causes. Earlier patterns win.
+var fxCausePatterns = []struct {
+	// pattern is a substring of the message.
+	pattern string
+	// cause is the cause the message indicates.
+	cause FXFFmpegErrorCause
+}{
+	{"Unknown encoder", FXCauseMissingCodec},
+	{"Unknown decoder", FXCauseMissingCodec},
+	{"Encoder not found", FXCauseMissingCodec},
+	{"Decoder not found", FXCauseMissingCodec},
+	{"Unsupported codec", FXCauseMissingCodec},
+	{"Incompatible pixel format", FXCauseUnsupportedPixelFormat},
+	{"Unsupported pixel format", FXCauseUnsupportedPixelFormat},
+	{"Invalid pixel format", FXCauseUnsupportedPixelFormat},
+	{"No such pixel format", FXCauseUnsupportedPixelFormat},
+	{"does not support pixel format", FXCauseUnsupportedPixelFormat},
+	{"No such file or directory", FXCauseInvalidInput},
+	{"Invalid data found when processing input", FXCauseInvalidInput},
+	{"could not find codec parameters", FXCauseInvalidInput},
+	{"moov atom not found", FXCauseInvalidInput},
+	{"Error opening input", FXCauseInvalidInput},
+	{"Permission denied", FXCauseInvalidInput},
+	{"Unrecognized option", FXCauseInvalidOption},
+	{"Option not found", FXCauseInvalidOption},
+	{"Error setting option", FXCauseInvalidOption},
+	{"Unable to parse option value", FXCauseInvalidOption},
+	{"Possible presets", FXCauseInvalidOption},
+}
+
+// FXFFmpegError is returned when ffmpeg or ffprobe fails.
+// Use errors.As to inspect it, e.g. to check the Cause.
+type FXFFmpegError struct {
+	// Program is the program that failed: "ffmpeg" or "ffprobe".
+	Program string
+	// Args are the arguments the program was started with.
+	Args []string
+	// Cause is the likely cause of the failure.
+	Cause FXFFmpegErrorCause
+	// Detail is the line of the error output describing the failure, or empty.
+	Detail string
+	// Stderr is the end of the error output of the program.
+	Stderr string
+	// Err is the underlying error, such as an *exec.ExitError or a broken pipe.
+	Err error
+}
+
+// newFXFFmpegError creates an error for a failed program, parsing the cause from its error output.
+func newFXFFmpegError(program string, args []string, stderr string, err error) *FXFFmpegError {
+	e := &FXFFmpegError{
+		Program: program,
+		Args:    args,
+		Stderr:  stderr,
+		Err:     err,
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		e.Cause = FXCauseNotInstalled
+		return e
+	}
+	e.Cause, e.Detail = parseFFmpegCause(stderr)
+	return e
+}
+
+// Error returns the cause, the detail and the underlying error, followed by the command line.
+func (e *FXFFmpegError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s failed", e.Program)
+	if e.Cause != FXCauseUnknown {
+		fmt.Fprintf(&b, " (%s)", e.Cause)
+	}
+	if e.Detail != "" {
+		fmt.Fprintf(&b, ": %s", e.Detail)
+	}
+	if e.Err != nil {
+		fmt.Fprintf(&b, ": %v", e.Err)
+	}
+	fmt.Fprintf(&b, "; command: %s", e.CommandLine())
+	return b.String()
+}
+
+// Unwrap returns the underlying error.
+func (e *FXFFmpegError) Unwrap() error {
+	return e.Err
+}
+
+// CommandLine returns the command as it could be typed in a shell, quoting arguments where needed.
+func (e *FXFFmpegError) CommandLine() string {
+	parts := make([]string, 0, len(e.Args)+1)
+	parts = append(parts, e.Program)
+	for _, arg := range e.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\$`*?;&|<>()") {
+			arg = strconv.Quote(arg)
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseFFmpegCause finds the cause of a failure in the error output of ffmpeg.
+// It returns the last line matching a known message; if no line matches, it returns
+// FXCauseUnknown and the last line of the output, which is usually the error.
+func parseFFmpegCause(stderr string) (FXFFmpegErrorCause, string) {
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		for _, p := range fxCausePatterns {
+			if strings.Contains(line, p.pattern) {
+				return p.cause, line
+			}
+		}
+	}
+	return FXCauseUnknown, strings.TrimSpace(lines[len(lines)-1])
+}
+
+// fxStderrTail is an io.Writer that keeps the last fxStderrTailSize bytes written to it.
+// It is safe for concurrent use, as exec writes to it from its own goroutine.
+type fxStderrTail struct {
+	// mu guards buf.
+	mu sync.Mutex
+	// buf holds the end of the output.
+	buf []byte
+}
+
+func (t *fxStderrTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if excess := len(t.buf) - fxStderrTailSize; excess > 0 {
+		// Drop the start, keeping the capacity for the next writes.
+		t.buf = t.buf[:copy(t.buf, t.buf[excess:])]
+	}
+	return len(p), nil
+}
+
+// String returns the kept output.
+func (t *fxStderrTail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
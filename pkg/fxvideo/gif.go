@@ -0,0 +1,265 @@
+package fxvideo
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"sort"
+	"sync/atomic"
+)
+
+// FXPaletteMode selects how GIF palettes are chosen.
+type FXPaletteMode int
+
+const (
+	// FXPaletteAdaptive computes a palette for every frame. It gives the best colors.
+	FXPaletteAdaptive FXPaletteMode = iota
+	// FXPaletteGlobal computes one palette from the first frame and uses it for all frames.
+	// Colors stay stable between frames, which avoids flicker and makes files smaller.
+	FXPaletteGlobal
+	// FXPaletteWebSafe uses the fixed 216-color web-safe palette.
+	FXPaletteWebSafe
+)
+
+// FXGIFOptions configures the GIF encoder.
+type FXGIFOptions struct {
+	// Colors is the maximum number of palette colors, 2 to 256. Zero means 256.
+	// It is ignored by FXPaletteWebSafe.
+	Colors int
+	// Palette selects how palettes are chosen.
+	Palette FXPaletteMode
+	// Dither enables Floyd-Steinberg error diffusion, which hides banding in gradients.
+	Dither bool
+	// Transparent reserves a palette entry for transparent pixels.
+	// Without it, frames are opaque.
+	Transparent bool
+	// LoopCount is the number of times the animation plays; 0 loops forever.
+	LoopCount int
+}
+
+// fxGIFStreamEncoder implements FXStreamEncoder for animated GIF output.
+// Frames are quantized as they are added and the file is written on Close,
+// because the GIF encoder needs all frames at once.
+type fxGIFStreamEncoder struct {
+	// writer receives the GIF file.
+	writer io.Writer
+	// width is the width of the animation.
+	width int
+	// height is the height of the animation.
+	height int
+	// fps is the frame rate of the animation.
+	fps int
+	// options are the encoder options.
+	options FXGIFOptions
+	// palette is the palette shared by all frames, for the global and web-safe modes.
+	palette color.Palette
+	// anim collects the quantized frames.
+	anim gif.GIF
+	// closed is true after Close.
+	closed bool
+	// aborted is set by Abort; the file is then not written.
+	aborted atomic.Bool
+}
+
+// NewFXGIFStreamEncoder creates an encoder that writes an animated GIF to the provided writer.
+// It does not need ffmpeg.
+func NewFXGIFStreamEncoder(writer io.Writer, width, height, fps int, options FXGIFOptions) (FXStreamEncoder, error) {
+	if options.Colors == 0 {
+		options.Colors = 256
+	}
+	if options.Colors < 2 || options.Colors > 256 {
+		return nil, fmt.Errorf("GIF colors must be between 2 and 256, got %d", options.Colors)
+	}
+	if fps <= 0 {
+		return nil, fmt.Errorf("invalid frame rate %d", fps)
+	}
+	if options.LoopCount < 0 {
+		return nil, fmt.Errorf("loop count must not be negative: %d", options.LoopCount)
+	}
+
+	e := &fxGIFStreamEncoder{
+		writer:  writer,
+		width:   width,
+		height:  height,
+		fps:     fps,
+		options: options,
+	}
+	// image/gif plays LoopCount+1 times, except that 0 loops forever and -1 plays once.
+	switch {
+	case options.LoopCount == 1:
+		e.anim.LoopCount = -1
+	case options.LoopCount > 1:
+		e.anim.LoopCount = options.LoopCount - 1
+	}
+	if options.Palette == FXPaletteWebSafe {
+		e.palette = withTransparency(palette.WebSafe, options.Transparent)
+	}
+	return e, nil
+}
+
+func (e *fxGIFStreamEncoder) AddFrame(img *image.RGBA) error {
+	if e.closed {
+		return fmt.Errorf("encoder is closed")
+	}
+	if img.Rect.Dx() != e.width || img.Rect.Dy() != e.height {
+		return fmt.Errorf("frame dimension mismatch: expected %dx%d, got %dx%d", e.width, e.height, img.Rect.Dx(), img.Rect.Dy())
+	}
+
+	// 1. Palette
+	p := e.palette
+	if p == nil {
+		colors := e.options.Colors
+		if e.options.Transparent {
+			colors--
+		}
+		p = withTransparency(medianCut(img, colors), e.options.Transparent)
+		if e.options.Palette == FXPaletteGlobal {
+			e.palette = p
+		}
+	}
+
+	// 2. Quantize
+	bounds := image.Rect(0, 0, e.width, e.height)
+	frame := image.NewPaletted(bounds, p)
+	if e.options.Dither {
+		draw.FloydSteinberg.Draw(frame, bounds, img, img.Rect.Min)
+	} else {
+		draw.Draw(frame, bounds, img, img.Rect.Min, draw.Src)
+	}
+
+	// 3. Timing
+	// GIF delays are in hundredths of a second. Round the frame end times rather than
+	// the frame durations, so the total length does not drift.
+	n := len(e.anim.Image)
+	delay := (n+1)*100/e.fps - n*100/e.fps
+	e.anim.Image = append(e.anim.Image, frame)
+	e.anim.Delay = append(e.anim.Delay, delay)
+	disposal := byte(gif.DisposalNone)
+	if e.options.Transparent {
+		// Clear each frame before the next, so old pixels do not show through transparent ones.
+		disposal = gif.DisposalBackground
+	}
+	e.anim.Disposal = append(e.anim.Disposal, disposal)
+	return nil
+}
+
+// Abort discards the frames, so Close writes nothing.
+func (e *fxGIFStreamEncoder) Abort() error {
+	e.aborted.Store(true)
+	return nil
+}
+
+func (e *fxGIFStreamEncoder) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	if e.aborted.Load() {
+		return nil
+	}
+	if len(e.anim.Image) == 0 {
+		return fmt.Errorf("no frames to encode")
+	}
+	if err := gif.EncodeAll(e.writer, &e.anim); err != nil {
+		return fmt.Errorf("failed to encode GIF: %w", err)
+	}
+	return nil
+}
+
+// withTransparency returns the palette with a transparent color added at index 0 if transparent is true.
+func withTransparency(p color.Palette, transparent bool) color.Palette {
+	if !transparent {
+		return p
+	}
+	out := make(color.Palette, 0, len(p)+1)
+	out = append(out, color.RGBA{})
+	// The web-safe palette has 216 colors, so there is always room.
+	return append(out, p...)
+}
+
+// medianCut computes a palette of at most n colors for an image by recursively splitting
+// the color space box with the widest channel range at its median.
+// Transparent pixels are ignored.
+func medianCut(img *image.RGBA, n int) color.Palette {
+	// Sample at most about 64K pixels, which is plenty to find the dominant colors.
+	pixelCount := img.Rect.Dx() * img.Rect.Dy()
+	step := pixelCount/65536 + 1
+	var samples [][3]uint8
+	for i := 0; i < pixelCount; i += step {
+		x, y := i%img.Rect.Dx(), i/img.Rect.Dx()
+		offset := y*img.Stride + x*4
+		pix := img.Pix[offset : offset+4]
+		if pix[3] < 128 {
+			continue
+		}
+		samples = append(samples, [3]uint8{pix[0], pix[1], pix[2]})
+	}
+	if len(samples) == 0 {
+		return color.Palette{color.RGBA{A: 255}}
+	}
+
+	boxes := []fxColorBox{newColorBox(samples)}
+	for len(boxes) < n {
+		// Split the box with the widest channel range.
+		best := -1
+		for i, box := range boxes {
+			if box.spread > 0 && (best < 0 || box.spread > boxes[best].spread) {
+				best = i
+			}
+		}
+		if best < 0 {
+			// Every box holds a single color.
+			break
+		}
+
+		box := boxes[best]
+		c := box.channel
+		sort.Slice(box.samples, func(i, j int) bool { return box.samples[i][c] < box.samples[j][c] })
+		mid := len(box.samples) / 2
+		boxes[best] = newColorBox(box.samples[:mid])
+		boxes = append(boxes, newColorBox(box.samples[mid:]))
+	}
+
+	// Each palette color is the average of its box.
+	p := make(color.Palette, 0, len(boxes))
+	for _, box := range boxes {
+		var sum [3]int
+		for _, s := range box.samples {
+			sum[0] += int(s[0])
+			sum[1] += int(s[1])
+			sum[2] += int(s[2])
+		}
+		k := len(box.samples)
+		p = append(p, color.RGBA{uint8((sum[0] + k/2) / k), uint8((sum[1] + k/2) / k), uint8((sum[2] + k/2) / k), 255})
+	}
+	return p
+}
+
+// fxColorBox is a set of color samples in the median cut.
+type fxColorBox struct {
+	// samples are the RGB samples in the box.
+	samples [][3]uint8
+	// channel is the channel with the widest range.
+	channel int
+	// spread is the range of that channel. Boxes with a zero spread cannot be split.
+	spread int
+}
+
+// newColorBox creates a box and finds its widest channel.
+func newColorBox(samples [][3]uint8) fxColorBox {
+	box := fxColorBox{samples: samples}
+	for c := 0; c < 3; c++ {
+		lo, hi := uint8(255), uint8(0)
+		for _, s := range samples {
+			lo, hi = min(lo, s[c]), max(hi, s[c])
+		}
+		if r := int(hi) - int(lo); r > box.spread {
+			box.channel, box.spread = c, r
+		}
+	}
+	return box
+}
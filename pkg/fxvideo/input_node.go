@@ -1,14 +1,41 @@
 package fxvideo
 
 import (
+	"errors"
 	"image"
+	"io"
 	"time"
 
 	"kdfx/pkg/fxcontext"
 	"kdfx/pkg/fxcore"
+	"kdfx/pkg/fximage"
 	"kdfx/pkg/fxnode"
 )
 
+// FXVideoDisplayFS is the fragment shader that turns stored frames into displayed frames.
+// Each displayed texture coordinate maps to a stored one: u = dot(u_mapU, (u, v, 1)), and likewise for v.
+// Stretching for non-square pixels happens because the output has the display size.
+const FXVideoDisplayFS = `
+precision mediump float;
+varying vec2 v_texCoord;
+uniform sampler2D u_texture;
+uniform vec3 u_mapU;
+uniform vec3 u_mapV;
+
+void main() {
+	vec3 uv = vec3(v_texCoord, 1.0);
+	gl_FragColor = texture2D(u_texture, vec2(dot(u_mapU, uv), dot(u_mapV, uv)));
+}
+`
+
+// fxDisplayMaps holds the u_mapU and u_mapV uniforms for each number of clockwise quarter turns.
+var fxDisplayMaps = [4][2][]float32{
+	{{1, 0, 0}, {0, 1, 0}},
+	{{0, 1, 0}, {-1, 0, 1}},
+	{{-1, 0, 1}, {0, -1, 1}},
+	{{0, -1, 1}, {1, 0, 0}},
+}
+
 // FXVideoPlaybackMode defines how the video behaves when the requested time is outside its duration.
 type FXVideoPlaybackMode int
 
@@ -30,6 +57,8 @@ type FXVideoInputNode interface {
 	// SetTime sets the current playback time.
 	// This is typically called by the animation loop.
 	SetTime(t time.Duration)
+	// Info returns the metadata of the video.
+	Info() FXVideoInfo
 }
 
 // fxVideoInputNode implements FXVideoInputNode.
@@ -37,8 +66,11 @@ type fxVideoInputNode struct {
 	fxnode.FXNode
 	// decoder is the video stream decoder.
 	decoder FXStreamDecoder
-	// texture is the texture where the video frame is uploaded.
+	// texture is the texture where the video frame is uploaded, at the stored size.
 	texture fxcore.FXTexture
+	// corrected is true if the frame is rotated or has non-square pixels, so the base node
+	// renders texture at the display size. Otherwise texture is the output.
+	corrected bool
 	// img is the temporary image buffer.
 	img *image.RGBA
 	// mode is the playback mode (Loop, Stretch, Clamp, None).
@@ -47,6 +79,9 @@ type fxVideoInputNode struct {
 	targetDuration time.Duration
 	// currentTime is the current playback time.
 	currentTime time.Duration
+	// frame is the index of the frame resolved at the last Process, or -1 before the first.
+	// The texture holds it, or the last decodable frame if the frame count was too high.
+	frame int
 }
 
 // NewFXVideoInputNode creates a new video fxnode.
@@ -56,26 +91,65 @@ func NewFXVideoInputNode(ctx fxcontext.FXContext, path string) (FXVideoInputNode
 	if err != nil {
 		return nil, err
 	}
+	return NewFXVideoInputNodeFromDecoder(ctx, decoder)
+}
 
+// NewFXImageSequenceInputNode creates a video fxnode that plays the numbered images matching
+// a pattern such as "shot_%04d.png" at the given frame rate. See NewFXImageSequenceDecoder.
+func NewFXImageSequenceInputNode(ctx fxcontext.FXContext, pattern string, rate FXRational) (FXVideoInputNode, error) {
+	decoder, err := NewFXImageSequenceDecoder(pattern, rate)
+	if err != nil {
+		return nil, err
+	}
+	return NewFXVideoInputNodeFromDecoder(ctx, decoder)
+}
+
+// NewFXVideoInputNodeFromDecoder creates a video fxnode that plays the frames of a decoder.
+// The node takes ownership of the decoder and closes it on Release, or on error.
+// Frames are shown as a player shows them: rotated by the display rotation and stretched
+// for non-square pixels, so the node has the size returned by FXVideoInfo.DisplaySize.
+func NewFXVideoInputNodeFromDecoder(ctx fxcontext.FXContext, decoder FXStreamDecoder) (FXVideoInputNode, error) {
 	info := decoder.Info()
 	// Create a texture to store video frames.
 	tex := fxcore.NewFXTexture(info.Width, info.Height)
 	// if err != nil { ... } // NewTexture doesn't return error currently
 
 	// Create a base node.
-	base, err := fxnode.NewFXBaseNode(ctx, info.Width, info.Height)
+	width, height := info.DisplaySize()
+	base, err := fxnode.NewFXBaseNode(ctx, width, height)
 	if err != nil {
+		tex.Release()
 		decoder.Close()
 		return nil, err
 	}
 
-	return &fxVideoInputNode{
-		FXNode:  base,
-		decoder: decoder,
-		texture: tex,
-		img:     image.NewRGBA(image.Rect(0, 0, info.Width, info.Height)),
-		mode:    FXModeLoop, // Default to loop
-	}, nil
+	n := &fxVideoInputNode{
+		FXNode:    base,
+		decoder:   decoder,
+		texture:   tex,
+		corrected: width != info.Width || height != info.Height || info.quarterTurns() != 0,
+		img:       image.NewRGBA(image.Rect(0, 0, info.Width, info.Height)),
+		mode:      FXModeLoop, // Default to loop
+		frame:     -1,
+	}
+	if !n.corrected {
+		return n, nil
+	}
+
+	// Render the stored frame rotated and at the display size.
+	program, err := fxcore.NewFXShaderProgram(fxcore.FXSimpleVS, FXVideoDisplayFS)
+	if err != nil {
+		base.Release()
+		tex.Release()
+		decoder.Close()
+		return nil, err
+	}
+	base.SetShaderProgram(program)
+	base.SetInput("u_texture", fximage.NewFXImageInput(tex))
+	maps := fxDisplayMaps[info.quarterTurns()]
+	base.SetUniform("u_mapU", maps[0])
+	base.SetUniform("u_mapV", maps[1])
+	return n, nil
 }
 
 func (n *fxVideoInputNode) SetMode(mode FXVideoPlaybackMode) {
@@ -90,57 +164,70 @@ func (n *fxVideoInputNode) SetTime(t time.Duration) {
 	n.currentTime = t
 }
 
-func (n *fxVideoInputNode) Process(ctx fxcontext.FXContext) error {
+func (n *fxVideoInputNode) Info() FXVideoInfo {
+	return n.decoder.Info()
+}
+
+// resolveFrame returns the index of the frame to show at the current time.
+// It returns false in FXModeNone after the end, where the last frame is kept.
+func (n *fxVideoInputNode) resolveFrame() (int, bool) {
 	info := n.decoder.Info()
-	videoDuration := info.Duration
-	var videoTime time.Duration
+	frame := info.FrameIndex(n.currentTime)
 
-	// Calculate the video time based on the playback mode.
+	// Calculate the video frame based on the playback mode.
+	// Frames are selected by index so that timing follows the exact frame rate.
 	switch n.mode {
 	case FXModeLoop:
 		// Loop the video if the current time exceeds duration.
-		if videoDuration > 0 {
-			videoTime = time.Duration(int64(n.currentTime) % int64(videoDuration))
+		if info.FrameCount > 0 {
+			frame %= info.FrameCount
 		}
 	case FXModeStretch:
 		// Stretch the video to fit the target duration.
 		if n.targetDuration > 0 {
-			videoTime = time.Duration(float64(n.currentTime) * float64(videoDuration) / float64(n.targetDuration))
-		} else {
-			videoTime = n.currentTime
+			videoTime := time.Duration(float64(n.currentTime) * float64(info.Duration) / float64(n.targetDuration))
+			frame = info.FrameIndex(videoTime)
+		}
+		if info.FrameCount > 0 && frame >= info.FrameCount {
+			frame = info.FrameCount - 1
 		}
 	case FXModeClamp:
 		// Clamp the video to the last frame if current time exceeds duration.
-		if n.currentTime > videoDuration {
-			videoTime = videoDuration
-		} else {
-			videoTime = n.currentTime
+		if info.FrameCount > 0 && frame >= info.FrameCount {
+			frame = info.FrameCount - 1
 		}
 	case FXModeNone:
-		// Play normally, potentially going past the end (handling EOF later).
-		videoTime = n.currentTime
+		// Play normally. After the end, keep the last frame.
+		if info.FrameCount > 0 && frame >= info.FrameCount {
+			return 0, false
+		}
 	}
+	return frame, true
+}
 
-	// Seek decoder to the calculated time
-	// This is efficient because the decoder handles seeking internally.
-	if err := n.decoder.Seek(videoTime); err != nil {
-		return err
+func (n *fxVideoInputNode) Process(ctx fxcontext.FXContext) error {
+	frame, ok := n.resolveFrame()
+	// The texture already holds this frame, e.g. when rendering faster than the video frame rate.
+	if !ok || frame == n.frame {
+		return nil
 	}
+	resolved := frame
 
 	// Read frame
-	// Decode the frame into the image buffer.
-	if err := n.decoder.ReadFrame(n.img); err != nil {
-		if err.Error() == "EOF" && n.mode == FXModeLoop {
-			// If we hit EOF in loop mode, try seeking to 0 and reading again
-			if err := n.decoder.Seek(0); err != nil {
-				return err
-			}
-			if err := n.decoder.ReadFrame(n.img); err != nil {
+	// Seek the decoder to the frame and decode it into the image buffer.
+	// Decoders read forward for nearby frames, so playing in order is cheap.
+	if err := n.decoder.FrameAt(frame, n.img); err != nil {
+		// The frame count can be slightly too high for files without an exact count.
+		atEnd := errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+		if atEnd && n.mode == FXModeLoop {
+			// Wrap around to the first frame.
+			frame = 0
+			if err := n.decoder.FrameAt(frame, n.img); err != nil {
 				return err
 			}
-		} else if n.mode == FXModeNone && err.Error() == "EOF" {
-			// Ignore EOF for FXModeNone (keep last frame or clear?)
-			// Keeping last frame is safer for now.
+		} else if atEnd {
+			// Past the last decodable frame: keep the last frame.
+			n.frame = resolved
 			return nil
 		} else {
 			return err
@@ -150,15 +237,34 @@ func (n *fxVideoInputNode) Process(ctx fxcontext.FXContext) error {
 	// Upload to texture
 	// Upload the decoded frame to the GPU texture.
 	n.texture.Upload(n.img)
+	n.frame = resolved
+
+	// Correct for display
+	// Render the new frame rotated and stretched to the display size.
+	if n.corrected {
+		n.MarkDirty()
+		return n.FXNode.Process(ctx)
+	}
 
 	return nil
 }
 
+// IsDirty returns true if the current time resolves to a different frame than the one shown.
+// The frame depends only on the time and playback mode, so this does not decode anything.
+func (n *fxVideoInputNode) IsDirty() bool {
+	frame, ok := n.resolveFrame()
+	return ok && frame != n.frame
+}
+
 func (n *fxVideoInputNode) GetTexture() fxcore.FXTexture {
+	if n.corrected {
+		return n.FXNode.GetTexture()
+	}
 	return n.texture
 }
 
 func (n *fxVideoInputNode) Release() {
 	n.decoder.Close()
+	n.texture.Release()
 	n.FXNode.Release()
 }
@@ -0,0 +1,397 @@
+package fxvideo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FXVideoCodec is an ffmpeg video encoder.
+type FXVideoCodec string
+
+const (
+	FXCodecH264   FXVideoCodec = "libx264"    // H.264 (x264).
+	FXCodecH265   FXVideoCodec = "libx265"    // H.265/HEVC (x265).
+	FXCodecVP9    FXVideoCodec = "libvpx-vp9" // VP9 (libvpx).
+	FXCodecProRes FXVideoCodec = "prores_ks"  // Apple ProRes.
+	FXCodecFFV1   FXVideoCodec = "ffv1"       // FFV1, lossless.
+)
+
+// FXContainer is an output container format.
+type FXContainer string
+
+const (
+	FXContainerMP4  FXContainer = "mp4"  // MPEG-4 Part 14.
+	FXContainerMOV  FXContainer = "mov"  // QuickTime.
+	FXContainerMKV  FXContainer = "mkv"  // Matroska.
+	FXContainerWebM FXContainer = "webm" // WebM.
+)
+
+// fxCodecSpec describes what an encoder supports.
+type fxCodecSpec struct {
+	// containers are the containers the codec can be muxed into.
+	containers []FXContainer
+	// pixelFormats are the supported pixel formats. Formats with alpha contain an "a".
+	pixelFormats []string
+	// maxCRF is the largest CRF value, or 0 if the codec has no CRF mode.
+	maxCRF int
+	// presets are the accepted speed presets, if any.
+	presets []string
+	// profiles are the accepted profiles. If empty, any profile is passed through to ffmpeg.
+	profiles []string
+	// lossy is true if the codec has a lossy mode with a bitrate.
+	lossy bool
+	// lossless is true if the codec has a lossless mode.
+	lossless bool
+}
+
+// fxX26xPresets are the speed presets of x264 and x265.
+var fxX26xPresets = []string{"ultrafast", "superfast", "veryfast", "faster", "fast", "medium", "slow", "slower", "veryslow", "placebo"}
+
+// fxCodecSpecs describes the supported codecs.
+var fxCodecSpecs = map[FXVideoCodec]fxCodecSpec{
+	FXCodecH264: {
+		containers:   []FXContainer{FXContainerMP4, FXContainerMOV, FXContainerMKV},
+		pixelFormats: []string{"yuv420p", "yuv422p", "yuv444p", "yuv420p10le", "yuv422p10le", "yuv444p10le"},
+		maxCRF:       51,
+		presets:      fxX26xPresets,
+		lossy:        true,
+		lossless:     true,
+	},
+	FXCodecH265: {
+		containers:   []FXContainer{FXContainerMP4, FXContainerMOV, FXContainerMKV},
+		pixelFormats: []string{"yuv420p", "yuv422p", "yuv444p", "yuv420p10le", "yuv422p10le", "yuv444p10le", "yuv420p12le", "yuv444p12le"},
+		maxCRF:       51,
+		presets:      fxX26xPresets,
+		lossy:        true,
+		lossless:     true,
+	},
+	FXCodecVP9: {
+		containers:   []FXContainer{FXContainerWebM, FXContainerMKV, FXContainerMP4},
+		pixelFormats: []string{"yuv420p", "yuva420p", "yuv422p", "yuv444p", "yuv420p10le", "yuv422p10le", "yuv444p10le"},
+		maxCRF:       63,
+		// VP9 presets are libvpx deadlines.
+		presets:  []string{"realtime", "good", "best"},
+		lossy:    true,
+		lossless: true,
+	},
+	FXCodecProRes: {
+		containers:   []FXContainer{FXContainerMOV, FXContainerMKV},
+		pixelFormats: []string{"yuv422p10le", "yuv444p10le", "yuva444p10le"},
+		profiles:     []string{"proxy", "lt", "standard", "hq", "4444", "4444xq"},
+	},
+	FXCodecFFV1: {
+		containers:   []FXContainer{FXContainerMKV, FXContainerMOV},
+		pixelFormats: []string{"yuv420p", "yuv422p", "yuv444p", "yuva420p", "yuva444p", "yuv420p10le", "yuv422p10le", "yuv444p10le", "bgr0", "bgra", "gbrp10le", "gbrap10le"},
+		lossless:     true,
+	},
+}
+
+// FXEncoderOptions configures the ffmpeg encoder used by FXStreamEncoder.
+// Empty fields use the codec defaults. Use FXEncoderPreset for common configurations.
+type FXEncoderOptions struct {
+	// Codec is the video encoder. Defaults to FXCodecH264.
+	Codec FXVideoCodec
+	// Container is the output container. Defaults to FXContainerMP4.
+	// MP4 and MOV are written fragmented, so they can be streamed to a pipe.
+	Container FXContainer
+	// CRF is the constant rate factor (lower is better quality); 0 uses the codec default.
+	// The range is 1 to 51 for x264 and x265 and 1 to 63 for VP9. ProRes and FFV1 do not use it.
+	CRF int
+	// Bitrate is the target video bitrate, such as "5M". It cannot be combined with CRF,
+	// except for VP9 where it caps the bitrate in constrained quality mode.
+	Bitrate string
+	// Lossless enables the lossless mode of x264, x265 and VP9. FFV1 is always lossless.
+	Lossless bool
+	// Preset is the speed preset: "ultrafast" to "placebo" for x264 and x265,
+	// or "realtime", "good" and "best" for VP9.
+	Preset string
+	// Profile is the codec profile. For ProRes it is one of "proxy", "lt", "standard", "hq",
+	// "4444" or "4444xq"; lossless H.264 requires "high444". For other codecs it is passed
+	// to ffmpeg unchecked.
+	Profile string
+	// PixelFormat is the encoded pixel format, such as "yuv420p" or "yuva444p10le".
+	// Formats with alpha keep the alpha channel of the frames. If empty, ffmpeg chooses.
+	PixelFormat string
+	// KeyframeInterval is the maximum number of frames between keyframes; 0 uses the codec default.
+	KeyframeInterval int
+	// ColorPrimaries is the color primaries tag, such as "bt709".
+	ColorPrimaries string
+	// ColorTransfer is the transfer characteristics tag, such as "bt709" or "arib-std-b67".
+	ColorTransfer string
+	// ColorSpace is the YUV matrix tag, such as "bt709" or "bt2020nc".
+	ColorSpace string
+	// ColorRange is "tv" (limited) or "pc" (full).
+	ColorRange string
+	// Audio is the audio to mux into the output, or nil for no audio.
+	Audio *FXAudioSource
+}
+
+// FXDefaultEncoderOptions returns the options of NewFXMP4StreamEncoder:
+// H.264 with the ultrafast preset in fragmented MP4.
+func FXDefaultEncoderOptions() FXEncoderOptions {
+	return FXEncoderOptions{
+		Codec:     FXCodecH264,
+		Container: FXContainerMP4,
+		Preset:    "ultrafast",
+	}
+}
+
+// FXEncoderPreset returns the options for a named preset:
+//   - "web": H.264 in MP4, 8-bit 4:2:0 with BT.709 tags, for playback in browsers.
+//   - "archive": lossless FFV1 in Matroska, keeping RGB and alpha exactly.
+//   - "intermediate": ProRes 422 HQ in MOV, for editing in other applications.
+func FXEncoderPreset(name string) (FXEncoderOptions, error) {
+	switch name {
+	case "web":
+		return FXEncoderOptions{
+			Codec:            FXCodecH264,
+			Container:        FXContainerMP4,
+			CRF:              23,
+			Preset:           "medium",
+			Profile:          "high",
+			PixelFormat:      "yuv420p",
+			KeyframeInterval: 120,
+			ColorPrimaries:   "bt709",
+			ColorTransfer:    "bt709",
+			ColorSpace:       "bt709",
+			ColorRange:       "tv",
+		}, nil
+	case "archive":
+		return FXEncoderOptions{
+			Codec:       FXCodecFFV1,
+			Container:   FXContainerMKV,
+			PixelFormat: "bgra",
+			// Every frame is a keyframe, so damage to the file stays local.
+			KeyframeInterval: 1,
+		}, nil
+	case "intermediate":
+		return FXEncoderOptions{
+			Codec:          FXCodecProRes,
+			Container:      FXContainerMOV,
+			Profile:        "hq",
+			PixelFormat:    "yuv422p10le",
+			ColorPrimaries: "bt709",
+			ColorTransfer:  "bt709",
+			ColorSpace:     "bt709",
+			ColorRange:     "tv",
+		}, nil
+	}
+	return FXEncoderOptions{}, fmt.Errorf("unknown encoder preset %q (available: archive, intermediate, web)", name)
+}
+
+// withDefaults returns the options with the codec and container filled in.
+func (o FXEncoderOptions) withDefaults() FXEncoderOptions {
+	if o.Codec == "" {
+		o.Codec = FXCodecH264
+	}
+	if o.Container == "" {
+		o.Container = FXContainerMP4
+	}
+	return o
+}
+
+// Validate checks that the options are consistent and supported by the codec.
+func (o FXEncoderOptions) Validate() error {
+	o = o.withDefaults()
+	spec, ok := fxCodecSpecs[o.Codec]
+	if !ok {
+		return fmt.Errorf("unsupported codec %q", o.Codec)
+	}
+	if _, ok := fxContainerMuxers[o.Container]; !ok {
+		return fmt.Errorf("unsupported container %q", o.Container)
+	}
+	if !containsString(containerNames(spec.containers), string(o.Container)) {
+		return fmt.Errorf("codec %s cannot be stored in %s (supported: %s)",
+			o.Codec, o.Container, strings.Join(containerNames(spec.containers), ", "))
+	}
+
+	// Rate control
+	switch {
+	case o.CRF < 0:
+		return fmt.Errorf("CRF must not be negative: %d", o.CRF)
+	case o.CRF > 0 && spec.maxCRF == 0:
+		return fmt.Errorf("codec %s does not support CRF", o.Codec)
+	case o.CRF > spec.maxCRF:
+		return fmt.Errorf("CRF %d is out of range for %s (1 to %d)", o.CRF, o.Codec, spec.maxCRF)
+	case o.Bitrate != "" && !spec.lossy:
+		return fmt.Errorf("codec %s does not support a target bitrate", o.Codec)
+	case o.Bitrate != "" && o.CRF > 0 && o.Codec != FXCodecVP9:
+		return fmt.Errorf("CRF and bitrate cannot be combined for %s", o.Codec)
+	case o.Lossless && !spec.lossless:
+		return fmt.Errorf("codec %s has no lossless mode", o.Codec)
+	case o.Lossless && (o.CRF > 0 || o.Bitrate != ""):
+		return fmt.Errorf("lossless mode cannot be combined with CRF or bitrate")
+	}
+
+	if o.Preset != "" && !containsString(spec.presets, o.Preset) {
+		if len(spec.presets) == 0 {
+			return fmt.Errorf("codec %s has no presets", o.Codec)
+		}
+		return fmt.Errorf("invalid preset %q for %s (supported: %s)", o.Preset, o.Codec, strings.Join(spec.presets, ", "))
+	}
+	if o.Profile != "" && len(spec.profiles) > 0 && !containsString(spec.profiles, o.Profile) {
+		return fmt.Errorf("invalid profile %q for %s (supported: %s)", o.Profile, o.Codec, strings.Join(spec.profiles, ", "))
+	}
+	if o.Lossless && o.Codec == FXCodecH264 && o.Profile != "" && o.Profile != "high444" {
+		// x264 encodes lossless video only in the High 4:4:4 Predictive profile.
+		return fmt.Errorf("lossless H.264 requires the high444 profile, not %q", o.Profile)
+	}
+
+	// Pixel format
+	if o.PixelFormat != "" {
+		if !containsString(spec.pixelFormats, o.PixelFormat) {
+			return fmt.Errorf("pixel format %s is not supported by %s (supported: %s)",
+				o.PixelFormat, o.Codec, strings.Join(spec.pixelFormats, ", "))
+		}
+		if hasAlpha(o.PixelFormat) {
+			if o.Codec == FXCodecProRes && !strings.HasPrefix(o.Profile, "4444") {
+				return fmt.Errorf("ProRes with alpha requires the 4444 or 4444xq profile")
+			}
+			if o.Codec == FXCodecVP9 && o.Container == FXContainerMP4 {
+				return fmt.Errorf("VP9 with alpha requires the webm or mkv container")
+			}
+		}
+	}
+
+	if o.KeyframeInterval < 0 {
+		return fmt.Errorf("keyframe interval must not be negative: %d", o.KeyframeInterval)
+	}
+	if o.ColorRange != "" && o.ColorRange != "tv" && o.ColorRange != "pc" {
+		return fmt.Errorf("invalid color range %q (supported: tv, pc)", o.ColorRange)
+	}
+
+	if o.Audio != nil {
+		if err := o.Audio.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fxContainerMuxers maps containers to ffmpeg muxer names.
+var fxContainerMuxers = map[FXContainer]string{
+	FXContainerMP4:  "mp4",
+	FXContainerMOV:  "mov",
+	FXContainerMKV:  "matroska",
+	FXContainerWebM: "webm",
+}
+
+// videoArgs returns the ffmpeg output arguments that encode the video stream.
+// The options must be valid.
+func (o FXEncoderOptions) videoArgs() []string {
+	o = o.withDefaults()
+	args := []string{"-c:v", string(o.Codec)}
+
+	switch o.Codec {
+	case FXCodecH264, FXCodecH265:
+		if o.Preset != "" {
+			args = append(args, "-preset", o.Preset)
+		}
+		switch {
+		case o.Lossless && o.Codec == FXCodecH264:
+			args = append(args, "-qp", "0")
+		case o.Lossless:
+			args = append(args, "-x265-params", "lossless=1")
+		case o.CRF > 0:
+			args = append(args, "-crf", fmt.Sprint(o.CRF))
+		case o.Bitrate != "":
+			args = append(args, "-b:v", o.Bitrate)
+		}
+	case FXCodecVP9:
+		if o.Preset != "" {
+			args = append(args, "-deadline", o.Preset)
+		}
+		switch {
+		case o.Lossless:
+			args = append(args, "-lossless", "1")
+		case o.CRF > 0:
+			// Constant quality needs a zero bitrate; a bitrate makes it constrained quality.
+			bitrate := o.Bitrate
+			if bitrate == "" {
+				bitrate = "0"
+			}
+			args = append(args, "-crf", fmt.Sprint(o.CRF), "-b:v", bitrate)
+		case o.Bitrate != "":
+			args = append(args, "-b:v", o.Bitrate)
+		}
+	case FXCodecFFV1:
+		// Version 3 adds slices and checksums, which make archives verifiable.
+		args = append(args, "-level", "3", "-slicecrc", "1")
+	}
+
+	if o.Profile != "" {
+		args = append(args, "-profile:v", o.Profile)
+	}
+	if o.PixelFormat != "" {
+		args = append(args, "-pix_fmt", o.PixelFormat)
+		if o.Codec == FXCodecProRes && hasAlpha(o.PixelFormat) {
+			// prores_ks drops alpha unless it is given bits to store it in.
+			args = append(args, "-alpha_bits", "16")
+		}
+	}
+	if o.KeyframeInterval > 0 {
+		args = append(args, "-g", fmt.Sprint(o.KeyframeInterval))
+	}
+
+	// Color metadata tags. They describe the pixels; they do not convert them.
+	if o.ColorPrimaries != "" {
+		args = append(args, "-color_primaries", o.ColorPrimaries)
+	}
+	if o.ColorTransfer != "" {
+		args = append(args, "-color_trc", o.ColorTransfer)
+	}
+	if o.ColorSpace != "" {
+		args = append(args, "-colorspace", o.ColorSpace)
+	}
+	if o.ColorRange != "" {
+		args = append(args, "-color_range", o.ColorRange)
+	}
+	return args
+}
+
+// containerArgs returns the ffmpeg output arguments that select the container.
+func (o FXEncoderOptions) containerArgs() []string {
+	o = o.withDefaults()
+	args := []string{"-f", fxContainerMuxers[o.Container]}
+	if o.Container == FXContainerMP4 || o.Container == FXContainerMOV {
+		// -movflags frag_keyframe+empty_moov: Enable fragmented MP4 for streaming (writing to pipe).
+		args = append(args, "-movflags", "frag_keyframe+empty_moov")
+	}
+	return args
+}
+
+// defaultAudioCodec returns the audio codec used when the audio source does not set one.
+func (o FXEncoderOptions) defaultAudioCodec() string {
+	if o.withDefaults().Container == FXContainerWebM {
+		// WebM only allows Opus and Vorbis audio.
+		return "libopus"
+	}
+	return "aac"
+}
+
+// hasAlpha returns true if the pixel format has an alpha channel.
+func hasAlpha(pixelFormat string) bool {
+	return strings.HasPrefix(pixelFormat, "yuva") || strings.HasPrefix(pixelFormat, "gbrap") ||
+		strings.Contains(pixelFormat, "rgba") || strings.Contains(pixelFormat, "bgra")
+}
+
+// containerNames returns the names of containers, sorted.
+func containerNames(containers []FXContainer) []string {
+	names := make([]string, len(containers))
+	for i, c := range containers {
+		names[i] = string(c)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// containsString returns true if list contains s.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -23,13 +23,16 @@ type fxVideoOutputNode struct {
 	input fxnode.FXInput
 	// ctx is the context used for rendering.
 	ctx fxcontext.FXContext
+	// writer reads frames back and encodes them asynchronously.
+	// It is created for the size of the first frame.
+	writer *fxFrameWriter
 }
 
 // NewFXVideoOutputNode creates a new video output fxnode.
 func NewFXVideoOutputNode(ctx fxcontext.FXContext, encoder FXStreamEncoder) (FXVideoOutputNode, error) {
-	// Create a base node. Dimensions are 0 because this node doesn't render to its own texture,
-	// but rather consumes an input texture.
-	base, err := fxnode.NewFXBaseNode(ctx, 0, 0) // width and height are not directly used by this node, but base node requires them.
+	// Create a base node. Dimensions are minimal because this node doesn't render to its own texture,
+	// but rather consumes an input texture. A 0x0 framebuffer would be incomplete.
+	base, err := fxnode.NewFXBaseNode(ctx, 1, 1) // width and height are not directly used by this node, but base node requires them.
 	if err != nil {
 		return nil, err
 	}
@@ -49,10 +52,8 @@ func (n *fxVideoOutputNode) SetInput(name string, input fxnode.FXInput) {
 func (n *fxVideoOutputNode) Process(ctx fxcontext.FXContext) error {
 	// Process the input node first to ensure the texture is ready.
 	if n.input != nil {
-		if inputNode, ok := n.input.(fxnode.FXNode); ok {
-			if err := inputNode.Process(ctx); err != nil {
-				return err
-			}
+		if err := fxnode.FXProcessInput(ctx, n.input); err != nil {
+			return err
 		}
 	}
 
@@ -66,20 +67,13 @@ func (n *fxVideoOutputNode) Process(ctx fxcontext.FXContext) error {
 		return fmt.Errorf("no input texture")
 	}
 
-	// Download texture
-	// Read the texture data from GPU memory to CPU memory.
-	img, err := tex.Download()
-	if err != nil {
-		return fmt.Errorf("failed to download texture: %w", err)
-	}
-
-	// Add frame to encoder
-	// Send the image to the video encoder.
-	if err := n.encoder.AddFrame(img); err != nil {
-		return fmt.Errorf("failed to add frame: %w", err)
+	// Queue the frame
+	// The texture is read back asynchronously and sent to the video encoder on another goroutine.
+	if n.writer == nil {
+		w, h := tex.GetSize()
+		n.writer = newFXFrameWriter(n.encoder, w, h, fxReadbackDepth)
 	}
-
-	return nil
+	return n.writer.Write(tex)
 }
 
 func (n *fxVideoOutputNode) GetTexture() fxcore.FXTexture {
@@ -90,5 +84,14 @@ func (n *fxVideoOutputNode) GetTexture() fxcore.FXTexture {
 }
 
 func (n *fxVideoOutputNode) Close() error {
+	// Encode the frames still in flight before closing the encoder.
+	if n.writer != nil {
+		err := n.writer.Close()
+		n.writer = nil
+		if err != nil {
+			n.encoder.Close()
+			return err
+		}
+	}
 	return n.encoder.Close()
 }
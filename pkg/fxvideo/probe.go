@@ -1,101 +1,576 @@
 package fxvideo
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// FXRational is an exact frame rate such as 30000/1001 (29.97 fps).
+type FXRational struct {
+	// Num is the numerator.
+	Num int
+	// Den is the denominator. It is always positive for valid rates.
+	Den int
+}
+
+// FXParseRational parses a rate in ffmpeg notation: "30000/1001", "30" or "29.97".
+// Decimal rates are converted to the NTSC rational they approximate where one exists.
+func FXParseRational(s string) (FXRational, error) {
+	// Handle rational frame rates (numerator/denominator).
+	if num, den, ok := strings.Cut(s, "/"); ok {
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			return FXRational{}, err
+		}
+		d, err := strconv.Atoi(den)
+		if err != nil {
+			return FXRational{}, err
+		}
+		if d == 0 {
+			return FXRational{}, fmt.Errorf("division by zero in rate %s", s)
+		}
+		return FXRational{Num: n, Den: d}.reduce(), nil
+	}
+
+	if n, err := strconv.Atoi(s); err == nil {
+		return FXRational{Num: n, Den: 1}, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return FXRational{}, fmt.Errorf("invalid rate format: %s", s)
+	}
+	// 23.976, 29.97 and 59.94 are the NTSC rates n*1000/1001.
+	if ntsc := math.Round(f * 1.001); math.Abs(f-ntsc/1.001) < 0.005 && math.Abs(f-ntsc) > 0.005 {
+		return FXRational{Num: int(ntsc) * 1000, Den: 1001}, nil
+	}
+	return FXRational{Num: int(math.Round(f * 1000)), Den: 1000}.reduce(), nil
+}
+
+// reduce divides the numerator and denominator by their greatest common divisor
+// and makes the denominator positive.
+func (r FXRational) reduce() FXRational {
+	a, b := r.Num, r.Den
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a < 0 {
+		a = -a
+	}
+	if a == 0 {
+		return r
+	}
+	r.Num, r.Den = r.Num/a, r.Den/a
+	if r.Den < 0 {
+		r.Num, r.Den = -r.Num, -r.Den
+	}
+	return r
+}
+
+// IsValid returns true if the rate is positive.
+func (r FXRational) IsValid() bool {
+	return r.Num > 0 && r.Den > 0
+}
+
+// Float64 returns the rate as a floating point number.
+func (r FXRational) Float64() float64 {
+	if r.Den == 0 {
+		return 0
+	}
+	return float64(r.Num) / float64(r.Den)
+}
+
+// String returns the rate in ffmpeg notation, e.g. "30000/1001".
+func (r FXRational) String() string {
+	return fmt.Sprintf("%d/%d", r.Num, r.Den)
+}
+
+// FrameTime returns the presentation time of frame n relative to the first frame.
+// It is exact to the nanosecond, so it does not drift for long videos.
+func (r FXRational) FrameTime(n int) time.Duration {
+	if !r.IsValid() {
+		return 0
+	}
+	// n frames last n*Den/Num seconds. Split into whole seconds and a remainder to avoid overflow.
+	total := int64(n) * int64(r.Den)
+	seconds, rem := total/int64(r.Num), total%int64(r.Num)
+	return time.Duration(seconds)*time.Second + time.Duration(rem*int64(time.Second)/int64(r.Num))
+}
+
+// FrameIndex returns the index of the frame displayed at time t, relative to the first frame.
+// Times within a microsecond before a frame boundary belong to the next frame, so times
+// computed with rounding, such as i*(time.Second/fps), map back to frame i.
+func (r FXRational) FrameIndex(t time.Duration) int {
+	if !r.IsValid() || t <= 0 {
+		return 0
+	}
+	return int(math.Floor((t.Seconds() + 1e-6) * float64(r.Num) / float64(r.Den)))
+}
+
+// FrameDuration returns the duration of one frame, rounded to the nanosecond.
+func (r FXRational) FrameDuration() time.Duration {
+	return r.FrameTime(1)
+}
+
 // FXVideoInfo contains metadata about a video file.
+// Fields that the file does not specify are left at their zero value.
 type FXVideoInfo struct {
-	// Width is the width of the video in pixels.
+	// Width is the width of the video in pixels, as stored.
 	Width int
-	// Height is the height of the video in pixels.
+	// Height is the height of the video in pixels, as stored.
 	Height int
-	// FPS is the frames per second of the video.
+	// FPS is the frame rate rounded to the nearest integer (30 for 29.97).
+	// Use FrameRate for timing.
 	FPS int
+	// FrameRate is the exact frame rate of the video stream.
+	FrameRate FXRational
+	// FrameCount is the number of frames in the video stream.
+	// It is computed from the duration if the container does not store it.
+	FrameCount int
+	// StartTime is the presentation time of the first frame. Frame times are relative to it.
+	StartTime time.Duration
 	// Duration is the total duration of the video.
 	Duration time.Duration
+	// StreamIndex is the index of the video stream in the file.
+	StreamIndex int
+	// Codec is the name of the video codec, such as "h264".
+	Codec string
+	// Profile is the codec profile, such as "High".
+	Profile string
+	// PixelFormat is the ffmpeg pixel format, such as "yuv420p".
+	PixelFormat string
+	// BitDepth is the number of bits per color component.
+	BitDepth int
+	// ColorSpace is the matrix of YUV video, such as "bt709".
+	ColorSpace string
+	// ColorTransfer is the transfer characteristic, such as "bt709" or "smpte2084".
+	ColorTransfer string
+	// ColorPrimaries are the color primaries, such as "bt709" or "bt2020".
+	ColorPrimaries string
+	// ColorRange is "tv" for limited range or "pc" for full range.
+	ColorRange string
+	// SampleAspectRatio is the shape of a pixel, width over height. It is 1/1 for square pixels.
+	SampleAspectRatio FXRational
+	// Rotation is the clockwise rotation in degrees (0, 90, 180 or 270) to apply for display.
+	Rotation int
+	// DisplayMatrix is the display transformation matrix of the stream, if it has one,
+	// in row order with 16.16 fixed point values (2.30 for the third column).
+	DisplayMatrix []int32
+	// TimeBase is the unit of the timestamps of the video stream.
+	TimeBase FXRational
+	// Bitrate is the bitrate of the video stream in bits per second.
+	Bitrate int64
+	// Audio lists the audio streams of the file.
+	Audio []FXAudioStreamInfo
+	// Container describes the file format.
+	Container FXContainerInfo
+}
+
+// FXAudioStreamInfo contains metadata about an audio stream.
+type FXAudioStreamInfo struct {
+	// StreamIndex is the index of the stream in the file.
+	StreamIndex int
+	// Codec is the name of the audio codec, such as "aac".
+	Codec string
+	// SampleRate is the number of samples per second.
+	SampleRate int
+	// Channels is the number of channels.
+	Channels int
+	// ChannelLayout is the channel layout, such as "stereo" or "5.1".
+	ChannelLayout string
+	// Duration is the duration of the stream.
+	Duration time.Duration
+	// Bitrate is the bitrate in bits per second.
+	Bitrate int64
+	// Language is the language tag of the stream, such as "eng".
+	Language string
+}
+
+// FXContainerInfo contains metadata about a media file format.
+type FXContainerInfo struct {
+	// FormatName is the list of ffmpeg format names matching the file, such as "mov,mp4,m4a,3gp,3g2,mj2".
+	FormatName string
+	// Duration is the duration of the file, the longest of its streams.
+	Duration time.Duration
+	// StartTime is the earliest presentation time of the streams.
+	StartTime time.Duration
+	// Size is the size of the file in bytes.
+	Size int64
+	// Bitrate is the total bitrate in bits per second.
+	Bitrate int64
+	// StreamCount is the number of streams in the file.
+	StreamCount int
+}
+
+// FrameTime returns the time of frame n relative to the start of the video.
+func (info FXVideoInfo) FrameTime(n int) time.Duration {
+	return info.FrameRate.FrameTime(n)
+}
+
+// FrameIndex returns the index of the frame displayed at time t relative to the start of the video.
+func (info FXVideoInfo) FrameIndex(t time.Duration) int {
+	return info.FrameRate.FrameIndex(t)
+}
+
+// quarterTurns returns the display rotation as a number of clockwise quarter turns, from 0 to 3.
+// Rotations that are not a multiple of 90 degrees are rounded to the nearest one.
+func (info FXVideoInfo) quarterTurns() int {
+	return ((info.Rotation+45)/90%4 + 4) % 4
+}
+
+// DisplaySize returns the size of the video as a player shows it: the width is scaled by the
+// sample aspect ratio, and width and height are swapped for rotations of 90 and 270 degrees.
+func (info FXVideoInfo) DisplaySize() (width, height int) {
+	width, height = info.Width, info.Height
+	if sar := info.SampleAspectRatio; sar.IsValid() && sar.Num != sar.Den {
+		width = max(1, int(math.Round(float64(width)*sar.Float64())))
+	}
+	if info.quarterTurns()%2 == 1 {
+		width, height = height, width
+	}
+	return width, height
+}
+
+// fxProbeOutput is the JSON output of ffprobe -show_streams -show_format.
+// ffprobe prints most numbers as strings, to keep their exact value.
+type fxProbeOutput struct {
+	// Streams are the streams of the file.
+	Streams []fxProbeStream `json:"streams"`
+	// Format describes the file format.
+	Format fxProbeFormat `json:"format"`
+}
+
+// fxProbeStream is a stream in the ffprobe output.
+type fxProbeStream struct {
+	// Index is the index of the stream in the file.
+	Index int `json:"index"`
+	// CodecName is the short codec name.
+	CodecName string `json:"codec_name"`
+	// CodecType is "video", "audio", "subtitle" or "data".
+	CodecType string `json:"codec_type"`
+	// Profile is the codec profile.
+	Profile string `json:"profile"`
+	// Width is the video width in pixels.
+	Width int `json:"width"`
+	// Height is the video height in pixels.
+	Height int `json:"height"`
+	// PixFmt is the pixel format.
+	PixFmt string `json:"pix_fmt"`
+	// BitsPerRawSample is the bit depth, if the codec reports it.
+	BitsPerRawSample string `json:"bits_per_raw_sample"`
+	// ColorRange is "tv" or "pc".
+	ColorRange string `json:"color_range"`
+	// ColorSpace is the YUV matrix.
+	ColorSpace string `json:"color_space"`
+	// ColorTransfer is the transfer characteristic.
+	ColorTransfer string `json:"color_transfer"`
+	// ColorPrimaries are the color primaries.
+	ColorPrimaries string `json:"color_primaries"`
+	// SampleAspectRatio is the pixel aspect ratio as "num:den".
+	SampleAspectRatio string `json:"sample_aspect_ratio"`
+	// RFrameRate is the base frame rate.
+	RFrameRate string `json:"r_frame_rate"`
+	// AvgFrameRate is the average frame rate.
+	AvgFrameRate string `json:"avg_frame_rate"`
+	// TimeBase is the timestamp unit.
+	TimeBase string `json:"time_base"`
+	// StartTime is the first timestamp in seconds.
+	StartTime string `json:"start_time"`
+	// Duration is the stream duration in seconds.
+	Duration string `json:"duration"`
+	// NbFrames is the number of frames, if the container stores it.
+	NbFrames string `json:"nb_frames"`
+	// BitRate is the bitrate in bits per second.
+	BitRate string `json:"bit_rate"`
+	// SampleRate is the audio sample rate.
+	SampleRate string `json:"sample_rate"`
+	// Channels is the number of audio channels.
+	Channels int `json:"channels"`
+	// ChannelLayout is the audio channel layout.
+	ChannelLayout string `json:"channel_layout"`
+	// Disposition holds flags such as "default" and "attached_pic".
+	Disposition map[string]int `json:"disposition"`
+	// Tags holds metadata such as "language" and "rotate".
+	Tags map[string]string `json:"tags"`
+	// SideDataList holds side data such as the display matrix.
+	SideDataList []fxProbeSideData `json:"side_data_list"`
+}
+
+// fxProbeSideData is stream side data in the ffprobe output.
+type fxProbeSideData struct {
+	// SideDataType is the kind of side data, such as "Display Matrix".
+	SideDataType string `json:"side_data_type"`
+	// DisplayMatrix is the display matrix as printed by ffprobe.
+	DisplayMatrix string `json:"displaymatrix"`
+	// Rotation is the counterclockwise rotation of the display matrix in degrees.
+	Rotation json.Number `json:"rotation"`
+}
+
+// fxProbeFormat is the format section of the ffprobe output.
+type fxProbeFormat struct {
+	// FormatName is the list of matching format names.
+	FormatName string `json:"format_name"`
+	// NbStreams is the number of streams.
+	NbStreams int `json:"nb_streams"`
+	// StartTime is the earliest timestamp in seconds.
+	StartTime string `json:"start_time"`
+	// Duration is the file duration in seconds.
+	Duration string `json:"duration"`
+	// Size is the file size in bytes.
+	Size string `json:"size"`
+	// BitRate is the total bitrate in bits per second.
+	BitRate string `json:"bit_rate"`
 }
 
 // FXProbeVideo extracts metadata from a video file using ffprobe.
+// The first video stream is described, skipping cover art; the audio streams are listed.
+// Y4M files (.y4m) are read natively.
 func FXProbeVideo(path string) (*FXVideoInfo, error) {
-	// ffprobe -v error -select_streams v:0 -show_entries stream=width,height,r_frame_rate,duration -of csv=p=0 <path>
-	// Construct the ffprobe command to extract specific video stream information.
+	if isY4MPath(path) {
+		decoder, err := NewFXY4MFileDecoder(path, FXY4MOptions{})
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+		info := decoder.Info()
+		return &info, nil
+	}
+
+	// ffprobe -v error -print_format json -show_streams -show_format <path>
 	// -v error: Suppress non-error output.
-	// -select_streams v:0: Select the first video stream.
-	// -show_entries: Specify which fields to output.
-	// -of csv=p=0: Output in CSV format without header.
+	// -print_format json: Structured output that tolerates missing fields and any number of streams.
+	// -show_streams, -show_format: Describe every stream and the container.
 	cmd := exec.Command("ffprobe",
 		"-v", "error",
-		"-select_streams", "v:0",
-		"-show_entries", "stream=width,height,r_frame_rate,duration",
-		"-of", "csv=p=0",
+		"-print_format", "json",
+		"-show_streams",
+		"-show_format",
 		path,
 	)
 
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("ffprobe failed: %w", err)
+		// Output keeps the error output in the exit error.
+		var stderr string
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			stderr = string(exitErr.Stderr)
+		}
+		return nil, newFXFFmpegError("ffprobe", cmd.Args[1:], stderr, err)
 	}
 
-	// Output format: width,height,r_frame_rate,duration
-	// Example: 1920,1080,30/1,10.5
-	parts := strings.Split(strings.TrimSpace(string(output)), ",")
-	if len(parts) < 4 {
-		return nil, fmt.Errorf("unexpected ffprobe output: %s", string(output))
+	var probe fxProbeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("invalid ffprobe output: %w", err)
 	}
+	return parseProbeOutput(&probe)
+}
 
-	width, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return nil, fmt.Errorf("invalid width: %v", err)
+// parseProbeOutput converts the ffprobe output to an FXVideoInfo.
+func parseProbeOutput(probe *fxProbeOutput) (*FXVideoInfo, error) {
+	// 1. Container
+	info := &FXVideoInfo{
+		Container: FXContainerInfo{
+			FormatName:  probe.Format.FormatName,
+			Duration:    parseSeconds(probe.Format.Duration),
+			StartTime:   parseSeconds(probe.Format.StartTime),
+			Size:        parseInt64(probe.Format.Size),
+			Bitrate:     parseInt64(probe.Format.BitRate),
+			StreamCount: probe.Format.NbStreams,
+		},
 	}
 
-	height, err := strconv.Atoi(parts[1])
-	if err != nil {
-		return nil, fmt.Errorf("invalid height: %v", err)
+	// 2. Streams
+	var video *fxProbeStream
+	for i := range probe.Streams {
+		stream := &probe.Streams[i]
+		switch stream.CodecType {
+		case "video":
+			// Cover art is stored as a video stream with a single picture.
+			if video == nil && stream.Disposition["attached_pic"] == 0 {
+				video = stream
+			}
+		case "audio":
+			info.Audio = append(info.Audio, FXAudioStreamInfo{
+				StreamIndex:   stream.Index,
+				Codec:         stream.CodecName,
+				SampleRate:    int(parseInt64(stream.SampleRate)),
+				Channels:      stream.Channels,
+				ChannelLayout: stream.ChannelLayout,
+				Duration:      parseSeconds(stream.Duration),
+				Bitrate:       parseInt64(stream.BitRate),
+				Language:      stream.Tags["language"],
+			})
+		}
+	}
+	if video == nil {
+		return nil, fmt.Errorf("no video stream found")
+	}
+	if video.Width <= 0 || video.Height <= 0 {
+		return nil, fmt.Errorf("invalid video size %dx%d", video.Width, video.Height)
 	}
 
-	fps, err := parseFPS(parts[2])
-	if err != nil {
-		return nil, fmt.Errorf("invalid fps: %v", err)
+	// 3. Timing
+	// r_frame_rate is the base rate of the stream; fall back to the average rate if it is unset.
+	rate, err := FXParseRational(video.RFrameRate)
+	if err != nil || !rate.IsValid() {
+		rate, err = FXParseRational(video.AvgFrameRate)
+	}
+	if err != nil || !rate.IsValid() {
+		return nil, fmt.Errorf("invalid frame rate: %s", video.RFrameRate)
+	}
+	// Some containers, such as Matroska, only store the duration of the file.
+	duration := parseSeconds(video.Duration)
+	if duration == 0 {
+		duration = info.Container.Duration
+	}
+	frameCount := int(parseInt64(video.NbFrames))
+	if frameCount <= 0 {
+		frameCount = int(math.Round(duration.Seconds() * rate.Float64()))
+	}
+	if duration == 0 {
+		duration = rate.FrameTime(frameCount)
 	}
 
-	durationSec, err := strconv.ParseFloat(parts[3], 64)
-	if err != nil {
-		return nil, fmt.Errorf("invalid duration: %v", err)
+	info.Width = video.Width
+	info.Height = video.Height
+	info.FPS = int(math.Round(rate.Float64()))
+	info.FrameRate = rate
+	info.FrameCount = frameCount
+	info.StartTime = parseSeconds(video.StartTime)
+	info.Duration = duration
+	info.StreamIndex = video.Index
+	info.Codec = video.CodecName
+	info.Profile = video.Profile
+	info.PixelFormat = video.PixFmt
+	info.BitDepth = int(parseInt64(video.BitsPerRawSample))
+	if info.BitDepth == 0 {
+		info.BitDepth = pixelFormatBitDepth(video.PixFmt)
+	}
+	info.ColorSpace = video.ColorSpace
+	info.ColorTransfer = video.ColorTransfer
+	info.ColorPrimaries = video.ColorPrimaries
+	info.ColorRange = video.ColorRange
+	info.TimeBase, _ = FXParseRational(video.TimeBase)
+	info.Bitrate = parseInt64(video.BitRate)
+
+	// 4. Geometry
+	// ffprobe prints "0:1" when the aspect ratio is unknown, which means square pixels.
+	info.SampleAspectRatio, err = FXParseRational(strings.Replace(video.SampleAspectRatio, ":", "/", 1))
+	if err != nil || !info.SampleAspectRatio.IsValid() {
+		info.SampleAspectRatio = FXRational{Num: 1, Den: 1}
+	}
+	// The display matrix side data gives the counterclockwise rotation; older files have a clockwise rotate tag.
+	rotation := 0
+	if tag, err := strconv.Atoi(video.Tags["rotate"]); err == nil {
+		rotation = tag
 	}
+	for _, side := range video.SideDataList {
+		if side.SideDataType != "Display Matrix" {
+			continue
+		}
+		if degrees, err := side.Rotation.Float64(); err == nil {
+			rotation = -int(math.Round(degrees))
+		}
+		info.DisplayMatrix = parseDisplayMatrix(side.DisplayMatrix)
+	}
+	info.Rotation = ((rotation % 360) + 360) % 360
 
-	return &FXVideoInfo{
-		Width:    width,
-		Height:   height,
-		FPS:      fps,
-		Duration: time.Duration(durationSec * float64(time.Second)),
-	}, nil
+	return info, nil
 }
 
-func parseFPS(fpsStr string) (int, error) {
-	// fps can be "30/1" or "30"
-	// Handle rational frame rates (numerator/denominator).
-	parts := strings.Split(fpsStr, "/")
-	if len(parts) == 1 {
-		return strconv.Atoi(parts[0])
+// parseSeconds parses a time in seconds as printed by ffprobe. Missing values ("N/A" or empty) are zero.
+func parseSeconds(s string) time.Duration {
+	seconds, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
 	}
-	if len(parts) == 2 {
-		num, err := strconv.Atoi(parts[0])
-		if err != nil {
-			return 0, err
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// parseInt64 parses an integer as printed by ffprobe. Missing values ("N/A" or empty) are zero.
+func parseInt64(s string) int64 {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// parseDisplayMatrix parses the display matrix printed by ffprobe: three rows, each with
+// an offset followed by three values. It returns nil if the text is not in that form.
+func parseDisplayMatrix(s string) []int32 {
+	var matrix []int32
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			return nil
 		}
-		den, err := strconv.Atoi(parts[1])
-		if err != nil {
-			return 0, err
+		for _, field := range fields[1:] {
+			v, err := strconv.ParseInt(field, 10, 32)
+			if err != nil {
+				return nil
+			}
+			matrix = append(matrix, int32(v))
 		}
-		if den == 0 {
-			return 0, fmt.Errorf("division by zero in fps")
+	}
+	if len(matrix) != 9 {
+		return nil
+	}
+	return matrix
+}
+
+// pixelFormatBitDepth returns the bit depth of an ffmpeg pixel format, such as 10 for "yuv420p10le".
+func pixelFormatBitDepth(format string) int {
+	if format == "" {
+		return 0
+	}
+	// The byte order does not change the depth.
+	name := strings.TrimSuffix(strings.TrimSuffix(format, "le"), "be")
+
+	// 1. Packed formats name the size of a pixel instead of the depth
+	switch name {
+	case "nv20", "x2rgb10", "x2bgr10", "xv30", "y210", "v30x":
+		return 10
+	case "xv36", "y212", "xyz12":
+		return 12
+	case "rgb48", "bgr48", "rgba64", "bgra64", "ayuv64", "xv48", "y216", "rgbaf16":
+		return 16
+	case "rgbf32", "rgbaf32":
+		return 32
+	}
+
+	// 2. Other formats put the depth after the layout: gray12, ya16, bayer_rggb16,
+	// p010 and p216 (semi-planar), or yuv420p10 and gbrap12 (planar). Without it they are 8-bit.
+	var depth string
+	switch {
+	case strings.HasPrefix(name, "gray"):
+		depth = name[len("gray"):]
+	case strings.HasPrefix(name, "ya"):
+		depth = name[len("ya"):]
+	case strings.HasPrefix(name, "bayer_"):
+		depth = strings.TrimLeft(name[len("bayer_"):], "bgr")
+	case name[0] == 'p' && len(name) == 4:
+		depth = name[2:]
+	default:
+		if i := strings.LastIndexByte(name, 'p'); i >= 0 {
+			depth = name[i+1:]
 		}
-		return num / den, nil // Integer FPS for now
 	}
-	return 0, fmt.Errorf("invalid fps format: %s", fpsStr)
+	// Float formats such as gbrpf32 have an "f" before the depth.
+	if bits, err := strconv.Atoi(strings.TrimPrefix(depth, "f")); err == nil && bits > 0 {
+		return bits
+	}
+	return 8
+}
+
+// isY4MPath returns true if the path has the Y4M extension.
+func isY4MPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".y4m")
 }
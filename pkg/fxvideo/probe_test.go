@@ -0,0 +1,35 @@
+package fxvideo
+
+import "testing"
+
+func TestPixelFormatBitDepth(t *testing.T) {
+	tests := map[string]int{
+		"":             0,
+		"yuv420p":      8,
+		"yuvj420p":     8,
+		"nv12":         8,
+		"uyvy422":      8,
+		"rgba":         8,
+		"pal8":         8,
+		"yuv420p9le":   9,
+		"yuv420p10le":  10,
+		"yuva444p16be": 16,
+		"gbrap12le":    12,
+		"gbrpf32le":    32,
+		"p010le":       10,
+		"p016le":       16,
+		"p216le":       16,
+		"nv20le":       10,
+		"gray10le":     10,
+		"gray12le":     12,
+		"ya16le":       16,
+		"rgb48le":      16,
+		"x2rgb10le":    10,
+		"y210le":       10,
+	}
+	for format, want := range tests {
+		if got := pixelFormatBitDepth(format); got != want {
+			t.Errorf("pixelFormatBitDepth(%q) = %d, want %d", format, got, want)
+		}
+	}
+}
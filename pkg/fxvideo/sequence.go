@@ -0,0 +1,341 @@
+package fxvideo
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fxSequencePattern is a parsed image sequence filename pattern such as "shot_%04d.png".
+type fxSequencePattern struct {
+	// prefix is the text before the frame number, with %% unescaped.
+	prefix string
+	// suffix is the text after the frame number, with %% unescaped.
+	suffix string
+	// digits is the minimum number of digits; shorter numbers are padded with zeros.
+	digits int
+}
+
+// parseSequencePattern parses a filename pattern with exactly one %d verb, optionally with
+// a zero padded width as in %04d. Literal percent signs are written %%.
+// The verb must be in the file name, not in a directory name.
+func parseSequencePattern(pattern string) (fxSequencePattern, error) {
+	var p fxSequencePattern
+	var text strings.Builder
+	found := false
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' {
+			text.WriteByte(pattern[i])
+			continue
+		}
+		if i+1 < len(pattern) && pattern[i+1] == '%' {
+			text.WriteByte('%')
+			i++
+			continue
+		}
+
+		// Parse %[0N]d.
+		j := i + 1
+		for j < len(pattern) && pattern[j] >= '0' && pattern[j] <= '9' {
+			j++
+		}
+		if j >= len(pattern) || pattern[j] != 'd' || found {
+			return p, fmt.Errorf("image sequence pattern %q must contain exactly one %%d or %%0Nd verb", pattern)
+		}
+		if j > i+1 {
+			p.digits, _ = strconv.Atoi(pattern[i+1 : j])
+		}
+		found = true
+		p.prefix = text.String()
+		text.Reset()
+		i = j
+	}
+	if !found {
+		return p, fmt.Errorf("image sequence pattern %q must contain exactly one %%d or %%0Nd verb", pattern)
+	}
+	p.suffix = text.String()
+	if strings.ContainsRune(p.suffix, filepath.Separator) || strings.ContainsRune(p.suffix, '/') {
+		return p, fmt.Errorf("image sequence pattern %q must number the file name, not a directory", pattern)
+	}
+	return p, nil
+}
+
+// format returns the filename of frame number n.
+func (p fxSequencePattern) format(n int) string {
+	return fmt.Sprintf("%s%0*d%s", p.prefix, p.digits, n, p.suffix)
+}
+
+// match returns the frame number of a filename, and false if it does not match the pattern.
+func (p fxSequencePattern) match(name string) (int, bool) {
+	if !strings.HasPrefix(name, p.prefix) || !strings.HasSuffix(name, p.suffix) || len(name) < len(p.prefix)+len(p.suffix) {
+		return 0, false
+	}
+	digits := name[len(p.prefix) : len(name)-len(p.suffix)]
+	n, err := strconv.Atoi(digits)
+	// Formatting the number back rejects signs and padding that differs from the pattern.
+	if err != nil || n < 0 || p.format(n) != name {
+		return 0, false
+	}
+	return n, true
+}
+
+// files returns the existing files of the sequence, sorted by frame number.
+func (p fxSequencePattern) files() ([]string, error) {
+	// The verb is in the file name, so the directory ends at the last separator of the prefix.
+	split := strings.LastIndexAny(p.prefix, "/"+string(filepath.Separator)) + 1
+	dir := p.prefix[:split]
+	name := fxSequencePattern{prefix: p.prefix[split:], suffix: p.suffix, digits: p.digits}
+	list := dir
+	if list == "" {
+		list = "."
+	}
+	entries, err := os.ReadDir(list)
+	if err != nil {
+		return nil, err
+	}
+
+	type numbered struct {
+		n    int
+		path string
+	}
+	var found []numbered
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if n, ok := name.match(entry.Name()); ok {
+			found = append(found, numbered{n, dir + entry.Name()})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].n < found[j].n })
+
+	files := make([]string, len(found))
+	for i, f := range found {
+		files[i] = f.path
+	}
+	return files, nil
+}
+
+// fxImageSequenceDecoder implements FXStreamDecoder for numbered image files.
+type fxImageSequenceDecoder struct {
+	// files are the images of the sequence in frame order.
+	files []string
+	// info contains metadata about the sequence.
+	info FXVideoInfo
+	// frame is the index of the next frame to be read.
+	frame int
+}
+
+// NewFXImageSequenceDecoder creates a decoder for the numbered images matching a pattern
+// such as "shot_%04d.png", played at the given frame rate. It does not need ffmpeg.
+// Frames are the matching files in numeric order, starting from the lowest number;
+// missing numbers are skipped. All images must have the same size.
+// PNG and JPEG images are supported.
+func NewFXImageSequenceDecoder(pattern string, rate FXRational) (FXStreamDecoder, error) {
+	if !rate.IsValid() {
+		return nil, fmt.Errorf("invalid frame rate %s", rate)
+	}
+	p, err := parseSequencePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	files, err := p.files()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list image sequence %s: %w", pattern, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no images match %s", pattern)
+	}
+
+	// The first image gives the size of the sequence.
+	file, err := os.Open(files[0])
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", files[0], err)
+	}
+
+	return &fxImageSequenceDecoder{
+		files: files,
+		info: FXVideoInfo{
+			Width:      config.Width,
+			Height:     config.Height,
+			FPS:        int(math.Round(rate.Float64())),
+			FrameRate:  rate,
+			FrameCount: len(files),
+			Duration:   rate.FrameTime(len(files)),
+			// Images have square pixels.
+			SampleAspectRatio: FXRational{Num: 1, Den: 1},
+		},
+	}, nil
+}
+
+func (d *fxImageSequenceDecoder) Seek(t time.Duration) error {
+	return d.SeekFrame(d.info.FrameIndex(t))
+}
+
+// SeekFrame selects the next frame. Every frame is a separate file, so seeking is free.
+func (d *fxImageSequenceDecoder) SeekFrame(n int) error {
+	if n < 0 {
+		return fmt.Errorf("invalid frame index %d", n)
+	}
+	d.frame = n
+	return nil
+}
+
+func (d *fxImageSequenceDecoder) FrameAt(n int, img *image.RGBA) error {
+	if err := d.SeekFrame(n); err != nil {
+		return err
+	}
+	return d.ReadFrame(img)
+}
+
+func (d *fxImageSequenceDecoder) ReadFrame(img *image.RGBA) error {
+	if img.Rect.Dx() != d.info.Width || img.Rect.Dy() != d.info.Height {
+		return fmt.Errorf("image dimension mismatch: expected %dx%d, got %dx%d", d.info.Width, d.info.Height, img.Rect.Dx(), img.Rect.Dy())
+	}
+	if d.frame >= len(d.files) {
+		return io.EOF
+	}
+
+	path := d.files[d.frame]
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	src, _, err := image.Decode(file)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	if src.Bounds().Dx() != d.info.Width || src.Bounds().Dy() != d.info.Height {
+		return fmt.Errorf("%s is %dx%d, but the sequence is %dx%d", path, src.Bounds().Dx(), src.Bounds().Dy(), d.info.Width, d.info.Height)
+	}
+
+	// Convert to RGBA, as for image textures.
+	draw.Draw(img, img.Rect, src, src.Bounds().Min, draw.Src)
+	d.frame++
+	return nil
+}
+
+func (d *fxImageSequenceDecoder) FrameIndex() int {
+	return d.frame
+}
+
+func (d *fxImageSequenceDecoder) Close() error {
+	return nil
+}
+
+func (d *fxImageSequenceDecoder) Info() FXVideoInfo {
+	return d.info
+}
+
+// FXImageSequenceOptions configures the image sequence encoder.
+type FXImageSequenceOptions struct {
+	// Start is the number of the first frame.
+	Start int
+	// JPEGQuality is the quality of JPEG images, from 1 to 100. Zero means jpeg.DefaultQuality.
+	JPEGQuality int
+}
+
+// fxImageSequenceEncoder implements FXStreamEncoder by writing every frame to a numbered image file.
+type fxImageSequenceEncoder struct {
+	// pattern is the filename pattern.
+	pattern fxSequencePattern
+	// width is the width of the frames.
+	width int
+	// height is the height of the frames.
+	height int
+	// options are the encoder options.
+	options FXImageSequenceOptions
+	// encode writes an image in the format of the file extension.
+	encode func(w io.Writer, img image.Image) error
+	// frame is the index of the next frame.
+	frame int
+	// closed is true after Close.
+	closed bool
+}
+
+// NewFXImageSequenceEncoder creates an encoder that writes frame n to the file named by the
+// pattern and the number Start+n, such as "out/shot_%04d.png". It does not need ffmpeg.
+// The format is chosen by the extension: .png or .jpg/.jpeg. JPEG images drop the alpha channel.
+// Existing files are overwritten; the directory must exist.
+func NewFXImageSequenceEncoder(pattern string, width, height int, options FXImageSequenceOptions) (FXStreamEncoder, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid size %dx%d", width, height)
+	}
+	p, err := parseSequencePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if options.Start < 0 {
+		return nil, fmt.Errorf("start frame must not be negative: %d", options.Start)
+	}
+	if options.JPEGQuality == 0 {
+		options.JPEGQuality = jpeg.DefaultQuality
+	}
+	if options.JPEGQuality < 1 || options.JPEGQuality > 100 {
+		return nil, fmt.Errorf("JPEG quality must be between 1 and 100, got %d", options.JPEGQuality)
+	}
+
+	e := &fxImageSequenceEncoder{
+		pattern: p,
+		width:   width,
+		height:  height,
+		options: options,
+	}
+	switch ext := strings.ToLower(filepath.Ext(p.suffix)); ext {
+	case ".png":
+		e.encode = png.Encode
+	case ".jpg", ".jpeg":
+		e.encode = func(w io.Writer, img image.Image) error {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: options.JPEGQuality})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported image sequence format %q", ext)
+	}
+	return e, nil
+}
+
+func (e *fxImageSequenceEncoder) AddFrame(img *image.RGBA) error {
+	if e.closed {
+		return fmt.Errorf("encoder is closed")
+	}
+	if img.Rect.Dx() != e.width || img.Rect.Dy() != e.height {
+		return fmt.Errorf("frame dimension mismatch: expected %dx%d, got %dx%d", e.width, e.height, img.Rect.Dx(), img.Rect.Dy())
+	}
+
+	path := e.pattern.format(e.options.Start + e.frame)
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := e.encode(file, img); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	e.frame++
+	return nil
+}
+
+// Close finishes the sequence. Every frame is already written by AddFrame.
+func (e *fxImageSequenceEncoder) Close() error {
+	e.closed = true
+	return nil
+}
@@ -0,0 +1,422 @@
+package fxvideo
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"time"
+
+	"kdfx/pkg/fxcontext"
+	"kdfx/pkg/fxcore"
+	"kdfx/pkg/fximage"
+	"kdfx/pkg/fxlib/fxblend"
+	"kdfx/pkg/fxlib/fxtransition"
+	"kdfx/pkg/fxnode"
+)
+
+// FXTimelineFitFS is the fragment shader that fits a texture into the timeline frame.
+// The input covers the centered fraction u_fit of the output; the rest is transparent.
+const FXTimelineFitFS = `
+precision mediump float;
+varying vec2 v_texCoord;
+uniform sampler2D u_texture;
+uniform vec2 u_fit;
+
+void main() {
+	vec2 uv = (v_texCoord - 0.5) / u_fit + 0.5;
+	if (uv.x < 0.0 || uv.x > 1.0 || uv.y < 0.0 || uv.y > 1.0) {
+		gl_FragColor = vec4(0.0);
+		return;
+	}
+	gl_FragColor = texture2D(u_texture, uv);
+}
+`
+
+// FXClipTransition mixes the outgoing clip, connected to slot "u_texture1",
+// with the incoming clip, connected to slot "u_texture2".
+// The clips are fitted to the timeline frame first, so the transition should have the size of the timeline.
+// Every fxtransition.FXTransitionNode is a clip transition.
+type FXClipTransition interface {
+	fxnode.FXNode
+	// SetProgress sets how far the transition has advanced,
+	// from 0 (only the outgoing clip) to 1 (only the incoming clip).
+	SetProgress(progress float32)
+}
+
+// NewFXCrossfadeTransition creates a transition that fades from the outgoing clip to the incoming one.
+// The fxtransition package has more transitions, such as wipes and pushes.
+func NewFXCrossfadeTransition(ctx fxcontext.FXContext, width, height int) (FXClipTransition, error) {
+	return fxtransition.NewFXCrossfadeNode(ctx, width, height)
+}
+
+// FXClip places a part of a video on a timeline track.
+type FXClip struct {
+	// Node plays the video. The timeline sets its time and switches it to FXModeClamp.
+	// Clips that overlap must use different nodes.
+	Node FXVideoInputNode
+	// Start is the time on the timeline at which the clip starts.
+	Start time.Duration
+	// In is the time in the video of the first frame of the clip.
+	In time.Duration
+	// Out is the time in the video at which the clip ends. Zero plays to the end of the video.
+	Out time.Duration
+	// Speed is the playback speed, such as 2 for twice as fast. Zero means 1.
+	Speed float64
+	// Transition mixes from the previous clip on the track while the two clips overlap.
+	// If it is nil, the clip cuts in at its start.
+	Transition FXClipTransition
+}
+
+// speed returns the playback speed, defaulting to 1.
+func (c FXClip) speed() float64 {
+	if c.Speed == 0 {
+		return 1
+	}
+	return c.Speed
+}
+
+// out returns the time in the video at which the clip ends.
+func (c FXClip) out() time.Duration {
+	if c.Out == 0 {
+		return c.Node.Info().Duration
+	}
+	return c.Out
+}
+
+// Length returns how long the clip lasts on the timeline, after applying its speed.
+func (c FXClip) Length() time.Duration {
+	return time.Duration(float64(c.out()-c.In) / c.speed())
+}
+
+// End returns the time on the timeline at which the clip ends.
+func (c FXClip) End() time.Duration {
+	return c.Start + c.Length()
+}
+
+// SourceTime returns the time in the video that is shown at time t on the timeline.
+func (c FXClip) SourceTime(t time.Duration) time.Duration {
+	return c.In + time.Duration(float64(t-c.Start)*c.speed())
+}
+
+// FXTimelineTrack is a layer of clips on a timeline.
+type FXTimelineTrack interface {
+	// AddClip adds a clip to the track. Clips are kept ordered by start time.
+	// A clip may overlap the previous clip on the track, for a transition,
+	// but at most two clips can play at the same time.
+	AddClip(clip FXClip) error
+	// GetClips returns the clips ordered by start time.
+	GetClips() []FXClip
+	// SetBlendMode sets how the track is blended over the tracks below it. The default is FXBlendNormal.
+	// The track is composited over the tracks below by its alpha, so its transparent areas,
+	// such as the bars around fitted clips, show the tracks below.
+	SetBlendMode(mode fxblend.FXBlendMode)
+	// SetOpacity sets the opacity of the track over the tracks below it, from 0 to 1. The default is 1.
+	SetOpacity(opacity float32)
+	// Duration returns the time at which the last clip ends.
+	Duration() time.Duration
+}
+
+// fxTimelineTrack implements FXTimelineTrack.
+type fxTimelineTrack struct {
+	// clips are the clips ordered by start time.
+	clips []FXClip
+	// mode is the blend mode over the tracks below.
+	mode fxblend.FXBlendMode
+	// opacity is the opacity over the tracks below.
+	opacity float32
+	// outgoing fits the only or outgoing clip into the timeline frame.
+	outgoing fxnode.FXNode
+	// incoming fits the incoming clip of a transition into the timeline frame.
+	incoming fxnode.FXNode
+	// blend blends the track over the tracks below.
+	blend fxblend.FXBlendNode
+}
+
+func (tr *fxTimelineTrack) AddClip(clip FXClip) error {
+	if clip.Node == nil {
+		return fmt.Errorf("clip has no video node")
+	}
+	if clip.Speed < 0 {
+		return fmt.Errorf("clip speed must not be negative: %v", clip.Speed)
+	}
+	if clip.Start < 0 || clip.In < 0 {
+		return fmt.Errorf("clip start and in point must not be negative")
+	}
+	if clip.out() <= clip.In {
+		return fmt.Errorf("clip out point %v must be after its in point %v", clip.out(), clip.In)
+	}
+
+	// Insert after the clips starting at the same time or earlier, then check the overlaps.
+	i := sort.Search(len(tr.clips), func(i int) bool { return tr.clips[i].Start > clip.Start })
+	clips := make([]FXClip, 0, len(tr.clips)+1)
+	clips = append(clips, tr.clips[:i]...)
+	clips = append(clips, clip)
+	clips = append(clips, tr.clips[i:]...)
+	for j := 1; j < len(clips); j++ {
+		if clips[j].End() <= clips[j-1].End() {
+			return fmt.Errorf("clip at %v would end inside the clip at %v", clips[j].Start, clips[j-1].Start)
+		}
+		if j >= 2 && clips[j].Start < clips[j-2].End() {
+			return fmt.Errorf("clip at %v would overlap two clips", clips[j].Start)
+		}
+	}
+
+	clip.Node.SetMode(FXModeClamp)
+	tr.clips = clips
+	return nil
+}
+
+func (tr *fxTimelineTrack) GetClips() []FXClip {
+	return append([]FXClip(nil), tr.clips...)
+}
+
+func (tr *fxTimelineTrack) SetBlendMode(mode fxblend.FXBlendMode) {
+	tr.mode = mode
+}
+
+func (tr *fxTimelineTrack) SetOpacity(opacity float32) {
+	tr.opacity = opacity
+}
+
+func (tr *fxTimelineTrack) Duration() time.Duration {
+	if len(tr.clips) == 0 {
+		return 0
+	}
+	return tr.clips[len(tr.clips)-1].End()
+}
+
+// connect connects the nodes of the track for time t and returns its output,
+// or nil if no clip plays at t. The nodes are rendered when the timeline is processed,
+// so that each of them renders once per frame.
+func (tr *fxTimelineTrack) connect(t time.Duration) fxnode.FXInput {
+	// 1. Find Clips
+	// The incoming clip is the last one that has started; the previous one may still be playing.
+	i := sort.Search(len(tr.clips), func(i int) bool { return tr.clips[i].Start > t }) - 1
+	if i < 0 || t >= tr.clips[i].End() {
+		return nil
+	}
+	current := tr.clips[i]
+	transition := i > 0 && t < tr.clips[i-1].End() && current.Transition != nil
+
+	// 2. Without Transition
+	if !transition {
+		fitClip(tr.outgoing, current, t)
+		return tr.outgoing
+	}
+
+	// 3. With Transition
+	// The transition lasts for the overlap of the two clips.
+	previous := tr.clips[i-1]
+	fitClip(tr.outgoing, previous, t)
+	fitClip(tr.incoming, current, t)
+	node := current.Transition
+	node.SetInput("u_texture1", tr.outgoing)
+	node.SetInput("u_texture2", tr.incoming)
+	node.SetProgress(float32(t-current.Start) / float32(previous.End()-current.Start))
+	node.MarkDirty()
+	return node
+}
+
+// fitClip connects a clip to a fit node, showing its frame at timeline time t.
+// The clip node decodes the frame when the fit node processes its inputs.
+func fitClip(fit fxnode.FXNode, clip FXClip, t time.Duration) {
+	clip.Node.SetTime(clip.SourceTime(t))
+	setFitInput(fit, clip.Node)
+}
+
+// newFXFitNode creates a node that fits its input into its own size, keeping the aspect ratio.
+func newFXFitNode(ctx fxcontext.FXContext, width, height int) (fxnode.FXNode, error) {
+	base, err := fxnode.NewFXBaseNode(ctx, width, height)
+	if err != nil {
+		return nil, err
+	}
+	program, err := fxcore.NewFXShaderProgram(fxcore.FXSimpleVS, FXTimelineFitFS)
+	if err != nil {
+		base.Release()
+		return nil, err
+	}
+	base.SetShaderProgram(program)
+	base.SetUniform("u_fit", []float32{1, 1})
+	return base, nil
+}
+
+// setFitInput connects the input of a fit node and scales it to fit the node,
+// leaving transparent bars where the aspect ratios differ.
+func setFitInput(fit fxnode.FXNode, input fxnode.FXInput) {
+	fit.SetInput("u_texture", input)
+	width, height := fit.GetTexture().GetSize()
+	inWidth, inHeight := input.GetTexture().GetSize()
+	scale := min(float32(width)/float32(inWidth), float32(height)/float32(inHeight))
+	fit.SetUniform("u_fit", []float32{float32(inWidth) * scale / float32(width), float32(inHeight) * scale / float32(height)})
+	fit.MarkDirty()
+}
+
+// FXTimeline arranges video clips on tracks and composites them into one frame.
+// It is a node, so it can be rendered with FXAnimation or used as the input of other nodes
+// and graphs. Clips are fitted into the frame of the timeline, keeping their aspect ratio.
+type FXTimeline interface {
+	fxnode.FXNode
+	// AddTrack adds a track above the existing ones.
+	AddTrack() (FXTimelineTrack, error)
+	// GetTracks returns the tracks from bottom to top.
+	GetTracks() []FXTimelineTrack
+	// SetTime sets the time on the timeline. The clips playing at that time are shown on the next Process.
+	SetTime(t time.Duration)
+	// Duration returns the time at which the last clip ends.
+	Duration() time.Duration
+}
+
+// fxTimeline implements FXTimeline.
+type fxTimeline struct {
+	fxnode.FXNode
+	// context is the context the internal nodes are created in.
+	context fxcontext.FXContext
+	// width is the width of the timeline frame.
+	width int
+	// height is the height of the timeline frame.
+	height int
+	// tracks are the tracks from bottom to top.
+	tracks []*fxTimelineTrack
+	// currentTime is the time on the timeline.
+	currentTime time.Duration
+	// blank is a transparent texture shown where no clip plays.
+	blank fxcore.FXTexture
+}
+
+// NewFXTimeline creates an empty timeline with the given frame size.
+// The timeline takes ownership of the clip nodes and transitions and releases them on Release.
+func NewFXTimeline(ctx fxcontext.FXContext, width, height int) (FXTimeline, error) {
+	base, err := newFXFitNode(ctx, width, height)
+	if err != nil {
+		return nil, err
+	}
+	blank := fxcore.NewFXTexture(1, 1)
+	blank.Upload(image.NewRGBA(image.Rect(0, 0, 1, 1)))
+	base.SetInput("u_texture", fximage.NewFXImageInput(blank))
+
+	return &fxTimeline{
+		FXNode:  base,
+		context: ctx,
+		width:   width,
+		height:  height,
+		blank:   blank,
+	}, nil
+}
+
+// NewFXTimelineAnimation creates an animation that plays the whole timeline.
+// The update function, if not nil, is called after the timeline time is set at each frame,
+// e.g. to animate effects applied to the timeline. Render it with the timeline, or a node
+// that uses the timeline, as the output node. The audio of the clips is not rendered.
+func NewFXTimelineAnimation(timeline FXTimeline, fps int, update func(t time.Duration)) FXAnimation {
+	return NewFXAnimation(timeline.Duration(), fps, func(t time.Duration) {
+		timeline.SetTime(t)
+		if update != nil {
+			update(t)
+		}
+	})
+}
+
+func (tl *fxTimeline) AddTrack() (FXTimelineTrack, error) {
+	tr := &fxTimelineTrack{opacity: 1}
+	var err error
+	if tr.outgoing, err = newFXFitNode(tl.context, tl.width, tl.height); err != nil {
+		return nil, err
+	}
+	if tr.incoming, err = newFXFitNode(tl.context, tl.width, tl.height); err != nil {
+		tr.outgoing.Release()
+		return nil, err
+	}
+	if tr.blend, err = fxblend.NewFXBlendNode(tl.context, tl.width, tl.height); err != nil {
+		tr.outgoing.Release()
+		tr.incoming.Release()
+		return nil, err
+	}
+	tl.tracks = append(tl.tracks, tr)
+	return tr, nil
+}
+
+func (tl *fxTimeline) GetTracks() []FXTimelineTrack {
+	tracks := make([]FXTimelineTrack, len(tl.tracks))
+	for i, tr := range tl.tracks {
+		tracks[i] = tr
+	}
+	return tracks
+}
+
+func (tl *fxTimeline) SetTime(t time.Duration) {
+	tl.currentTime = t
+}
+
+func (tl *fxTimeline) Duration() time.Duration {
+	var d time.Duration
+	for _, tr := range tl.tracks {
+		d = max(d, tr.Duration())
+	}
+	return d
+}
+
+// IsDirty always returns true: the frame depends on the time, which is only resolved in Process.
+func (tl *fxTimeline) IsDirty() bool {
+	return true
+}
+
+func (tl *fxTimeline) Process(ctx fxcontext.FXContext) error {
+	// 1. Connect Tracks
+	// Blend every track that plays at this time over the ones below it.
+	// The lowest playing track is the background, so its blend mode and opacity are not used.
+	var result fxnode.FXInput
+	for _, tr := range tl.tracks {
+		layer := tr.connect(tl.currentTime)
+		if layer == nil {
+			continue
+		}
+		if result == nil {
+			result = layer
+			continue
+		}
+		tr.blend.SetInput1(result)
+		tr.blend.SetInput2(layer)
+		tr.blend.SetMode(tr.mode)
+		tr.blend.SetFactor(tr.opacity)
+		tr.blend.MarkDirty()
+		result = tr.blend
+	}
+
+	// 2. Output
+	// Render the connected nodes into the output, or clear it if no clip plays.
+	// Processing the output processes every node connected above once.
+	if result == nil {
+		result = fximage.NewFXImageInput(tl.blank)
+	}
+	tl.SetInput("u_texture", result)
+	tl.MarkDirty()
+	if err := tl.FXNode.Process(ctx); err != nil {
+		return fmt.Errorf("failed to render timeline at %v: %w", tl.currentTime, err)
+	}
+	return nil
+}
+
+// Release releases the internal nodes, the clip nodes and the transitions.
+func (tl *fxTimeline) Release() {
+	// A node can be used by several clips that do not overlap, so release each node once.
+	released := make(map[fxnode.FXNode]bool)
+	release := func(node fxnode.FXNode) {
+		if node != nil && !released[node] {
+			released[node] = true
+			node.Release()
+		}
+	}
+	for _, tr := range tl.tracks {
+		for _, clip := range tr.clips {
+			release(clip.Node)
+			if clip.Transition != nil {
+				release(clip.Transition)
+			}
+		}
+		tr.outgoing.Release()
+		tr.incoming.Release()
+		tr.blend.Release()
+	}
+	tl.blank.Release()
+	tl.FXNode.Release()
+}
@@ -0,0 +1,125 @@
+package fxvideo
+
+import (
+	"fmt"
+	"image"
+	"sync"
+
+	"kdfx/pkg/fxcore"
+)
+
+// fxReadbackDepth is the number of frames in flight between rendering and encoding.
+// Three buffers let the GPU render a frame while the previous one is read back
+// and the one before that is encoded.
+const fxReadbackDepth = 3
+
+// fxFrameWriter reads rendered textures back asynchronously and encodes them on a separate goroutine.
+// All methods except the encoding goroutine must be called on the thread owning the GL context.
+type fxFrameWriter struct {
+	// encoder is the stream encoder receiving the frames.
+	encoder FXStreamEncoder
+	// readback is the ring of buffers the textures are read into.
+	readback fxcore.FXReadback
+	// frames queues the read back images for the encoding goroutine.
+	frames chan *image.RGBA
+	// free holds the images available for the next readback.
+	free chan *image.RGBA
+	// done is closed when the encoding goroutine exits.
+	done chan struct{}
+	// started is the number of frames written so far.
+	started int
+	// mu guards err.
+	mu sync.Mutex
+	// err is the first error returned by the encoder.
+	err error
+}
+
+// newFXFrameWriter creates a frame writer for frames of the given size and starts its encoding goroutine.
+func newFXFrameWriter(encoder FXStreamEncoder, width, height, depth int) *fxFrameWriter {
+	w := &fxFrameWriter{
+		encoder:  encoder,
+		readback: fxcore.NewFXReadback(width, height, depth),
+		frames:   make(chan *image.RGBA, depth),
+		free:     make(chan *image.RGBA, depth),
+		done:     make(chan struct{}),
+	}
+	// The images are reused for the whole stream, so encoding does not allocate per frame.
+	for i := 0; i < depth; i++ {
+		w.free <- image.NewRGBA(image.Rect(0, 0, width, height))
+	}
+	go w.encode()
+	return w
+}
+
+// encode passes the queued frames to the encoder until the frames channel is closed.
+// After an error the remaining frames are discarded so the writer never blocks.
+func (w *fxFrameWriter) encode() {
+	defer close(w.done)
+	index := 0
+	for img := range w.frames {
+		if w.error() == nil {
+			if err := w.encoder.AddFrame(img); err != nil {
+				w.mu.Lock()
+				w.err = fmt.Errorf("failed to add frame %d to encoder: %w", index, err)
+				w.mu.Unlock()
+			}
+		}
+		index++
+		w.free <- img
+	}
+}
+
+// error returns the first encoder error, if any.
+func (w *fxFrameWriter) error() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Write queues the readback of a rendered texture. The texture can be rendered to again
+// as soon as Write returns. Once all buffers are in flight, the oldest frame is passed
+// to the encoder first.
+func (w *fxFrameWriter) Write(tex fxcore.FXTexture) error {
+	if err := w.error(); err != nil {
+		return err
+	}
+	if w.readback.Pending() == w.readback.Depth() {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+	if err := w.readback.Start(tex); err != nil {
+		return fmt.Errorf("failed to download texture at frame %d: %w", w.started, err)
+	}
+	w.started++
+	return nil
+}
+
+// flush completes the oldest pending readback and queues it for encoding.
+// It blocks while all images are waiting to be encoded.
+func (w *fxFrameWriter) flush() error {
+	index := w.started - w.readback.Pending()
+	img := <-w.free
+	if err := w.readback.Finish(img); err != nil {
+		w.free <- img
+		return fmt.Errorf("failed to download texture at frame %d: %w", index, err)
+	}
+	w.frames <- img
+	return nil
+}
+
+// Close encodes the pending frames, waits for the encoding goroutine and releases the readback buffers.
+// It does not close the encoder.
+func (w *fxFrameWriter) Close() error {
+	var err error
+	for w.readback.Pending() > 0 && err == nil {
+		err = w.flush()
+	}
+	close(w.frames)
+	<-w.done
+	w.readback.Release()
+	if encodeErr := w.error(); encodeErr != nil {
+		return encodeErr
+	}
+	return err
+}
@@ -0,0 +1,451 @@
+package fxvideo
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// fxY4MFrameHeader is the header of a frame without parameters, which is what almost all writers emit.
+const fxY4MFrameHeader = "FRAME\n"
+
+// FXY4MOptions configures the conversion between RGB and the YUV frames of a Y4M (YUV4MPEG2) stream.
+type FXY4MOptions struct {
+	// Chroma is the chroma subsampling of encoded frames.
+	// Decoders take it from the stream header instead.
+	Chroma FXChromaSubsampling
+	// Matrix selects the RGB to YUV coefficients. Y4M streams do not record it.
+	Matrix FXColorMatrix
+	// FullRange uses the full 0-255 range for YUV instead of the limited 16-235 video range.
+	// Decoders use the XCOLORRANGE tag of the stream header instead, if it is present.
+	FullRange bool
+}
+
+// fxY4MHeader holds the parameters of a Y4M stream header.
+type fxY4MHeader struct {
+	// width is the frame width in pixels.
+	width int
+	// height is the frame height in pixels.
+	height int
+	// rate is the frame rate.
+	rate FXRational
+	// chroma is the chroma subsampling.
+	chroma FXChromaSubsampling
+	// alpha is true if frames have an alpha plane after the chroma planes.
+	alpha bool
+	// fullRange is true if the XCOLORRANGE tag is FULL.
+	fullRange bool
+	// hasRange is true if the header has an XCOLORRANGE tag.
+	hasRange bool
+	// aspect is the pixel aspect ratio, or 0/0 if it is unknown.
+	aspect FXRational
+}
+
+// frameSize returns the size in bytes of the planes of one frame.
+func (h fxY4MHeader) frameSize() int {
+	luma, chroma := h.chroma.planeSizes(h.width, h.height)
+	size := luma + 2*chroma
+	if h.alpha {
+		size += luma
+	}
+	return size
+}
+
+// parseY4MHeader parses a stream header line, without the trailing newline.
+func parseY4MHeader(line string) (fxY4MHeader, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "YUV4MPEG2" {
+		return fxY4MHeader{}, fmt.Errorf("not a Y4M stream")
+	}
+
+	// Streams without a C tag are 4:2:0.
+	header := fxY4MHeader{chroma: FXChroma420}
+	for _, field := range fields[1:] {
+		value := field[1:]
+		var err error
+		switch field[0] {
+		case 'W':
+			header.width, err = strconv.Atoi(value)
+		case 'H':
+			header.height, err = strconv.Atoi(value)
+		case 'F':
+			num, den, _ := strings.Cut(value, ":")
+			header.rate, err = FXParseRational(num + "/" + den)
+		case 'C':
+			switch value {
+			case "420jpeg", "420paldv", "420mpeg2", "420":
+				// The variants differ only in chroma siting, which does not matter for nearest sampling.
+				header.chroma = FXChroma420
+			case "422":
+				header.chroma = FXChroma422
+			case "444":
+				header.chroma = FXChroma444
+			case "444alpha":
+				header.chroma, header.alpha = FXChroma444, true
+			case "mono":
+				header.chroma = FXChromaMono
+			default:
+				return fxY4MHeader{}, fmt.Errorf("unsupported Y4M color space %s", value)
+			}
+		case 'A':
+			// A0:0 means unknown, which is left as 0/0.
+			if num, den, _ := strings.Cut(value, ":"); num != "0" || den != "0" {
+				header.aspect, err = FXParseRational(num + "/" + den)
+			}
+		case 'X':
+			if key, v, ok := strings.Cut(value, "="); ok && key == "COLORRANGE" {
+				header.hasRange = true
+				header.fullRange = v == "FULL"
+			}
+		}
+		// Interlacing (I) does not change how frames are stored.
+		if err != nil {
+			return fxY4MHeader{}, fmt.Errorf("invalid Y4M header field %s: %w", field, err)
+		}
+	}
+
+	if header.width <= 0 || header.height <= 0 {
+		return fxY4MHeader{}, fmt.Errorf("invalid Y4M frame size %dx%d", header.width, header.height)
+	}
+	if !header.rate.IsValid() {
+		return fxY4MHeader{}, fmt.Errorf("invalid Y4M frame rate %s", header.rate)
+	}
+	return header, nil
+}
+
+// fxY4MStreamDecoder implements FXStreamDecoder for Y4M streams without ffmpeg.
+type fxY4MStreamDecoder struct {
+	// source is the stream being decoded.
+	source io.Reader
+	// reader buffers the source.
+	reader *bufio.Reader
+	// seeker is the source if it can seek, or nil.
+	seeker io.Seeker
+	// closer is closed by Close, or nil if the caller owns the source.
+	closer io.Closer
+	// header holds the stream parameters.
+	header fxY4MHeader
+	// info contains metadata about the video.
+	info FXVideoInfo
+	// converter converts the frames to RGBA.
+	converter *fxYUVConverter
+	// headerSize is the size in bytes of the stream header.
+	headerSize int64
+	// fixedFrames is true while every frame header read so far had no parameters,
+	// so frame n starts at a known offset.
+	fixedFrames bool
+	// buf holds the planes of the frame being read.
+	buf []byte
+	// frame is the index of the next frame to be read.
+	frame int
+}
+
+// NewFXY4MStreamDecoder creates a decoder for a Y4M stream. It does not need ffmpeg.
+// If the reader is an io.Seeker, such as an *os.File, seeking jumps directly to the frame
+// and the frame count is known. Otherwise only forward seeking is possible.
+// The reader is not closed by Close.
+func NewFXY4MStreamDecoder(reader io.Reader, options FXY4MOptions) (FXStreamDecoder, error) {
+	// 1. Header
+	buffered := bufio.NewReader(reader)
+	line, err := buffered.ReadSlice('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Y4M header: %w", err)
+	}
+	header, err := parseY4MHeader(string(bytes.TrimSuffix(line, []byte("\n"))))
+	if err != nil {
+		return nil, err
+	}
+	fullRange := options.FullRange
+	if header.hasRange {
+		fullRange = header.fullRange
+	}
+
+	d := &fxY4MStreamDecoder{
+		source:      reader,
+		reader:      buffered,
+		header:      header,
+		converter:   newFXYUVConverter(options.Matrix.resolve(header.height), fullRange),
+		headerSize:  int64(len(line)),
+		fixedFrames: true,
+		buf:         make([]byte, header.frameSize()),
+	}
+
+	// 2. Frame count
+	// Frames have a fixed size, so a seekable stream's length gives the count.
+	frameCount := 0
+	if seeker, ok := reader.(io.Seeker); ok {
+		if end, err := seeker.Seek(0, io.SeekEnd); err == nil {
+			d.seeker = seeker
+			frameCount = int((end - d.headerSize) / int64(len(fxY4MFrameHeader)+len(d.buf)))
+			if err := d.seekOffset(d.headerSize); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	d.info = FXVideoInfo{
+		Width:      header.width,
+		Height:     header.height,
+		FPS:        int(math.Round(header.rate.Float64())),
+		FrameRate:  header.rate,
+		FrameCount: frameCount,
+		Duration:   header.rate.FrameTime(frameCount),
+		// Unknown aspect ratios mean square pixels.
+		SampleAspectRatio: FXRational{Num: 1, Den: 1},
+	}
+	if header.aspect.IsValid() {
+		d.info.SampleAspectRatio = header.aspect
+	}
+	return d, nil
+}
+
+// NewFXY4MFileDecoder opens a Y4M file and creates a decoder for it.
+// The file is closed by Close.
+func NewFXY4MFileDecoder(path string, options FXY4MOptions) (FXStreamDecoder, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	decoder, err := NewFXY4MStreamDecoder(file, options)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	decoder.(*fxY4MStreamDecoder).closer = file
+	return decoder, nil
+}
+
+// seekOffset moves the source to the given offset and discards the buffered data.
+func (d *fxY4MStreamDecoder) seekOffset(offset int64) error {
+	if _, err := d.seeker.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek: %w", err)
+	}
+	d.reader.Reset(d.source)
+	return nil
+}
+
+func (d *fxY4MStreamDecoder) Seek(t time.Duration) error {
+	return d.SeekFrame(d.info.FrameIndex(t))
+}
+
+func (d *fxY4MStreamDecoder) SeekFrame(n int) error {
+	if n < 0 {
+		return fmt.Errorf("invalid frame index %d", n)
+	}
+	if n == d.frame {
+		return nil
+	}
+
+	// Jump straight to the frame if the frame offsets are known.
+	if d.seeker != nil && d.fixedFrames {
+		offset := d.headerSize + int64(n)*int64(len(fxY4MFrameHeader)+len(d.buf))
+		if err := d.seekOffset(offset); err != nil {
+			return err
+		}
+		d.frame = n
+		return nil
+	}
+
+	// Otherwise read forward, from the start if the frame is behind.
+	if n < d.frame {
+		if d.seeker == nil {
+			return fmt.Errorf("cannot seek back to frame %d in a stream that is not seekable", n)
+		}
+		if err := d.seekOffset(d.headerSize); err != nil {
+			return err
+		}
+		d.frame = 0
+	}
+	for d.frame < n {
+		if err := d.readFrameData(); err != nil {
+			return err
+		}
+		d.frame++
+	}
+	return nil
+}
+
+func (d *fxY4MStreamDecoder) FrameAt(n int, img *image.RGBA) error {
+	if err := d.SeekFrame(n); err != nil {
+		return err
+	}
+	return d.ReadFrame(img)
+}
+
+func (d *fxY4MStreamDecoder) ReadFrame(img *image.RGBA) error {
+	if img.Rect.Dx() != d.info.Width || img.Rect.Dy() != d.info.Height {
+		return fmt.Errorf("image dimension mismatch: expected %dx%d, got %dx%d", d.info.Width, d.info.Height, img.Rect.Dx(), img.Rect.Dy())
+	}
+	if err := d.readFrameData(); err != nil {
+		return err
+	}
+
+	// Split the frame into its planes: Y, U, V and optionally alpha.
+	luma, chroma := d.header.chroma.planeSizes(d.info.Width, d.info.Height)
+	y := d.buf[:luma]
+	u := d.buf[luma : luma+chroma]
+	v := d.buf[luma+chroma : luma+2*chroma]
+	var alpha []byte
+	if d.header.alpha {
+		alpha = d.buf[luma+2*chroma:]
+	}
+	d.converter.toRGBA(y, u, v, alpha, img, d.header.chroma)
+
+	d.frame++
+	return nil
+}
+
+// readFrameData reads the next frame header and the frame planes into buf.
+// It returns io.EOF at the end of the stream.
+func (d *fxY4MStreamDecoder) readFrameData() error {
+	line, err := d.reader.ReadSlice('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(line) == 0 {
+			return io.EOF
+		}
+		return fmt.Errorf("failed to read header of frame %d: %w", d.frame, err)
+	}
+	if !bytes.HasPrefix(line, []byte("FRAME")) {
+		return fmt.Errorf("invalid header of frame %d", d.frame)
+	}
+	if len(line) != len(fxY4MFrameHeader) {
+		// Frame parameters change the frame size, so offsets can no longer be computed.
+		d.fixedFrames = false
+	}
+
+	if _, err := io.ReadFull(d.reader, d.buf); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("failed to read frame %d: %w", d.frame, err)
+	}
+	return nil
+}
+
+func (d *fxY4MStreamDecoder) FrameIndex() int {
+	return d.frame
+}
+
+func (d *fxY4MStreamDecoder) Close() error {
+	if d.closer != nil {
+		return d.closer.Close()
+	}
+	return nil
+}
+
+func (d *fxY4MStreamDecoder) Info() FXVideoInfo {
+	return d.info
+}
+
+// fxY4MStreamEncoder implements FXStreamEncoder for Y4M output without ffmpeg.
+// The output can be piped to any tool that reads Y4M, including ffmpeg and most encoders.
+type fxY4MStreamEncoder struct {
+	// writer receives the Y4M stream.
+	writer io.Writer
+	// width is the width of the video.
+	width int
+	// height is the height of the video.
+	height int
+	// chroma is the chroma subsampling of the frames.
+	chroma FXChromaSubsampling
+	// converter converts the frames to YUV.
+	converter *fxYUVConverter
+	// buf holds the frame header followed by the frame planes.
+	buf []byte
+	// closed is true after Close.
+	closed bool
+	// aborted is set by Abort; later frames are then not written.
+	aborted atomic.Bool
+}
+
+// NewFXY4MStreamEncoder creates an encoder that writes a Y4M stream to the provided writer.
+// It does not need ffmpeg. The alpha channel of the frames is dropped.
+func NewFXY4MStreamEncoder(writer io.Writer, width, height, fps int, options FXY4MOptions) (FXStreamEncoder, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid size %dx%d", width, height)
+	}
+	if fps <= 0 {
+		return nil, fmt.Errorf("invalid frame rate %d", fps)
+	}
+
+	// The C tag names the subsampling; 4:2:0 chroma is averaged over each block, which is centered (jpeg) siting.
+	var colorSpace string
+	switch options.Chroma {
+	case FXChroma420:
+		colorSpace = "420jpeg"
+	case FXChroma422, FXChroma444, FXChromaMono:
+		colorSpace = options.Chroma.String()
+	default:
+		return nil, fmt.Errorf("unsupported chroma subsampling %s", options.Chroma)
+	}
+	colorRange := "LIMITED"
+	if options.FullRange {
+		colorRange = "FULL"
+	}
+
+	header := fmt.Sprintf("YUV4MPEG2 W%d H%d F%d:1 Ip A1:1 C%s XCOLORRANGE=%s\n", width, height, fps, colorSpace, colorRange)
+	if _, err := io.WriteString(writer, header); err != nil {
+		return nil, fmt.Errorf("failed to write Y4M header: %w", err)
+	}
+
+	luma, chroma := options.Chroma.planeSizes(width, height)
+	buf := make([]byte, len(fxY4MFrameHeader)+luma+2*chroma)
+	copy(buf, fxY4MFrameHeader)
+	return &fxY4MStreamEncoder{
+		writer:    writer,
+		width:     width,
+		height:    height,
+		chroma:    options.Chroma,
+		converter: newFXYUVConverter(options.Matrix.resolve(height), options.FullRange),
+		buf:       buf,
+	}, nil
+}
+
+func (e *fxY4MStreamEncoder) AddFrame(img *image.RGBA) error {
+	if e.closed {
+		return fmt.Errorf("encoder is closed")
+	}
+	if e.aborted.Load() {
+		return fmt.Errorf("encoder is aborted")
+	}
+	if img.Rect.Dx() != e.width || img.Rect.Dy() != e.height {
+		return fmt.Errorf("frame dimension mismatch: expected %dx%d, got %dx%d", e.width, e.height, img.Rect.Dx(), img.Rect.Dy())
+	}
+
+	planes := e.buf[len(fxY4MFrameHeader):]
+	luma, chroma := e.chroma.planeSizes(e.width, e.height)
+	e.converter.fromRGBA(img, planes[:luma], planes[luma:luma+chroma], planes[luma+chroma:], e.chroma)
+
+	// Write the frame header and planes in one call, so pipes see whole frames.
+	if _, err := e.writer.Write(e.buf); err != nil {
+		return fmt.Errorf("failed to write frame: %w", err)
+	}
+	return nil
+}
+
+// Abort stops writing frames. If the writer is an io.Closer, such as a pipe to a stalled
+// consumer, it is closed so that a pending write fails.
+func (e *fxY4MStreamEncoder) Abort() error {
+	e.aborted.Store(true)
+	if closer, ok := e.writer.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			return fmt.Errorf("failed to close writer: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close finishes the stream. It does not close the writer, unless Abort did.
+func (e *fxY4MStreamEncoder) Close() error {
+	e.closed = true
+	return nil
+}
@@ -0,0 +1,235 @@
+package fxvideo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"testing"
+)
+
+// testY4MFrame returns a frame whose colors are constant over each 2x2 block,
+// so 4:2:0 subsampling does not lose any chroma.
+func testY4MFrame(width, height, seed int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			bx, by := x/2, y/2
+			img.SetRGBA(x, y, color.RGBA{
+				R: uint8((bx*70 + seed*40) % 256),
+				G: uint8((by*90 + seed*25) % 256),
+				B: uint8((bx*by*50 + 30 + seed*60) % 256),
+				A: 255,
+			})
+		}
+	}
+	return img
+}
+
+// solidY4MFrame returns a gray frame of the given level.
+func solidY4MFrame(width, height int, level uint8) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = level
+	}
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+	return img
+}
+
+// encodeY4M encodes the frames into a Y4M stream at 30 fps.
+func encodeY4M(t *testing.T, options FXY4MOptions, frames ...*image.RGBA) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	size := frames[0].Rect.Size()
+	encoder, err := NewFXY4MStreamEncoder(&buf, size.X, size.Y, 30, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, frame := range frames {
+		if err := encoder.AddFrame(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// compareY4MFrames fails if a channel of two frames differs by more than tolerance.
+func compareY4MFrames(t *testing.T, got, want *image.RGBA, tolerance int) {
+	t.Helper()
+	for i := range want.Pix {
+		if d := int(got.Pix[i]) - int(want.Pix[i]); d > tolerance || d < -tolerance {
+			p := i / 4
+			t.Fatalf("pixel (%d, %d) channel %d: got %d, want %d",
+				p%want.Rect.Dx(), p/want.Rect.Dx(), i%4, got.Pix[i], want.Pix[i])
+		}
+	}
+}
+
+func TestY4MRoundTrip(t *testing.T) {
+	const width, height = 16, 10
+	frames := []*image.RGBA{testY4MFrame(width, height, 0), testY4MFrame(width, height, 1)}
+
+	for _, chroma := range []FXChromaSubsampling{FXChroma420, FXChroma444} {
+		for _, matrix := range []FXColorMatrix{FXMatrixBT601, FXMatrixBT709} {
+			for _, fullRange := range []bool{false, true} {
+				name := fmt.Sprintf("%s/%s/full=%v", chroma, matrix, fullRange)
+				t.Run(name, func(t *testing.T) {
+					data := encodeY4M(t, FXY4MOptions{Chroma: chroma, Matrix: matrix, FullRange: fullRange}, frames...)
+
+					// The range comes from the header, so the decoder is not told about it.
+					decoder, err := NewFXY4MStreamDecoder(bytes.NewReader(data), FXY4MOptions{Matrix: matrix})
+					if err != nil {
+						t.Fatal(err)
+					}
+					defer decoder.Close()
+
+					info := decoder.Info()
+					if info.Width != width || info.Height != height || info.FrameCount != len(frames) {
+						t.Fatalf("info = %dx%d with %d frames, want %dx%d with %d",
+							info.Width, info.Height, info.FrameCount, width, height, len(frames))
+					}
+					if info.FrameRate != (FXRational{Num: 30, Den: 1}) {
+						t.Fatalf("frame rate = %s, want 30/1", info.FrameRate)
+					}
+
+					img := image.NewRGBA(image.Rect(0, 0, width, height))
+					for _, want := range frames {
+						if err := decoder.ReadFrame(img); err != nil {
+							t.Fatal(err)
+						}
+						compareY4MFrames(t, img, want, 3)
+					}
+					if err := decoder.ReadFrame(img); !errors.Is(err, io.EOF) {
+						t.Fatalf("read past the end: got %v, want io.EOF", err)
+					}
+				})
+			}
+		}
+	}
+}
+
+// nonSeekableReader hides the Seek method of a reader.
+type nonSeekableReader struct {
+	io.Reader
+}
+
+func TestY4MSeekFrame(t *testing.T) {
+	const width, height = 4, 4
+	levels := []uint8{20, 60, 100, 140, 180}
+	var frames []*image.RGBA
+	for _, level := range levels {
+		frames = append(frames, solidY4MFrame(width, height, level))
+	}
+	data := encodeY4M(t, FXY4MOptions{Chroma: FXChroma444, FullRange: true}, frames...)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	// frameAt reads frame n and checks its level.
+	frameAt := func(t *testing.T, decoder FXStreamDecoder, n int) {
+		t.Helper()
+		if err := decoder.FrameAt(n, img); err != nil {
+			t.Fatalf("frame %d: %v", n, err)
+		}
+		compareY4MFrames(t, img, frames[n], 2)
+		if got := decoder.FrameIndex(); got != n+1 {
+			t.Fatalf("after frame %d: FrameIndex = %d, want %d", n, got, n+1)
+		}
+	}
+
+	t.Run("seekable", func(t *testing.T) {
+		decoder, err := NewFXY4MStreamDecoder(bytes.NewReader(data), FXY4MOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer decoder.Close()
+
+		for _, n := range []int{3, 1, 4, 0, 0, 2} {
+			frameAt(t, decoder, n)
+		}
+		if err := decoder.SeekFrame(-1); err == nil {
+			t.Fatal("seeking to frame -1 succeeded")
+		}
+	})
+
+	t.Run("not seekable", func(t *testing.T) {
+		decoder, err := NewFXY4MStreamDecoder(nonSeekableReader{bytes.NewReader(data)}, FXY4MOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer decoder.Close()
+
+		if got := decoder.Info().FrameCount; got != 0 {
+			t.Fatalf("FrameCount = %d, want 0 for a stream that is not seekable", got)
+		}
+		frameAt(t, decoder, 1)
+		frameAt(t, decoder, 3)
+		if err := decoder.SeekFrame(2); err == nil {
+			t.Fatal("seeking back succeeded on a stream that is not seekable")
+		}
+		frameAt(t, decoder, 4)
+		if err := decoder.ReadFrame(img); !errors.Is(err, io.EOF) {
+			t.Fatalf("read past the end: got %v, want io.EOF", err)
+		}
+	})
+}
+
+func TestParseY4MHeader(t *testing.T) {
+	tests := []struct {
+		line string
+		want fxY4MHeader
+	}{
+		{
+			line: "YUV4MPEG2 W640 H480 F30000:1001 Ip A0:0 C444 XCOLORRANGE=FULL",
+			want: fxY4MHeader{
+				width: 640, height: 480, rate: FXRational{Num: 30000, Den: 1001},
+				chroma: FXChroma444, fullRange: true, hasRange: true,
+			},
+		},
+		{
+			line: "YUV4MPEG2 W720 H576 F25:1 It A16:15 C420mpeg2 XYSCSS=420MPEG2 XCOLORRANGE=LIMITED",
+			want: fxY4MHeader{
+				width: 720, height: 576, rate: FXRational{Num: 25, Den: 1},
+				chroma: FXChroma420, hasRange: true, aspect: FXRational{Num: 16, Den: 15},
+			},
+		},
+		{
+			// Streams without C and XCOLORRANGE tags are 4:2:0 with an unknown range.
+			line: "YUV4MPEG2 W8 H6 F24:1",
+			want: fxY4MHeader{width: 8, height: 6, rate: FXRational{Num: 24, Den: 1}, chroma: FXChroma420},
+		},
+		{
+			line: "YUV4MPEG2 W8 H6 F24:1 C444alpha",
+			want: fxY4MHeader{width: 8, height: 6, rate: FXRational{Num: 24, Den: 1}, chroma: FXChroma444, alpha: true},
+		},
+	}
+	for _, test := range tests {
+		got, err := parseY4MHeader(test.line)
+		if err != nil {
+			t.Errorf("parseY4MHeader(%q): %v", test.line, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("parseY4MHeader(%q) = %+v, want %+v", test.line, got, test.want)
+		}
+	}
+
+	for _, line := range []string{
+		"",
+		"MPEG2 W8 H6 F24:1",
+		"YUV4MPEG2 W0 H6 F24:1",
+		"YUV4MPEG2 W8 H6",
+		"YUV4MPEG2 W8 H6 F24:0",
+		"YUV4MPEG2 W8 H6 F24:1 C411",
+		"YUV4MPEG2 Wx H6 F24:1",
+	} {
+		if _, err := parseY4MHeader(line); err == nil {
+			t.Errorf("parseY4MHeader(%q) succeeded, want an error", line)
+		}
+	}
+}
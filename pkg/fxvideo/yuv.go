@@ -0,0 +1,255 @@
+package fxvideo
+
+import (
+	"fmt"
+	"image"
+)
+
+// FXColorMatrix selects the coefficients used to convert between RGB and YUV.
+type FXColorMatrix int
+
+const (
+	// FXMatrixAuto uses BT.709 for videos at least 720 pixels high and BT.601 otherwise,
+	// which matches what most players assume for untagged video.
+	FXMatrixAuto FXColorMatrix = iota
+	// FXMatrixBT601 is the standard definition matrix.
+	FXMatrixBT601
+	// FXMatrixBT709 is the high definition matrix.
+	FXMatrixBT709
+)
+
+// String returns the name of the matrix.
+func (m FXColorMatrix) String() string {
+	switch m {
+	case FXMatrixAuto:
+		return "auto"
+	case FXMatrixBT601:
+		return "bt601"
+	case FXMatrixBT709:
+		return "bt709"
+	default:
+		return fmt.Sprintf("FXColorMatrix(%d)", int(m))
+	}
+}
+
+// resolve returns the matrix to use for a video of the given height.
+func (m FXColorMatrix) resolve(height int) FXColorMatrix {
+	if m != FXMatrixAuto {
+		return m
+	}
+	if height >= 720 {
+		return FXMatrixBT709
+	}
+	return FXMatrixBT601
+}
+
+// FXChromaSubsampling is the resolution of the chroma planes relative to the luma plane.
+type FXChromaSubsampling int
+
+const (
+	// FXChroma420 halves the chroma resolution horizontally and vertically.
+	FXChroma420 FXChromaSubsampling = iota
+	// FXChroma422 halves the chroma resolution horizontally.
+	FXChroma422
+	// FXChroma444 keeps the chroma at full resolution.
+	FXChroma444
+	// FXChromaMono has no chroma planes; frames are grayscale.
+	FXChromaMono
+)
+
+// String returns the name of the subsampling, as in "420".
+func (s FXChromaSubsampling) String() string {
+	switch s {
+	case FXChroma420:
+		return "420"
+	case FXChroma422:
+		return "422"
+	case FXChroma444:
+		return "444"
+	case FXChromaMono:
+		return "mono"
+	default:
+		return fmt.Sprintf("FXChromaSubsampling(%d)", int(s))
+	}
+}
+
+// shifts returns the base 2 logarithms of the horizontal and vertical subsampling factors.
+func (s FXChromaSubsampling) shifts() (h, v uint) {
+	switch s {
+	case FXChroma420:
+		return 1, 1
+	case FXChroma422:
+		return 1, 0
+	default:
+		return 0, 0
+	}
+}
+
+// planeSizes returns the sizes in bytes of the luma plane and of each chroma plane
+// for a frame of the given size.
+func (s FXChromaSubsampling) planeSizes(width, height int) (luma, chroma int) {
+	if s == FXChromaMono {
+		return width * height, 0
+	}
+	h, v := s.shifts()
+	return width * height, chromaSize(width, h) * chromaSize(height, v)
+}
+
+// chromaSize returns the chroma plane dimension for a luma dimension and subsampling shift,
+// rounding up so that odd sizes keep their last column or row.
+func chromaSize(size int, shift uint) int {
+	return (size + 1<<shift - 1) >> shift
+}
+
+// fxYUVConverter converts 8-bit RGB to and from YUV with fixed point arithmetic.
+// Coefficients are scaled by 1<<16.
+type fxYUVConverter struct {
+	// yOffset is the luma value of black: 16 for limited range, 0 for full range.
+	yOffset int32
+	// yr, yg and yb are the RGB to luma coefficients.
+	yr, yg, yb int32
+	// ur, ug and ub are the RGB to blue difference (Cb) coefficients.
+	ur, ug, ub int32
+	// vr, vg and vb are the RGB to red difference (Cr) coefficients.
+	vr, vg, vb int32
+	// ys is the luma to RGB scale.
+	ys int32
+	// rv is the Cr contribution to red.
+	rv int32
+	// gu and gv are the Cb and Cr contributions to green.
+	gu, gv int32
+	// bu is the Cb contribution to blue.
+	bu int32
+}
+
+// newFXYUVConverter creates a converter for the given matrix and range.
+// The matrix must not be FXMatrixAuto.
+func newFXYUVConverter(matrix FXColorMatrix, fullRange bool) *fxYUVConverter {
+	// Kr and Kb are the luma weights of red and blue; green gets the rest.
+	kr, kb := 0.299, 0.114
+	if matrix == FXMatrixBT709 {
+		kr, kb = 0.2126, 0.0722
+	}
+	kg := 1 - kr - kb
+
+	// Limited range maps luma to 16-235 and chroma to 16-240.
+	yRange, cRange, yOffset := 219.0, 224.0, int32(16)
+	if fullRange {
+		yRange, cRange, yOffset = 255, 255, 0
+	}
+	fixed := func(f float64) int32 {
+		if f < 0 {
+			return int32(f*65536 - 0.5)
+		}
+		return int32(f*65536 + 0.5)
+	}
+
+	ys, cs := yRange/255, cRange/255
+	return &fxYUVConverter{
+		yOffset: yOffset,
+		yr:      fixed(kr * ys),
+		yg:      fixed(kg * ys),
+		yb:      fixed(kb * ys),
+		ur:      fixed(-kr / (2 * (1 - kb)) * cs),
+		ug:      fixed(-kg / (2 * (1 - kb)) * cs),
+		ub:      fixed(0.5 * cs),
+		vr:      fixed(0.5 * cs),
+		vg:      fixed(-kg / (2 * (1 - kr)) * cs),
+		vb:      fixed(-kb / (2 * (1 - kr)) * cs),
+		ys:      fixed(1 / ys),
+		rv:      fixed(2 * (1 - kr) / cs),
+		gu:      fixed(-2 * (1 - kb) * kb / kg / cs),
+		gv:      fixed(-2 * (1 - kr) * kr / kg / cs),
+		bu:      fixed(2 * (1 - kb) / cs),
+	}
+}
+
+// fromRGBA converts an image to YUV planes. Chroma is the average of each subsampled block.
+// The alpha channel is ignored.
+func (c *fxYUVConverter) fromRGBA(img *image.RGBA, y, u, v []byte, chroma FXChromaSubsampling) {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+
+	// 1. Luma
+	for py := 0; py < height; py++ {
+		row := img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+py):]
+		out := y[py*width : (py+1)*width]
+		for px := range out {
+			r, g, b := int32(row[px*4]), int32(row[px*4+1]), int32(row[px*4+2])
+			out[px] = clampByte(c.yOffset + (c.yr*r+c.yg*g+c.yb*b+1<<15)>>16)
+		}
+	}
+	if chroma == FXChromaMono {
+		return
+	}
+
+	// 2. Chroma
+	// Averaging RGB before converting is the same as averaging U and V, because the conversion is linear.
+	hs, vs := chroma.shifts()
+	cw, ch := chromaSize(width, hs), chromaSize(height, vs)
+	for cy := 0; cy < ch; cy++ {
+		for cx := 0; cx < cw; cx++ {
+			var r, g, b, n int32
+			for py := cy << vs; py < min((cy+1)<<vs, height); py++ {
+				for px := cx << hs; px < min((cx+1)<<hs, width); px++ {
+					i := img.PixOffset(img.Rect.Min.X+px, img.Rect.Min.Y+py)
+					r += int32(img.Pix[i])
+					g += int32(img.Pix[i+1])
+					b += int32(img.Pix[i+2])
+					n++
+				}
+			}
+			r, g, b = (r+n/2)/n, (g+n/2)/n, (b+n/2)/n
+			u[cy*cw+cx] = clampByte(128 + (c.ur*r+c.ug*g+c.ub*b+1<<15)>>16)
+			v[cy*cw+cx] = clampByte(128 + (c.vr*r+c.vg*g+c.vb*b+1<<15)>>16)
+		}
+	}
+}
+
+// toRGBA converts YUV planes to an image, repeating each chroma sample over its block.
+// Alpha is taken from the straight alpha plane if it is not nil, and is opaque otherwise.
+func (c *fxYUVConverter) toRGBA(y, u, v, alpha []byte, img *image.RGBA, chroma FXChromaSubsampling) {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	hs, vs := chroma.shifts()
+	cw := chromaSize(width, hs)
+
+	for py := 0; py < height; py++ {
+		row := img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+py):]
+		for px := 0; px < width; px++ {
+			l := c.ys * (int32(y[py*width+px]) - c.yOffset)
+			var r, g, b int32
+			if chroma == FXChromaMono {
+				r, g, b = l, l, l
+			} else {
+				ci := (py>>vs)*cw + px>>hs
+				cb, cr := int32(u[ci])-128, int32(v[ci])-128
+				r = l + c.rv*cr
+				g = l + c.gu*cb + c.gv*cr
+				b = l + c.bu*cb
+			}
+			out := row[px*4 : px*4+4]
+			out[0] = clampByte((r + 1<<15) >> 16)
+			out[1] = clampByte((g + 1<<15) >> 16)
+			out[2] = clampByte((b + 1<<15) >> 16)
+			out[3] = 255
+			if alpha != nil {
+				// image.RGBA is premultiplied, while YUV alpha is straight.
+				a := uint16(alpha[py*width+px])
+				out[0] = byte((uint16(out[0])*a + 127) / 255)
+				out[1] = byte((uint16(out[1])*a + 127) / 255)
+				out[2] = byte((uint16(out[2])*a + 127) / 255)
+				out[3] = byte(a)
+			}
+		}
+	}
+}
+
+// clampByte clamps a value to the range of a byte.
+func clampByte(x int32) byte {
+	if x < 0 {
+		return 0
+	}
+	if x > 255 {
+		return 255
+	}
+	return byte(x)
+}